@@ -12,6 +12,8 @@ import (
 const (
 	DashboardReferencesTypeSearch        dashboardReferencesType = "search"
 	DashboardReferencesTypeVisualization dashboardReferencesType = "visualization"
+	DashboardReferencesTypeIndexPattern  dashboardReferencesType = "index-pattern"
+	DashboardReferencesTypeLens          dashboardReferencesType = "lens"
 )
 
 type dashboardReferencesType string
@@ -26,6 +28,8 @@ type DashboardClient interface {
 	List() ([]*Dashboard, error)
 	Update(id string, request *UpdateDashboardRequest) (*Dashboard, error)
 	Delete(id string) error
+	DeepDelete(id string) error
+	DeepClone(id string, options *DashboardCloneOptions) (*Dashboard, error)
 }
 
 type CreateDashboardRequest struct {
@@ -60,6 +64,9 @@ type DashboardAttributes struct {
 	OptionsJson           string                       `json:"optionsJSON"`
 	UiStateJSON           string                       `json:"uiStateJSON,omitempty"`
 	TimeRestore           bool                         `json:"timeRestore"`
+	TimeFrom              string                       `json:"timeFrom,omitempty"`
+	TimeTo                string                       `json:"timeTo,omitempty"`
+	RefreshInterval       *DashboardRefreshInterval    `json:"refreshInterval,omitempty"`
 	KibanaSavedObjectMeta *SearchKibanaSavedObjectMeta `json:"kibanaSavedObjectMeta"`
 }
 
@@ -67,9 +74,17 @@ type DashboardRequestBuilder struct {
 	title                 string
 	description           string
 	panelsJson            string
+	panels                []*DashboardPanel
+	layouts               []*DashboardLayout
 	optionsJson           string
+	options               *DashboardOptions
 	uiStateJson           string
 	timeRestore           bool
+	timeFrom              string
+	timeTo                string
+	refreshInterval       *DashboardRefreshInterval
+	query                 *SearchQuery600
+	filters               []*SearchFilter
 	kibanaSavedObjectMeta *SearchKibanaSavedObjectMeta
 	references            []*DashboardReferences
 }
@@ -110,6 +125,30 @@ func (builder *DashboardRequestBuilder) WithPanelsJson(panelsJson string) *Dashb
 	return builder
 }
 
+// WithPanel adds a typed panel, typed panels replace the panels json and are encoded for the kibana version
+// passed to BuildForVersion
+func (builder *DashboardRequestBuilder) WithPanel(panel *DashboardPanel) *DashboardRequestBuilder {
+	builder.panels = append(builder.panels, panel)
+	return builder
+}
+
+func (builder *DashboardRequestBuilder) WithPanels(panels []*DashboardPanel) *DashboardRequestBuilder {
+	builder.panels = panels
+	return builder
+}
+
+// WithLayout adds the panels of the layout, they are placed on the grid when the request is built below the
+// panels added with WithPanel
+func (builder *DashboardRequestBuilder) WithLayout(layout *DashboardLayout) *DashboardRequestBuilder {
+	builder.layouts = append(builder.layouts, layout)
+	return builder
+}
+
+func (builder *DashboardRequestBuilder) WithOptions(options *DashboardOptions) *DashboardRequestBuilder {
+	builder.options = options
+	return builder
+}
+
 func (builder *DashboardRequestBuilder) WithOptionsJson(optionsJson string) *DashboardRequestBuilder {
 	builder.optionsJson = optionsJson
 	return builder
@@ -125,6 +164,33 @@ func (builder *DashboardRequestBuilder) WithTimeRestore(timeRestore bool) *Dashb
 	return builder
 }
 
+// WithTimeRange stores the time range with the dashboard and restores it when the dashboard is opened, from and
+// to are absolute or relative times such as now-15m
+func (builder *DashboardRequestBuilder) WithTimeRange(from string, to string) *DashboardRequestBuilder {
+	builder.timeFrom = from
+	builder.timeTo = to
+	builder.timeRestore = true
+	return builder
+}
+
+func (builder *DashboardRequestBuilder) WithRefreshInterval(refreshInterval *DashboardRefreshInterval) *DashboardRequestBuilder {
+	builder.refreshInterval = refreshInterval
+	return builder
+}
+
+// WithQuery sets the dashboard query in the given language, use QueryLanguageLucene or QueryLanguageKuery. The
+// query and filters replace the kibana saved object meta and are encoded for the kibana version passed to
+// BuildForVersion.
+func (builder *DashboardRequestBuilder) WithQuery(query string, language string) *DashboardRequestBuilder {
+	builder.query = &SearchQuery600{Query: query, Language: language}
+	return builder
+}
+
+func (builder *DashboardRequestBuilder) WithFilter(filter *SearchFilter) *DashboardRequestBuilder {
+	builder.filters = append(builder.filters, filter)
+	return builder
+}
+
 func (builder *DashboardRequestBuilder) WithKibanaSavedObjectMeta(meta *SearchKibanaSavedObjectMeta) *DashboardRequestBuilder {
 	builder.kibanaSavedObjectMeta = meta
 	return builder
@@ -135,25 +201,93 @@ func (builder *DashboardRequestBuilder) WithReferences(refs []*DashboardReferenc
 	return builder
 }
 
+// Build creates the request from the panels and options json, typed panels, layouts, options, query, filters and
+// refresh interval are encoded differently by each kibana version and require BuildForVersion
 func (builder *DashboardRequestBuilder) Build() (*CreateDashboardRequest, error) {
+	if len(builder.panels) > 0 || len(builder.layouts) > 0 || builder.options != nil || builder.query != nil ||
+		len(builder.filters) > 0 || builder.refreshInterval != nil {
+		return nil, errors.New("typed panels, layouts, options, query, filters and refresh interval depend on the kibana version, use BuildForVersion")
+	}
+
+	return builder.BuildForVersion(DefaultKibanaVersion6)
+}
+
+// BuildForVersion creates the request with the typed panels, options, query and filters encoded for the kibana
+// version, for 7.x and later a reference is added for the saved object of every panel and filter index pattern
+func (builder *DashboardRequestBuilder) BuildForVersion(version string) (*CreateDashboardRequest, error) {
+	panelsJson := builder.panelsJson
+	references := builder.references
+	panels := builder.panels
+	for _, layout := range builder.layouts {
+		placed, err := layout.Place(panels)
+		if err != nil {
+			return nil, err
+		}
+
+		panels = append(append([]*DashboardPanel{}, panels...), placed...)
+	}
+
+	if len(panels) > 0 {
+		if err := ValidatePanelLayout(panels); err != nil {
+			return nil, err
+		}
+
+		encodedPanels, panelReferences, err := encodeDashboardPanels(panels, version)
+		if err != nil {
+			return nil, err
+		}
+
+		panelsJson = encodedPanels
+		references = append(append([]*DashboardReferences{}, builder.references...), panelReferences...)
+	}
+
+	kibanaSavedObjectMeta := builder.kibanaSavedObjectMeta
+	if builder.query != nil || len(builder.filters) > 0 {
+		searchSourceJson, filterReferences, err := encodeDashboardSearchSource(builder.query, builder.filters, version)
+		if err != nil {
+			return nil, err
+		}
+
+		kibanaSavedObjectMeta = &SearchKibanaSavedObjectMeta{SearchSourceJSON: searchSourceJson}
+		references = append(append([]*DashboardReferences{}, references...), filterReferences...)
+	}
+
+	var refreshInterval *DashboardRefreshInterval
+	if builder.refreshInterval != nil {
+		refreshInterval = builder.refreshInterval.forVersion(version)
+	}
+
+	optionsJson := builder.optionsJson
+	if builder.options != nil {
+		encodedOptions, err := encodeDashboardOptions(builder.options, version)
+		if err != nil {
+			return nil, err
+		}
+
+		optionsJson = encodedOptions
+	}
 
 	return &CreateDashboardRequest{
 		Attributes: &DashboardAttributes{
 			Title:                 builder.title,
 			Description:           builder.description,
-			PanelsJson:            builder.panelsJson,
-			OptionsJson:           builder.optionsJson,
+			PanelsJson:            panelsJson,
+			OptionsJson:           optionsJson,
 			UiStateJSON:           builder.uiStateJson,
 			TimeRestore:           builder.timeRestore,
-			KibanaSavedObjectMeta: builder.kibanaSavedObjectMeta,
+			TimeFrom:              builder.timeFrom,
+			TimeTo:                builder.timeTo,
+			RefreshInterval:       refreshInterval,
+			KibanaSavedObjectMeta: kibanaSavedObjectMeta,
 		},
-		References: builder.references,
+		References: references,
 	}, nil
 }
 
 func (api *dashboardClient600) Create(request *CreateDashboardRequest) (*Dashboard, error) {
 	response, body, err := api.client.
 		Post(api.config.KibanaBaseUri+savedObjectsPath+"dashboard?overwrite=true").
+		Operation("Dashboard.Create", "dashboard", "").
 		Set("kbn-version", api.config.KibanaVersion).
 		Send(request).
 		End()
@@ -178,6 +312,7 @@ func (api *dashboardClient600) Create(request *CreateDashboardRequest) (*Dashboa
 func (api *dashboardClient600) GetById(id string) (*Dashboard, error) {
 	response, body, err := api.client.
 		Get(api.config.KibanaBaseUri+savedObjectsPath+"dashboard/"+id).
+		Operation("Dashboard.GetById", "dashboard", id).
 		Set("kbn-version", api.config.KibanaVersion).
 		End()
 
@@ -204,6 +339,7 @@ func (api *dashboardClient600) GetById(id string) (*Dashboard, error) {
 func (api *dashboardClient600) List() ([]*Dashboard, error) {
 	response, body, err := api.client.
 		Get(api.config.KibanaBaseUri+savedObjectsPath+"_find?type=dashboard&per_page=9999").
+		Operation("Dashboard.List", "dashboard", "").
 		Set("kbn-version", api.config.KibanaVersion).
 		End()
 
@@ -233,6 +369,7 @@ func (api *dashboardClient600) List() ([]*Dashboard, error) {
 func (api *dashboardClient600) Update(id string, request *UpdateDashboardRequest) (*Dashboard, error) {
 	response, body, err := api.client.
 		Post(api.config.KibanaBaseUri+savedObjectsPath+"dashboard/"+id+"?overwrite=true").
+		Operation("Dashboard.Update", "dashboard", id).
 		Set("kbn-version", api.config.KibanaVersion).
 		Send(request).
 		End()
@@ -257,6 +394,7 @@ func (api *dashboardClient600) Update(id string, request *UpdateDashboardRequest
 func (api *dashboardClient600) Delete(id string) error {
 	response, body, err := api.client.
 		Delete(api.config.KibanaBaseUri+savedObjectsPath+"dashboard/"+id).
+		Operation("Dashboard.Delete", "dashboard", id).
 		Set("kbn-version", api.config.KibanaVersion).
 		End()
 
@@ -271,6 +409,7 @@ func (api *dashboardClient553) Create(request *CreateDashboardRequest) (*Dashboa
 	id := uuid.NewV4().String()
 	response, body, errs := api.client.
 		Post(api.config.BuildFullPath("/%s/%s", "dashboard", id)).
+		Operation("Dashboard.Create", "dashboard", id).
 		Set("kbn-version", api.config.KibanaVersion).
 		Send(request.Attributes).
 		End()
@@ -295,6 +434,7 @@ func (api *dashboardClient553) Create(request *CreateDashboardRequest) (*Dashboa
 func (api *dashboardClient553) GetById(id string) (*Dashboard, error) {
 	response, body, err := api.client.
 		Get(api.config.BuildFullPath("/%s/%s", "dashboard", id)).
+		Operation("Dashboard.GetById", "dashboard", id).
 		Set("kbn-version", api.config.KibanaVersion).
 		End()
 
@@ -331,6 +471,7 @@ func (api *dashboardClient553) List() ([]*Dashboard, error) {
 func (api *dashboardClient553) Update(id string, request *UpdateDashboardRequest) (*Dashboard, error) {
 	response, body, err := api.client.
 		Post(api.config.BuildFullPath("/%s/%s", "dashboard", id)).
+		Operation("Dashboard.Update", "dashboard", id).
 		Set("kbn-version", api.config.KibanaVersion).
 		Send(request.Attributes).
 		End()
@@ -355,6 +496,7 @@ func (api *dashboardClient553) Update(id string, request *UpdateDashboardRequest
 func (api *dashboardClient553) Delete(id string) error {
 	response, body, err := api.client.
 		Delete(api.config.BuildFullPath("/%s/%s", "dashboard", id)).
+		Operation("Dashboard.Delete", "dashboard", id).
 		Set("kbn-version", api.config.KibanaVersion).
 		End()
 
@@ -0,0 +1,205 @@
+package kibana
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	uuid "github.com/satori/go.uuid"
+)
+
+// DashboardCloneOptions changes the copy made by DashboardClient.DeepClone. Title replaces the title of the
+// copied dashboard and Space is the id of the space to copy the dashboard into, by default the dashboard is
+// copied into its own space. Index patterns are shared with the copy unless IncludeIndexPatterns is set, a copy
+// into another space shares the index patterns of the same id in that space and fails when they do not exist.
+type DashboardCloneOptions struct {
+	Title                string
+	Space                string
+	IncludeIndexPatterns bool
+}
+
+func (api *dashboardClient600) DeepDelete(id string) error {
+	return deepDeleteDashboard(&savedObjectsClient600{config: api.config, client: api.client}, api.config.KibanaVersion, id)
+}
+
+func (api *dashboardClient600) DeepClone(id string, options *DashboardCloneOptions) (*Dashboard, error) {
+	source := &savedObjectsClient600{config: api.config, client: api.client}
+	target := source
+	if options != nil && options.Space != "" {
+		spaceConfig := *api.config
+		spaceConfig.KibanaBaseUri = api.config.KibanaBaseUri + "/s/" + options.Space
+		target = &savedObjectsClient600{config: &spaceConfig, client: api.client}
+	}
+
+	return deepCloneDashboard(source, target, api.config.KibanaVersion, id, options)
+}
+
+func (api *dashboardClient553) DeepDelete(id string) error {
+	return deepDeleteDashboard(&savedObjectsClient553{config: api.config, client: api.client}, api.config.KibanaVersion, id)
+}
+
+func (api *dashboardClient553) DeepClone(id string, options *DashboardCloneOptions) (*Dashboard, error) {
+	if options != nil && options.Space != "" {
+		return nil, fmt.Errorf("could not clone dashboard into space %s, spaces are not supported by kibana %s", options.Space, api.config.KibanaVersion)
+	}
+
+	client := &savedObjectsClient553{config: api.config, client: api.client}
+	return deepCloneDashboard(client, client, api.config.KibanaVersion, id, options)
+}
+
+// deepDeleteDashboard deletes the dashboard followed by the visualizations and searches it depends on which no
+// other saved object refers to
+func deepDeleteDashboard(client SavedObjectsClient, version string, id string) error {
+	graph := NewSavedObjectGraph(client, version)
+	tree, err := graph.Dependencies("dashboard", id)
+	if err != nil {
+		return err
+	}
+
+	if tree.Missing {
+		return client.Delete("dashboard", id)
+	}
+
+	dependencies := deepCopyableDependencies(tree, false)
+	dependents := map[string][]*SavedObjectNode{}
+	for _, dependency := range dependencies {
+		node, err := graph.Dependents(dependency.Type, dependency.Id)
+		if err != nil {
+			return err
+		}
+
+		dependents[referenceKey(dependency.Type, dependency.Id)] = node.Children
+	}
+
+	if err := client.Delete("dashboard", id); err != nil {
+		return err
+	}
+
+	deleted := map[string]bool{referenceKey("dashboard", id): true}
+	for index := len(dependencies) - 1; index >= 0; index-- {
+		key := referenceKey(dependencies[index].Type, dependencies[index].Id)
+		orphaned := true
+		for _, dependent := range dependents[key] {
+			if !deleted[referenceKey(dependent.Type, dependent.Id)] {
+				orphaned = false
+			}
+		}
+
+		if !orphaned {
+			continue
+		}
+
+		if err := client.Delete(dependencies[index].Type, dependencies[index].Id); err != nil {
+			return err
+		}
+
+		deleted[key] = true
+	}
+
+	return nil
+}
+
+// deepCloneDashboard copies the dashboard and the saved objects it depends on under new ids, the copies refer
+// to each other
+func deepCloneDashboard(source SavedObjectsClient, target SavedObjectsClient, version string, id string, options *DashboardCloneOptions) (*Dashboard, error) {
+	if options == nil {
+		options = &DashboardCloneOptions{}
+	}
+
+	graph := NewSavedObjectGraph(source, version)
+	tree, err := graph.Dependencies("dashboard", id)
+	if err != nil {
+		return nil, err
+	}
+
+	if tree.Missing {
+		_, err := source.GetById("dashboard", id)
+		return nil, err
+	}
+
+	if options.Space != "" && !options.IncludeIndexPatterns {
+		if err := checkSharedIndexPatterns(target, tree, options.Space); err != nil {
+			return nil, err
+		}
+	}
+
+	resolver := NewReferenceResolver(target)
+	nodes := append(deepCopyableDependencies(tree, options.IncludeIndexPatterns), tree)
+	for _, node := range nodes {
+		resolver.WithIdMapping(node.Type, node.Id, uuid.NewV4().String())
+	}
+
+	var copied *SavedObject
+	for _, node := range nodes {
+		object, err := graph.savedObject(node.Type, node.Id)
+		if err != nil {
+			return nil, err
+		}
+
+		rewritten, err := resolver.RewriteSavedObject(object)
+		if err != nil {
+			return nil, err
+		}
+
+		if node == tree && options.Title != "" {
+			rewritten.Attributes["title"] = options.Title
+		}
+
+		copied, err = target.Create(node.Type, resolver.mappedId(node.Type, node.Id), &CreateSavedObjectRequest{
+			Attributes: rewritten.Attributes,
+			References: rewritten.References,
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	data, err := json.Marshal(copied)
+	if err != nil {
+		return nil, fmt.Errorf("could not encode cloned dashboard, error: %v", err)
+	}
+
+	dashboard := &Dashboard{}
+	if err := json.Unmarshal(data, dashboard); err != nil {
+		return nil, fmt.Errorf("could not parse cloned dashboard, error: %v", err)
+	}
+
+	return dashboard, nil
+}
+
+// checkSharedIndexPatterns checks the index patterns the dashboard depends on exist in the space it is copied into
+func checkSharedIndexPatterns(target SavedObjectsClient, tree *SavedObjectNode, space string) error {
+	for _, node := range tree.Flatten() {
+		if node.Type != "index-pattern" || node.Missing {
+			continue
+		}
+
+		if _, err := target.GetById(node.Type, node.Id); err != nil {
+			if httpErr, ok := err.(*HttpError); ok && httpErr.Code == http.StatusNotFound {
+				return fmt.Errorf("could not clone dashboard into space %s, index pattern %s does not exist in the space, set IncludeIndexPatterns to copy it", space, node.Id)
+			}
+
+			return err
+		}
+	}
+
+	return nil
+}
+
+// deepCopyableDependencies returns the visualizations, lens visualizations and searches, and optionally index
+// patterns, the saved object depends on with every saved object after the saved objects it depends on
+func deepCopyableDependencies(tree *SavedObjectNode, includeIndexPatterns bool) []*SavedObjectNode {
+	var dependencies []*SavedObjectNode
+	for _, node := range tree.Flatten() {
+		switch node.Type {
+		case "visualization", "lens", "search":
+			dependencies = append(dependencies, node)
+		case "index-pattern":
+			if includeIndexPatterns {
+				dependencies = append(dependencies, node)
+			}
+		}
+	}
+
+	return dependencies
+}
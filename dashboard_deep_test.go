@@ -0,0 +1,201 @@
+package kibana
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type testDashboardObjects struct {
+	search        *Search
+	visualization *Visualization
+	dashboard     *Dashboard
+}
+
+func createTestDashboardObjects(t *testing.T, client *KibanaClient) *testDashboardObjects {
+	searchRequest, _, err := createSearchRequest(client.Search(), client.Config.DefaultIndexId, t)
+	require.NoError(t, err)
+	search, err := client.Search().Create(searchRequest)
+	require.NoError(t, err)
+
+	visualizationRequest, err := newTestVisualizationRequestBuilder().
+		WithSavedSearchId(search.Id).
+		Build(client.Config.KibanaVersion)
+	require.NoError(t, err)
+	visualization, err := client.Visualization().Create(visualizationRequest)
+	require.NoError(t, err)
+
+	request, err := newTestPanelDashboardRequestBuilder(visualization.Id, search.Id).
+		BuildForVersion(client.Config.KibanaVersion)
+	require.NoError(t, err)
+	dashboard, err := client.Dashboard().Create(request)
+	require.NoError(t, err)
+
+	return &testDashboardObjects{search: search, visualization: visualization, dashboard: dashboard}
+}
+
+func assertNotFound(t *testing.T, err error) {
+	httpErr, ok := err.(*HttpError)
+	require.True(t, ok, "Expected http error, got %v", err)
+	assert.Equal(t, 404, httpErr.Code)
+}
+
+func Test_DashboardDeepDelete(t *testing.T) {
+	client := DefaultTestKibanaClient()
+	objects := createTestDashboardObjects(t, client)
+
+	sharedVisualizationRequest, err := newTestVisualizationRequestBuilder().Build(client.Config.KibanaVersion)
+	require.NoError(t, err)
+	sharedVisualization, err := client.Visualization().Create(sharedVisualizationRequest)
+	require.NoError(t, err)
+	defer client.Visualization().Delete(sharedVisualization.Id)
+
+	request, err := NewDashboardRequestBuilder().
+		WithTitle("Other dashboard").
+		WithPanel(NewDashboardPanel(DashboardReferencesTypeVisualization, sharedVisualization.Id, 0, 0, 24, 15)).
+		BuildForVersion(client.Config.KibanaVersion)
+	require.NoError(t, err)
+	otherDashboard, err := client.Dashboard().Create(request)
+	require.NoError(t, err)
+	defer client.Dashboard().Delete(otherDashboard.Id)
+
+	request, err = newTestPanelDashboardRequestBuilder(objects.visualization.Id, objects.search.Id).
+		WithPanel(NewDashboardPanel(DashboardReferencesTypeVisualization, sharedVisualization.Id, 0, 15, 24, 15)).
+		BuildForVersion(client.Config.KibanaVersion)
+	require.NoError(t, err)
+	_, err = client.Dashboard().Update(objects.dashboard.Id, &UpdateDashboardRequest{Attributes: request.Attributes, References: request.References})
+	require.NoError(t, err)
+
+	require.NoError(t, client.Dashboard().DeepDelete(objects.dashboard.Id))
+
+	_, err = client.Dashboard().GetById(objects.dashboard.Id)
+	assertNotFound(t, err)
+	_, err = client.Visualization().GetById(objects.visualization.Id)
+	assertNotFound(t, err)
+	_, err = client.Search().GetById(objects.search.Id)
+	assertNotFound(t, err)
+
+	_, err = client.Visualization().GetById(sharedVisualization.Id)
+	assert.NoError(t, err, "a visualization used by another dashboard is kept")
+	_, err = client.SavedObjects().GetById("index-pattern", client.Config.DefaultIndexId)
+	assert.NoError(t, err, "index patterns are kept")
+
+	assertNotFound(t, client.Dashboard().DeepDelete(objects.dashboard.Id))
+}
+
+func Test_DashboardDeepClone(t *testing.T) {
+	client := DefaultTestKibanaClient()
+	objects := createTestDashboardObjects(t, client)
+	defer client.Dashboard().DeepDelete(objects.dashboard.Id)
+
+	clone, err := client.Dashboard().DeepClone(objects.dashboard.Id, &DashboardCloneOptions{Title: "China errors copy"})
+	require.NoError(t, err)
+	defer client.Dashboard().DeepDelete(clone.Id)
+
+	assert.NotEqual(t, objects.dashboard.Id, clone.Id)
+	assert.Equal(t, "China errors copy", clone.Attributes.Title)
+
+	dashboard, err := client.Dashboard().GetById(clone.Id)
+	require.NoError(t, err)
+	panels, err := dashboard.Panels()
+	require.NoError(t, err)
+	require.Len(t, panels, 2)
+	assert.NotEqual(t, objects.visualization.Id, panels[0].Id)
+	assert.NotEqual(t, objects.search.Id, panels[1].Id)
+
+	visualization, err := client.Visualization().GetById(panels[0].Id)
+	require.NoError(t, err)
+	references, err := visualization.SavedObjectReferences()
+	require.NoError(t, err)
+	require.Len(t, references, 1)
+	assert.Equal(t, panels[1].Id, references[0].Id, "the copied visualization uses the copied search")
+
+	search, err := client.SavedObjects().GetById("search", panels[1].Id)
+	require.NoError(t, err)
+	references, err = search.SavedObjectReferences()
+	require.NoError(t, err)
+	require.Len(t, references, 1)
+	assert.Equal(t, client.Config.DefaultIndexId, references[0].Id, "index patterns are shared")
+
+	dangling, err := NewReferenceResolver(client.SavedObjects()).ValidateDashboard(dashboard)
+	require.NoError(t, err)
+	assert.Empty(t, dangling)
+}
+
+func Test_DashboardDeepClone_into_space(t *testing.T) {
+	fake := NewFakeKibana(DefaultKibanaVersion7)
+	defer fake.Close()
+
+	client := NewClient(fake.Config())
+	require.NoError(t, client.Space().Create(&Space{Id: "blue", Name: "Blue team"}))
+	indexPattern, err := client.SavedObjects().Create("index-pattern", client.Config.DefaultIndexId, &CreateSavedObjectRequest{
+		Attributes: map[string]interface{}{"title": "logstash-*"},
+	})
+	require.NoError(t, err)
+
+	objects := createTestDashboardObjects(t, client)
+	clone, err := client.Dashboard().DeepClone(objects.dashboard.Id, &DashboardCloneOptions{Space: "blue", IncludeIndexPatterns: true})
+	require.NoError(t, err)
+	assert.Equal(t, "China errors", clone.Attributes.Title)
+
+	spaceConfig := fake.Config()
+	spaceConfig.KibanaBaseUri = fake.URL() + "/s/blue"
+	spaceClient := NewClient(spaceConfig)
+
+	tree, err := NewSavedObjectGraph(spaceClient.SavedObjects(), spaceClient.Config.KibanaVersion).Dependencies("dashboard", clone.Id)
+	require.NoError(t, err)
+	copied := tree.Flatten()
+	require.Len(t, copied, 3)
+	assert.Equal(t, "index-pattern", copied[0].Type)
+	assert.NotEqual(t, indexPattern.Id, copied[0].Id)
+	assert.Equal(t, "logstash-*", copied[0].Title)
+
+	_, err = client.Dashboard().GetById(clone.Id)
+	assertNotFound(t, err)
+
+	legacyFake := NewFakeKibana("5.5.3")
+	defer legacyFake.Close()
+
+	_, err = NewClient(legacyFake.Config()).Dashboard().DeepClone("dashboard", &DashboardCloneOptions{Space: "blue"})
+	assert.EqualError(t, err, "could not clone dashboard into space blue, spaces are not supported by kibana 5.5.3")
+}
+
+func Test_DashboardDeepClone_into_space_shares_index_patterns(t *testing.T) {
+	fake := NewFakeKibana(DefaultKibanaVersion7)
+	defer fake.Close()
+
+	client := NewClient(fake.Config())
+	require.NoError(t, client.Space().Create(&Space{Id: "blue", Name: "Blue team"}))
+	_, err := client.SavedObjects().Create("index-pattern", client.Config.DefaultIndexId, &CreateSavedObjectRequest{
+		Attributes: map[string]interface{}{"title": "logstash-*"},
+	})
+	require.NoError(t, err)
+
+	objects := createTestDashboardObjects(t, client)
+	_, err = client.Dashboard().DeepClone(objects.dashboard.Id, &DashboardCloneOptions{Space: "blue"})
+	assert.EqualError(t, err, "could not clone dashboard into space blue, index pattern "+client.Config.DefaultIndexId+" does not exist in the space, set IncludeIndexPatterns to copy it")
+
+	spaceConfig := fake.Config()
+	spaceConfig.KibanaBaseUri = fake.URL() + "/s/blue"
+	spaceClient := NewClient(spaceConfig)
+	dashboards, err := spaceClient.SavedObjects().GetByType(&SavedObjectRequest{Type: "dashboard", PerPage: 100})
+	require.NoError(t, err)
+	assert.Empty(t, dashboards.SavedObjects, "nothing is copied when an index pattern is missing")
+
+	_, err = spaceClient.SavedObjects().Create("index-pattern", client.Config.DefaultIndexId, &CreateSavedObjectRequest{
+		Attributes: map[string]interface{}{"title": "logstash-*"},
+	})
+	require.NoError(t, err)
+
+	clone, err := client.Dashboard().DeepClone(objects.dashboard.Id, &DashboardCloneOptions{Space: "blue"})
+	require.NoError(t, err)
+
+	tree, err := NewSavedObjectGraph(spaceClient.SavedObjects(), spaceClient.Config.KibanaVersion).Dependencies("dashboard", clone.Id)
+	require.NoError(t, err)
+	copied := tree.Flatten()
+	require.Len(t, copied, 3)
+	assert.Equal(t, "index-pattern", copied[0].Type)
+	assert.Equal(t, client.Config.DefaultIndexId, copied[0].Id, "the copy shares the index pattern of the space")
+	assert.False(t, copied[0].Missing)
+}
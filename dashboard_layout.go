@@ -0,0 +1,179 @@
+package kibana
+
+import (
+	"fmt"
+)
+
+const (
+	// DashboardGridColumns is the width of the dashboard grid in kibana 6.0 and later
+	DashboardGridColumns = 48
+
+	DefaultPanelWidth  = 24
+	DefaultPanelHeight = 15
+)
+
+// DashboardLayout places panels on the dashboard grid in the order they are added. Panels flow left to right
+// and wrap to a new row below the tallest panel of the current row when they do not fit, skipping any area
+// already taken by other panels of the dashboard. Sections start a new row with a full width markdown panel.
+type DashboardLayout struct {
+	width  int
+	height int
+	items  []*dashboardLayoutItem
+}
+
+type dashboardLayoutItem struct {
+	panel   *DashboardPanel
+	width   int
+	height  int
+	section bool
+}
+
+type dashboardLayoutCursor struct {
+	x         int
+	y         int
+	rowBottom int
+}
+
+// NewFlowLayout creates a layout whose panels default to the given size, use AddWithSize to override it
+func NewFlowLayout(width int, height int) *DashboardLayout {
+	return &DashboardLayout{width: width, height: height}
+}
+
+// NewColumnLayout creates a layout placing the panels in the given number of equally wide columns, between 1 and
+// DashboardGridColumns columns of at least one grid column each
+func NewColumnLayout(columns int, height int) *DashboardLayout {
+	if columns < 1 {
+		columns = 1
+	}
+
+	if columns > DashboardGridColumns {
+		columns = DashboardGridColumns
+	}
+
+	return NewFlowLayout(DashboardGridColumns/columns, height)
+}
+
+func (layout *DashboardLayout) Add(panel *DashboardPanel) *DashboardLayout {
+	return layout.AddWithSize(panel, layout.width, layout.height)
+}
+
+func (layout *DashboardLayout) AddWithSize(panel *DashboardPanel, width int, height int) *DashboardLayout {
+	layout.items = append(layout.items, &dashboardLayoutItem{panel: panel, width: width, height: height})
+	return layout
+}
+
+// AddSection starts a new row with a full width header showing the markdown visualization
+func (layout *DashboardLayout) AddSection(markdownVisualizationId string, height int) *DashboardLayout {
+	panel := NewDashboardPanel(DashboardReferencesTypeVisualization, markdownVisualizationId, 0, 0, DashboardGridColumns, height)
+	layout.items = append(layout.items, &dashboardLayoutItem{panel: panel, width: DashboardGridColumns, height: height, section: true})
+	return layout
+}
+
+// Place sets the grid data of the layout panels so they do not overlap each other or the placed panels,
+// the layout starts below the placed panels
+func (layout *DashboardLayout) Place(placed []*DashboardPanel) ([]*DashboardPanel, error) {
+	var taken []*DashboardPanelGridData
+	cursor := &dashboardLayoutCursor{}
+	for _, panel := range placed {
+		if panel.GridData != nil {
+			taken = append(taken, panel.GridData)
+			cursor.y = maxInt(cursor.y, panel.GridData.Y+panel.GridData.H)
+		}
+	}
+
+	cursor.rowBottom = cursor.y
+	panels := []*DashboardPanel{}
+	for _, item := range layout.items {
+		width, height := item.width, item.height
+		if width <= 0 {
+			width = DefaultPanelWidth
+		}
+
+		if height <= 0 {
+			height = DefaultPanelHeight
+		}
+
+		if width > DashboardGridColumns {
+			return nil, fmt.Errorf("panel %s is %d columns wide, the dashboard grid has %d columns", item.panel.Id, width, DashboardGridColumns)
+		}
+
+		if item.section || cursor.x+width > DashboardGridColumns {
+			cursor.x, cursor.y = 0, cursor.rowBottom
+		}
+
+		gridData := cursor.findFreeArea(taken, width, height)
+		panel := *item.panel
+		panel.GridData = gridData
+		panels = append(panels, &panel)
+		taken = append(taken, gridData)
+
+		cursor.x, cursor.y = gridData.X+width, gridData.Y
+		cursor.rowBottom = maxInt(cursor.rowBottom, gridData.Y+height)
+		if item.section {
+			cursor.x, cursor.y = 0, cursor.rowBottom
+		}
+	}
+
+	return panels, nil
+}
+
+// ValidatePanelLayout checks that the panels fit in the dashboard grid and do not overlap
+func ValidatePanelLayout(panels []*DashboardPanel) error {
+	for index, panel := range panels {
+		gridData := panel.GridData
+		if gridData == nil {
+			continue
+		}
+
+		if gridData.X < 0 || gridData.Y < 0 || gridData.W <= 0 || gridData.H <= 0 || gridData.X+gridData.W > DashboardGridColumns {
+			return fmt.Errorf("panel %s at x: %d, y: %d, w: %d, h: %d is outside of the dashboard grid", panel.Id, gridData.X, gridData.Y, gridData.W, gridData.H)
+		}
+
+		for _, other := range panels[index+1:] {
+			if other.GridData != nil && overlaps(gridData, other.GridData) {
+				return fmt.Errorf("panel %s overlaps panel %s", panel.Id, other.Id)
+			}
+		}
+	}
+
+	return nil
+}
+
+// findFreeArea returns the first area in reading order from the cursor which does not overlap a taken area
+func (cursor *dashboardLayoutCursor) findFreeArea(taken []*DashboardPanelGridData, width int, height int) *DashboardPanelGridData {
+	candidate := &DashboardPanelGridData{X: cursor.x, Y: cursor.y, W: width, H: height}
+	for {
+		for candidate.X+width <= DashboardGridColumns {
+			if !overlapsAny(candidate, taken) {
+				return candidate
+			}
+
+			candidate.X++
+		}
+
+		candidate.X = 0
+		candidate.Y++
+	}
+}
+
+func overlapsAny(area *DashboardPanelGridData, taken []*DashboardPanelGridData) bool {
+	for _, other := range taken {
+		if overlaps(area, other) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func overlaps(a *DashboardPanelGridData, b *DashboardPanelGridData) bool {
+	return a.X < b.X+b.W && b.X < a.X+a.W && a.Y < b.Y+b.H && b.Y < a.Y+a.H
+}
+
+func maxInt(a int, b int) int {
+	if a > b {
+		return a
+	}
+
+	return b
+}
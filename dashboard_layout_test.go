@@ -0,0 +1,131 @@
+package kibana
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestLayoutPanel(id string) *DashboardPanel {
+	return &DashboardPanel{Type: DashboardReferencesTypeVisualization, Id: id}
+}
+
+func gridDataOf(panels []*DashboardPanel) []DashboardPanelGridData {
+	gridData := []DashboardPanelGridData{}
+	for _, panel := range panels {
+		gridData = append(gridData, *panel.GridData)
+	}
+
+	return gridData
+}
+
+func Test_DashboardLayout_flow_wraps_below_tallest_panel(t *testing.T) {
+	panels, err := NewFlowLayout(16, 10).
+		Add(newTestLayoutPanel("a")).
+		AddWithSize(newTestLayoutPanel("b"), 16, 20).
+		Add(newTestLayoutPanel("c")).
+		AddWithSize(newTestLayoutPanel("d"), 0, 0).
+		Place(nil)
+	require.NoError(t, err)
+
+	assert.Equal(t, []DashboardPanelGridData{
+		{X: 0, Y: 0, W: 16, H: 10},
+		{X: 16, Y: 0, W: 16, H: 20},
+		{X: 32, Y: 0, W: 16, H: 10},
+		{X: 0, Y: 20, W: DefaultPanelWidth, H: DefaultPanelHeight},
+	}, gridDataOf(panels))
+	assert.NoError(t, ValidatePanelLayout(panels))
+}
+
+func Test_DashboardLayout_columns_and_sections(t *testing.T) {
+	panels, err := NewColumnLayout(3, 10).
+		AddSection("header-1", 3).
+		Add(newTestLayoutPanel("a")).
+		Add(newTestLayoutPanel("b")).
+		Add(newTestLayoutPanel("c")).
+		Add(newTestLayoutPanel("d")).
+		AddSection("header-2", 3).
+		Add(newTestLayoutPanel("e")).
+		Place(nil)
+	require.NoError(t, err)
+
+	assert.Equal(t, []DashboardPanelGridData{
+		{X: 0, Y: 0, W: 48, H: 3},
+		{X: 0, Y: 3, W: 16, H: 10},
+		{X: 16, Y: 3, W: 16, H: 10},
+		{X: 32, Y: 3, W: 16, H: 10},
+		{X: 0, Y: 13, W: 16, H: 10},
+		{X: 0, Y: 23, W: 48, H: 3},
+		{X: 0, Y: 26, W: 16, H: 10},
+	}, gridDataOf(panels))
+	assert.Equal(t, "header-2", panels[5].Id)
+	assert.Equal(t, DashboardReferencesTypeVisualization, panels[5].Type)
+}
+
+func Test_DashboardLayout_columns_are_clamped_to_the_grid(t *testing.T) {
+	panels, err := NewColumnLayout(100, 10).
+		Add(newTestLayoutPanel("a")).
+		Add(newTestLayoutPanel("b")).
+		Place(nil)
+	require.NoError(t, err)
+	assert.Equal(t, []DashboardPanelGridData{
+		{X: 0, Y: 0, W: 1, H: 10},
+		{X: 1, Y: 0, W: 1, H: 10},
+	}, gridDataOf(panels))
+
+	panels, err = NewColumnLayout(0, 10).Add(newTestLayoutPanel("a")).Place(nil)
+	require.NoError(t, err)
+	assert.Equal(t, []DashboardPanelGridData{{X: 0, Y: 0, W: 48, H: 10}}, gridDataOf(panels))
+}
+
+func Test_DashboardLayout_avoids_placed_panels(t *testing.T) {
+	placed := []*DashboardPanel{NewDashboardPanel(DashboardReferencesTypeSearch, "search", 0, 0, 48, 15)}
+	panels, err := NewFlowLayout(24, 15).
+		Add(newTestLayoutPanel("a")).
+		Add(newTestLayoutPanel("b")).
+		Place(placed)
+	require.NoError(t, err)
+
+	assert.Equal(t, []DashboardPanelGridData{
+		{X: 0, Y: 15, W: 24, H: 15},
+		{X: 24, Y: 15, W: 24, H: 15},
+	}, gridDataOf(panels))
+
+	_, err = NewFlowLayout(24, 15).AddWithSize(newTestLayoutPanel("wide"), 60, 15).Place(nil)
+	assert.EqualError(t, err, "panel wide is 60 columns wide, the dashboard grid has 48 columns")
+}
+
+func Test_ValidatePanelLayout(t *testing.T) {
+	err := ValidatePanelLayout([]*DashboardPanel{
+		NewDashboardPanel(DashboardReferencesTypeVisualization, "a", 0, 0, 24, 15),
+		NewDashboardPanel(DashboardReferencesTypeVisualization, "b", 12, 10, 24, 15),
+	})
+	assert.EqualError(t, err, "panel a overlaps panel b")
+
+	err = ValidatePanelLayout([]*DashboardPanel{NewDashboardPanel(DashboardReferencesTypeVisualization, "a", 40, 0, 24, 15)})
+	assert.EqualError(t, err, "panel a at x: 40, y: 0, w: 24, h: 15 is outside of the dashboard grid")
+}
+
+func Test_DashboardRequestBuilder_WithLayout(t *testing.T) {
+	request, err := newTestPanelDashboardRequestBuilder("vis-1", "search-1").
+		WithLayout(NewColumnLayout(2, 15).
+			AddSection("header", 5).
+			Add(newTestLayoutPanel("vis-2"))).
+		BuildForVersion(DefaultKibanaVersion6)
+	require.NoError(t, err)
+
+	panels := []*DashboardPanel{}
+	require.NoError(t, json.Unmarshal([]byte(request.Attributes.PanelsJson), &panels))
+	require.Len(t, panels, 4)
+	assert.Equal(t, &DashboardPanelGridData{X: 0, Y: 15, W: 48, H: 5, I: "3"}, panels[2].GridData)
+	assert.Equal(t, &DashboardPanelGridData{X: 0, Y: 20, W: 24, H: 15, I: "4"}, panels[3].GridData)
+	assert.Equal(t, "vis-2", panels[3].Id)
+
+	_, err = NewDashboardRequestBuilder().
+		WithPanel(NewDashboardPanel(DashboardReferencesTypeVisualization, "a", 0, 0, 24, 15)).
+		WithPanel(NewDashboardPanel(DashboardReferencesTypeVisualization, "b", 0, 0, 24, 15)).
+		BuildForVersion(DefaultKibanaVersion6)
+	assert.EqualError(t, err, "panel a overlaps panel b")
+}
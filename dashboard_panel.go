@@ -0,0 +1,245 @@
+package kibana
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+
+	goversion "github.com/mcuadros/go-version"
+)
+
+// Kibana 6.0 and later lay panels out on a 48 column grid, earlier versions use 12 columns with
+// rows five times the height of a grid unit
+const (
+	legacyPanelWidthScaleFactor  = 4
+	legacyPanelHeightScaleFactor = 5
+)
+
+// DashboardPanel is a visualization or saved search placed on a dashboard. Id is the id of the saved object
+// the panel shows, when reading a 7.x dashboard it is resolved from the reference named PanelRefName.
+type DashboardPanel struct {
+	PanelIndex       string                  `json:"panelIndex"`
+	GridData         *DashboardPanelGridData `json:"gridData"`
+	Version          string                  `json:"version,omitempty"`
+	Type             dashboardReferencesType `json:"type,omitempty"`
+	Id               string                  `json:"id,omitempty"`
+	PanelRefName     string                  `json:"panelRefName,omitempty"`
+	Title            string                  `json:"title,omitempty"`
+	EmbeddableConfig map[string]interface{}  `json:"embeddableConfig"`
+	Columns          []string                `json:"columns,omitempty"`
+	Sort             []string                `json:"sort,omitempty"`
+}
+
+// DashboardPanelGridData is the position of a panel on the 48 column grid, I is the panel index
+type DashboardPanelGridData struct {
+	X int    `json:"x"`
+	Y int    `json:"y"`
+	W int    `json:"w"`
+	H int    `json:"h"`
+	I string `json:"i"`
+}
+
+// DashboardOptions are the display options of a dashboard, only DarkTheme is supported before kibana 6.0
+type DashboardOptions struct {
+	DarkTheme       bool `json:"darkTheme"`
+	HidePanelTitles bool `json:"hidePanelTitles"`
+	UseMargins      bool `json:"useMargins"`
+}
+
+type dashboardPanel553 struct {
+	Col        int                     `json:"col"`
+	Row        int                     `json:"row"`
+	SizeX      int                     `json:"size_x"`
+	SizeY      int                     `json:"size_y"`
+	PanelIndex int                     `json:"panelIndex"`
+	Type       dashboardReferencesType `json:"type"`
+	Id         string                  `json:"id"`
+	Columns    []string                `json:"columns,omitempty"`
+	Sort       []string                `json:"sort,omitempty"`
+}
+
+type dashboardOptions553 struct {
+	DarkTheme bool `json:"darkTheme"`
+}
+
+// NewDashboardPanel creates a panel showing the saved object of the given type and id at the grid position
+func NewDashboardPanel(panelType dashboardReferencesType, id string, x int, y int, w int, h int) *DashboardPanel {
+	return &DashboardPanel{
+		Type:             panelType,
+		Id:               id,
+		GridData:         &DashboardPanelGridData{X: x, Y: y, W: w, H: h},
+		EmbeddableConfig: map[string]interface{}{},
+	}
+}
+
+func (panel *DashboardPanel) UnmarshalJSON(data []byte) error {
+	type plainDashboardPanel DashboardPanel
+	var raw struct {
+		*plainDashboardPanel
+		PanelIndex interface{} `json:"panelIndex"`
+		Col        int         `json:"col"`
+		Row        int         `json:"row"`
+		SizeX      int         `json:"size_x"`
+		SizeY      int         `json:"size_y"`
+	}
+
+	raw.plainDashboardPanel = (*plainDashboardPanel)(panel)
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+
+	switch index := raw.PanelIndex.(type) {
+	case string:
+		panel.PanelIndex = index
+	case float64:
+		panel.PanelIndex = strconv.FormatFloat(index, 'f', -1, 64)
+	}
+
+	if panel.GridData == nil && raw.SizeX > 0 {
+		panel.GridData = &DashboardPanelGridData{
+			X: (raw.Col - 1) * legacyPanelWidthScaleFactor,
+			Y: (raw.Row - 1) * legacyPanelHeightScaleFactor,
+			W: raw.SizeX * legacyPanelWidthScaleFactor,
+			H: raw.SizeY * legacyPanelHeightScaleFactor,
+			I: panel.PanelIndex,
+		}
+	}
+
+	return nil
+}
+
+// Panels decodes the panels of the dashboard, resolving the ids of 7.x panels from the dashboard references
+func (dashboard *Dashboard) Panels() ([]*DashboardPanel, error) {
+	return decodeDashboardPanels(dashboard.Attributes.PanelsJson, dashboard.References)
+}
+
+// Options decodes the display options of the dashboard
+func (attributes *DashboardAttributes) Options() (*DashboardOptions, error) {
+	options := &DashboardOptions{}
+	if attributes.OptionsJson == "" {
+		return options, nil
+	}
+
+	if err := json.Unmarshal([]byte(attributes.OptionsJson), options); err != nil {
+		return nil, fmt.Errorf("could not parse dashboard options, error: %v", err)
+	}
+
+	return options, nil
+}
+
+func decodeDashboardPanels(panelsJson string, references []*DashboardReferences) ([]*DashboardPanel, error) {
+	panels := []*DashboardPanel{}
+	if panelsJson == "" {
+		return panels, nil
+	}
+
+	if err := json.Unmarshal([]byte(panelsJson), &panels); err != nil {
+		return nil, fmt.Errorf("could not parse dashboard panels, error: %v", err)
+	}
+
+	for _, panel := range panels {
+		if panel.PanelRefName == "" {
+			continue
+		}
+
+		for _, reference := range references {
+			if reference.Name == panel.PanelRefName {
+				panel.Id = reference.Id
+				panel.Type = reference.Type
+			}
+		}
+	}
+
+	return panels, nil
+}
+
+// encodeDashboardPanels serializes the panels to the panelsJSON format of the kibana version, for 7.x and later
+// the panels refer to their saved objects by the returned references
+func encodeDashboardPanels(panels []*DashboardPanel, version string) (string, []*DashboardReferences, error) {
+	var encoded interface{}
+	var references []*DashboardReferences
+
+	if goversion.Compare(version, "6.0.0", "<") {
+		legacyPanels := []*dashboardPanel553{}
+		for index, panel := range panels {
+			panelIndex, err := strconv.Atoi(panel.PanelIndex)
+			if err != nil {
+				panelIndex = index + 1
+			}
+
+			legacyPanel := &dashboardPanel553{
+				PanelIndex: panelIndex,
+				Type:       panel.Type,
+				Id:         panel.Id,
+				Columns:    panel.Columns,
+				Sort:       panel.Sort,
+			}
+
+			if panel.GridData != nil {
+				legacyPanel.Col = panel.GridData.X/legacyPanelWidthScaleFactor + 1
+				legacyPanel.Row = panel.GridData.Y/legacyPanelHeightScaleFactor + 1
+				legacyPanel.SizeX = panel.GridData.W / legacyPanelWidthScaleFactor
+				legacyPanel.SizeY = panel.GridData.H / legacyPanelHeightScaleFactor
+			}
+
+			legacyPanels = append(legacyPanels, legacyPanel)
+		}
+
+		encoded = legacyPanels
+	} else {
+		gridPanels := []*DashboardPanel{}
+		for index, panel := range panels {
+			gridPanel := *panel
+			if gridPanel.PanelIndex == "" {
+				gridPanel.PanelIndex = strconv.Itoa(index + 1)
+			}
+
+			if gridPanel.GridData != nil {
+				gridData := *gridPanel.GridData
+				gridData.I = gridPanel.PanelIndex
+				gridPanel.GridData = &gridData
+			}
+
+			if gridPanel.EmbeddableConfig == nil {
+				gridPanel.EmbeddableConfig = map[string]interface{}{}
+			}
+
+			gridPanel.Version = version
+			if goversion.Compare(version, "7.0.0", ">=") {
+				gridPanel.PanelRefName = fmt.Sprintf("panel_%d", index)
+				references = append(references, &DashboardReferences{
+					Name: gridPanel.PanelRefName,
+					Type: gridPanel.Type,
+					Id:   gridPanel.Id,
+				})
+				gridPanel.Type = ""
+				gridPanel.Id = ""
+			}
+
+			gridPanels = append(gridPanels, &gridPanel)
+		}
+
+		encoded = gridPanels
+	}
+
+	data, err := json.Marshal(encoded)
+	if err != nil {
+		return "", nil, fmt.Errorf("could not encode dashboard panels, error: %v", err)
+	}
+
+	return string(data), references, nil
+}
+
+func encodeDashboardOptions(options *DashboardOptions, version string) (string, error) {
+	var encoded interface{} = options
+	if goversion.Compare(version, "6.0.0", "<") {
+		encoded = &dashboardOptions553{DarkTheme: options.DarkTheme}
+	}
+
+	data, err := json.Marshal(encoded)
+	if err != nil {
+		return "", fmt.Errorf("could not encode dashboard options, error: %v", err)
+	}
+
+	return string(data), nil
+}
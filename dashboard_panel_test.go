@@ -0,0 +1,126 @@
+package kibana
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestPanelDashboardRequestBuilder(visualizationId string, searchId string) *DashboardRequestBuilder {
+	searchPanel := NewDashboardPanel(DashboardReferencesTypeSearch, searchId, 24, 0, 24, 15)
+	searchPanel.Columns = []string{"_source"}
+	searchPanel.Sort = []string{"@timestamp", "desc"}
+
+	return NewDashboardRequestBuilder().
+		WithTitle("China errors").
+		WithPanel(NewDashboardPanel(DashboardReferencesTypeVisualization, visualizationId, 0, 0, 24, 15)).
+		WithPanel(searchPanel).
+		WithOptions(&DashboardOptions{DarkTheme: true, UseMargins: true})
+}
+
+func Test_DashboardPanels_legacy_layout_before_6(t *testing.T) {
+	request, err := newTestPanelDashboardRequestBuilder("vis-1", "search-1").BuildForVersion("5.5.3")
+	require.NoError(t, err)
+
+	assert.JSONEq(t, `[
+		{"col":1,"row":1,"size_x":6,"size_y":3,"panelIndex":1,"type":"visualization","id":"vis-1"},
+		{"col":7,"row":1,"size_x":6,"size_y":3,"panelIndex":2,"type":"search","id":"search-1","columns":["_source"],"sort":["@timestamp","desc"]}
+	]`, request.Attributes.PanelsJson)
+	assert.JSONEq(t, `{"darkTheme":true}`, request.Attributes.OptionsJson)
+	assert.Empty(t, request.References)
+
+	panels, err := decodeDashboardPanels(request.Attributes.PanelsJson, nil)
+	require.NoError(t, err)
+	assert.Equal(t, &DashboardPanelGridData{X: 24, Y: 0, W: 24, H: 15, I: "2"}, panels[1].GridData)
+	assert.Equal(t, "search-1", panels[1].Id)
+}
+
+func Test_DashboardPanels_grid_layout_for_6(t *testing.T) {
+	request, err := newTestPanelDashboardRequestBuilder("vis-1", "search-1").BuildForVersion(DefaultKibanaVersion6)
+	require.NoError(t, err)
+
+	panels := []map[string]interface{}{}
+	require.NoError(t, json.Unmarshal([]byte(request.Attributes.PanelsJson), &panels))
+	require.Len(t, panels, 2)
+	assert.Equal(t, "1", panels[0]["panelIndex"])
+	assert.Equal(t, "vis-1", panels[0]["id"])
+	assert.Equal(t, "visualization", panels[0]["type"])
+	assert.Equal(t, DefaultKibanaVersion6, panels[0]["version"])
+	assert.Equal(t, map[string]interface{}{"x": 0.0, "y": 0.0, "w": 24.0, "h": 15.0, "i": "1"}, panels[0]["gridData"])
+	assert.NotContains(t, panels[0], "panelRefName")
+	assert.Empty(t, request.References)
+}
+
+func Test_DashboardPanels_references_for_7(t *testing.T) {
+	request, err := newTestPanelDashboardRequestBuilder("vis-1", "search-1").
+		WithReferences([]*DashboardReferences{{Name: "extra", Type: DashboardReferencesTypeSearch, Id: "search-2"}}).
+		BuildForVersion(DefaultKibanaVersion7)
+	require.NoError(t, err)
+
+	panels := []map[string]interface{}{}
+	require.NoError(t, json.Unmarshal([]byte(request.Attributes.PanelsJson), &panels))
+	assert.Equal(t, "panel_0", panels[0]["panelRefName"])
+	assert.NotContains(t, panels[0], "id")
+	assert.NotContains(t, panels[0], "type")
+
+	assert.Equal(t, []*DashboardReferences{
+		{Name: "extra", Type: DashboardReferencesTypeSearch, Id: "search-2"},
+		{Name: "panel_0", Type: DashboardReferencesTypeVisualization, Id: "vis-1"},
+		{Name: "panel_1", Type: DashboardReferencesTypeSearch, Id: "search-1"},
+	}, request.References)
+}
+
+func Test_DashboardCreateWithPanels(t *testing.T) {
+	client := DefaultTestKibanaClient()
+
+	searchRequest, _, err := createSearchRequest(client.Search(), client.Config.DefaultIndexId, t)
+	require.NoError(t, err)
+	search, err := client.Search().Create(searchRequest)
+	require.NoError(t, err)
+	defer client.Search().Delete(search.Id)
+
+	visualizationRequest, err := newTestVisualizationRequestBuilder().
+		WithSavedSearchId(search.Id).
+		Build(client.Config.KibanaVersion)
+	require.NoError(t, err)
+	visualization, err := client.Visualization().Create(visualizationRequest)
+	require.NoError(t, err)
+	defer client.Visualization().Delete(visualization.Id)
+
+	request, err := newTestPanelDashboardRequestBuilder(visualization.Id, search.Id).
+		BuildForVersion(client.Config.KibanaVersion)
+	require.NoError(t, err)
+
+	created, err := client.Dashboard().Create(request)
+	require.NoError(t, err)
+	defer client.Dashboard().Delete(created.Id)
+
+	dashboard, err := client.Dashboard().GetById(created.Id)
+	require.NoError(t, err)
+
+	panels, err := dashboard.Panels()
+	require.NoError(t, err)
+	require.Len(t, panels, 2)
+	assert.Equal(t, visualization.Id, panels[0].Id)
+	assert.Equal(t, DashboardReferencesTypeVisualization, panels[0].Type)
+	assert.Equal(t, search.Id, panels[1].Id)
+	assert.Equal(t, &DashboardPanelGridData{X: 24, Y: 0, W: 24, H: 15, I: "2"}, panels[1].GridData)
+
+	options, err := dashboard.Attributes.Options()
+	require.NoError(t, err)
+	assert.True(t, options.DarkTheme)
+}
+
+func Test_DashboardRequestBuilder_Build_requires_version_for_typed_attributes(t *testing.T) {
+	_, err := newTestPanelDashboardRequestBuilder("vis", "search").Build()
+	assert.EqualError(t, err, "typed panels, layouts, options, query, filters and refresh interval depend on the kibana version, use BuildForVersion")
+
+	_, err = NewDashboardRequestBuilder().WithTitle("China errors").WithQuery("geo.src:CN", QueryLanguageKuery).Build()
+	assert.Error(t, err)
+
+	request, err := newTestDashboardRequestBuilder("vis", "search").Build()
+	require.NoError(t, err)
+	assert.Equal(t, "{\"darkTheme\":false}", request.Attributes.OptionsJson)
+}
@@ -0,0 +1,208 @@
+package kibana
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	goversion "github.com/mcuadros/go-version"
+)
+
+const (
+	QueryLanguageLucene = "lucene"
+	QueryLanguageKuery  = "kuery"
+)
+
+// DashboardRefreshInterval is how often an open dashboard reloads its data, Value is in milliseconds. Display
+// and Section describe the refresh interval picker and are only stored before kibana 6.0.
+type DashboardRefreshInterval struct {
+	Display string `json:"display,omitempty"`
+	Pause   bool   `json:"pause"`
+	Section int    `json:"section,omitempty"`
+	Value   int64  `json:"value"`
+}
+
+type dashboardSearchSource600 struct {
+	Query        *SearchQuery600 `json:"query"`
+	Filter       []*SearchFilter `json:"filter"`
+	HighlightAll bool            `json:"highlightAll"`
+	Version      bool            `json:"version"`
+}
+
+type dashboardSearchSource553 struct {
+	Filter       []interface{} `json:"filter"`
+	HighlightAll bool          `json:"highlightAll"`
+	Version      bool          `json:"version"`
+}
+
+type dashboardQueryFilter553 struct {
+	Query *SearchQuery553 `json:"query"`
+}
+
+// NewDashboardRefreshInterval creates a refresh interval reloading the dashboard every interval, an interval
+// of zero pauses the refresh
+func NewDashboardRefreshInterval(interval time.Duration) *DashboardRefreshInterval {
+	return &DashboardRefreshInterval{
+		Pause: interval <= 0,
+		Value: int64(interval / time.Millisecond),
+	}
+}
+
+func (refreshInterval *DashboardRefreshInterval) forVersion(version string) *DashboardRefreshInterval {
+	encoded := &DashboardRefreshInterval{Pause: refreshInterval.Pause, Value: refreshInterval.Value}
+	if goversion.Compare(version, "6.0.0", "<") {
+		encoded.Display = refreshIntervalDisplay(refreshInterval)
+		encoded.Section = refreshInterval.Section
+	}
+
+	return encoded
+}
+
+func refreshIntervalDisplay(refreshInterval *DashboardRefreshInterval) string {
+	if refreshInterval.Pause || refreshInterval.Value <= 0 {
+		return "Off"
+	}
+
+	units := []struct {
+		name         string
+		milliseconds int64
+	}{
+		{"day", int64(24 * time.Hour / time.Millisecond)},
+		{"hour", int64(time.Hour / time.Millisecond)},
+		{"minute", int64(time.Minute / time.Millisecond)},
+		{"second", int64(time.Second / time.Millisecond)},
+	}
+
+	for _, unit := range units {
+		if refreshInterval.Value%unit.milliseconds == 0 {
+			count := refreshInterval.Value / unit.milliseconds
+			if count == 1 {
+				return fmt.Sprintf("1 %s", unit.name)
+			}
+
+			return fmt.Sprintf("%d %ss", count, unit.name)
+		}
+	}
+
+	return fmt.Sprintf("%d milliseconds", refreshInterval.Value)
+}
+
+// Query returns the text and language of the dashboard query, or nil when the dashboard has none
+func (dashboard *Dashboard) Query() (*SearchSourceQuery, error) {
+	searchSource, err := dashboard.searchSource()
+	if err != nil || searchSource == nil {
+		return nil, err
+	}
+
+	return searchSource.TypedQuery()
+}
+
+// Filters returns the filters of the dashboard, the index of 7.x filters is resolved from the dashboard
+// references. The query which kibana 5.x keeps as the first filter is returned by Query instead.
+func (dashboard *Dashboard) Filters() ([]*SearchFilter, error) {
+	filters := []*SearchFilter{}
+	searchSource, err := dashboard.searchSource()
+	if err != nil || searchSource == nil {
+		return filters, err
+	}
+
+	for _, filter := range searchSource.Filter {
+		if legacyQueryFilter(filter) != nil {
+			continue
+		}
+
+		if filter.Meta != nil && filter.Meta.Index == "" && filter.Meta.IndexRefName != "" {
+			for _, reference := range dashboard.References {
+				if reference.Name == filter.Meta.IndexRefName {
+					meta := *filter.Meta
+					meta.Index = reference.Id
+					resolved := *filter
+					resolved.Meta = &meta
+					filter = &resolved
+				}
+			}
+		}
+
+		filters = append(filters, filter)
+	}
+
+	return filters, nil
+}
+
+func (dashboard *Dashboard) searchSource() (*SearchSource, error) {
+	if dashboard.Attributes == nil || dashboard.Attributes.KibanaSavedObjectMeta == nil {
+		return nil, nil
+	}
+
+	searchSource, err := dashboard.Attributes.KibanaSavedObjectMeta.searchSource()
+	if err != nil {
+		return nil, fmt.Errorf("could not parse dashboard search source, error: %v", err)
+	}
+
+	return searchSource, nil
+}
+
+// encodeDashboardSearchSource serializes the query and filters to the searchSourceJSON format of the kibana
+// version. Before 6.0 the query is stored as the first filter, for 7.x and later filters refer to their index
+// pattern by the returned references.
+func encodeDashboardSearchSource(query *SearchQuery600, filters []*SearchFilter, version string) (string, []*DashboardReferences, error) {
+	var encoded interface{}
+	var references []*DashboardReferences
+
+	if query == nil {
+		query = &SearchQuery600{}
+	}
+
+	if query.Language == "" {
+		query = &SearchQuery600{Query: query.Query, Language: QueryLanguageLucene}
+	}
+
+	if goversion.Compare(version, "6.0.0", "<") {
+		if query.Language != QueryLanguageLucene {
+			return "", nil, fmt.Errorf("%s queries are not supported before kibana 6.0", query.Language)
+		}
+
+		queryString := query.Query
+		if queryString == "" {
+			queryString = "*"
+		}
+
+		legacyFilters := []interface{}{
+			&dashboardQueryFilter553{Query: &SearchQuery553{QueryString: &searchQueryString{Query: queryString, Analyze: true}}},
+		}
+
+		for _, filter := range filters {
+			legacyFilters = append(legacyFilters, filter)
+		}
+
+		encoded = &dashboardSearchSource553{Filter: legacyFilters, HighlightAll: true, Version: true}
+	} else {
+		encodedFilters := []*SearchFilter{}
+		for index, filter := range filters {
+			if goversion.Compare(version, "7.0.0", ">=") && filter.Meta != nil && filter.Meta.Index != "" {
+				meta := *filter.Meta
+				meta.IndexRefName = fmt.Sprintf("kibanaSavedObjectMeta.searchSourceJSON.filter[%d].meta.index", index)
+				references = append(references, &DashboardReferences{
+					Name: meta.IndexRefName,
+					Type: DashboardReferencesTypeIndexPattern,
+					Id:   meta.Index,
+				})
+				meta.Index = ""
+				encodedFilter := *filter
+				encodedFilter.Meta = &meta
+				filter = &encodedFilter
+			}
+
+			encodedFilters = append(encodedFilters, filter)
+		}
+
+		encoded = &dashboardSearchSource600{Query: query, Filter: encodedFilters, HighlightAll: true, Version: true}
+	}
+
+	data, err := json.Marshal(encoded)
+	if err != nil {
+		return "", nil, fmt.Errorf("could not encode dashboard search source, error: %v", err)
+	}
+
+	return string(data), references, nil
+}
@@ -0,0 +1,123 @@
+package kibana
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestQueryDashboardRequestBuilder(indexId string) *DashboardRequestBuilder {
+	return NewDashboardRequestBuilder().
+		WithTitle("China errors").
+		WithTimeRange("now-24h", "now").
+		WithRefreshInterval(NewDashboardRefreshInterval(30*time.Second)).
+		WithQuery("response:500", QueryLanguageLucene).
+		WithFilter(&SearchFilter{
+			Query: &SearchFilterQuery{Match: map[string]*SearchFilterQueryAttributes{
+				"geo.src": {Query: "CN", Type: "phrase"},
+			}},
+			Meta: &SearchFilterMetaData{
+				Index: indexId,
+				Type:  "phrase",
+				Key:   "geo.src",
+				Value: "CN",
+			},
+		})
+}
+
+func Test_DashboardQuery_legacy_search_source_before_6(t *testing.T) {
+	request, err := newTestQueryDashboardRequestBuilder("logstash-*").BuildForVersion("5.5.3")
+	require.NoError(t, err)
+
+	assert.True(t, request.Attributes.TimeRestore)
+	assert.Equal(t, "now-24h", request.Attributes.TimeFrom)
+	assert.Equal(t, "now", request.Attributes.TimeTo)
+	assert.Equal(t, &DashboardRefreshInterval{Display: "30 seconds", Value: 30000}, request.Attributes.RefreshInterval)
+
+	var searchSource struct {
+		Filter []json.RawMessage `json:"filter"`
+	}
+	require.NoError(t, json.Unmarshal([]byte(request.Attributes.KibanaSavedObjectMeta.SearchSourceJSON), &searchSource))
+	require.Len(t, searchSource.Filter, 2)
+	assert.JSONEq(t, `{"query":{"query_string":{"query":"response:500","analyze_wildcard":true}}}`, string(searchSource.Filter[0]))
+
+	dashboard := &Dashboard{Attributes: request.Attributes}
+	query, err := dashboard.Query()
+	require.NoError(t, err)
+	assert.Equal(t, &SearchSourceQuery{Query: "response:500", Language: QueryLanguageLucene}, query)
+	filters, err := dashboard.Filters()
+	require.NoError(t, err)
+	require.Len(t, filters, 1)
+	assert.Equal(t, "logstash-*", filters[0].Meta.Index)
+
+	_, err = NewDashboardRequestBuilder().WithQuery("geo.src:CN", QueryLanguageKuery).BuildForVersion("5.5.3")
+	assert.EqualError(t, err, "kuery queries are not supported before kibana 6.0")
+}
+
+func Test_DashboardQuery_search_source_for_6(t *testing.T) {
+	request, err := newTestQueryDashboardRequestBuilder("logstash-*").BuildForVersion(DefaultKibanaVersion6)
+	require.NoError(t, err)
+
+	assert.Equal(t, &DashboardRefreshInterval{Value: 30000}, request.Attributes.RefreshInterval)
+
+	var searchSource map[string]interface{}
+	require.NoError(t, json.Unmarshal([]byte(request.Attributes.KibanaSavedObjectMeta.SearchSourceJSON), &searchSource))
+	assert.Equal(t, map[string]interface{}{"query": "response:500", "language": "lucene"}, searchSource["query"])
+	assert.Len(t, searchSource["filter"], 1)
+	assert.Empty(t, request.References)
+}
+
+func Test_DashboardQuery_filter_references_for_7(t *testing.T) {
+	request, err := newTestQueryDashboardRequestBuilder("logstash-*").BuildForVersion(DefaultKibanaVersion7)
+	require.NoError(t, err)
+
+	assert.Equal(t, []*DashboardReferences{{
+		Name: "kibanaSavedObjectMeta.searchSourceJSON.filter[0].meta.index",
+		Type: DashboardReferencesTypeIndexPattern,
+		Id:   "logstash-*",
+	}}, request.References)
+
+	dashboard := &Dashboard{Attributes: request.Attributes, References: request.References}
+	filters, err := dashboard.Filters()
+	require.NoError(t, err)
+	require.Len(t, filters, 1)
+	assert.Equal(t, "logstash-*", filters[0].Meta.Index)
+	assert.Equal(t, "kibanaSavedObjectMeta.searchSourceJSON.filter[0].meta.index", filters[0].Meta.IndexRefName)
+}
+
+func Test_DashboardRefreshInterval_display(t *testing.T) {
+	assert.Equal(t, "Off", refreshIntervalDisplay(NewDashboardRefreshInterval(0)))
+	assert.Equal(t, "1 minute", refreshIntervalDisplay(NewDashboardRefreshInterval(time.Minute)))
+	assert.Equal(t, "2 hours", refreshIntervalDisplay(NewDashboardRefreshInterval(2*time.Hour)))
+	assert.Equal(t, "1500 milliseconds", refreshIntervalDisplay(NewDashboardRefreshInterval(1500*time.Millisecond)))
+}
+
+func Test_DashboardCreateWithTimeRangeAndQuery(t *testing.T) {
+	client := DefaultTestKibanaClient()
+
+	request, err := newTestQueryDashboardRequestBuilder(client.Config.DefaultIndexId).
+		BuildForVersion(client.Config.KibanaVersion)
+	require.NoError(t, err)
+
+	created, err := client.Dashboard().Create(request)
+	require.NoError(t, err)
+	defer client.Dashboard().Delete(created.Id)
+
+	dashboard, err := client.Dashboard().GetById(created.Id)
+	require.NoError(t, err)
+	assert.True(t, dashboard.Attributes.TimeRestore)
+	assert.Equal(t, "now-24h", dashboard.Attributes.TimeFrom)
+	assert.Equal(t, "now", dashboard.Attributes.TimeTo)
+	assert.Equal(t, int64(30000), dashboard.Attributes.RefreshInterval.Value)
+
+	query, err := dashboard.Query()
+	require.NoError(t, err)
+	assert.Equal(t, &SearchSourceQuery{Query: "response:500", Language: QueryLanguageLucene}, query)
+	filters, err := dashboard.Filters()
+	require.NoError(t, err)
+	require.Len(t, filters, 1)
+	assert.Equal(t, client.Config.DefaultIndexId, filters[0].Meta.Index)
+}
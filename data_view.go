@@ -0,0 +1,505 @@
+package kibana
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	goversion "github.com/mcuadros/go-version"
+)
+
+const dataViewsPath = "/api/data_views"
+
+type DataViewClient interface {
+	Create(request *CreateDataViewRequest) (*DataView, error)
+	GetById(id string) (*DataView, error)
+	List() ([]*DataViewListItem, error)
+	Update(id string, request *UpdateDataViewRequest) (*DataView, error)
+	Delete(id string) error
+	SetDefault(id string) error
+	GetDefault() (*DataView, error)
+	UpdateFields(id string, fields map[string]*DataViewFieldUpdate) (*DataView, error)
+	SetRuntimeField(id string, name string, field *RuntimeField) (*DataView, error)
+	GetRuntimeField(id string, name string) (*RuntimeField, error)
+	DeleteRuntimeField(id string, name string) error
+}
+
+type dataViewClient800 struct {
+	config *Config
+	client *HttpAgent
+}
+
+// DataView is the kibana 8.0 and later replacement of the index pattern, it is stored as an index-pattern saved
+// object but managed with the /api/data_views api which decodes the fields, formats and runtime fields
+type DataView struct {
+	Id              string                              `json:"id,omitempty"`
+	Version         string                              `json:"version,omitempty"`
+	Title           string                              `json:"title"`
+	Name            string                              `json:"name,omitempty"`
+	TimeFieldName   string                              `json:"timeFieldName,omitempty"`
+	SourceFilters   []*DataViewSourceFilter             `json:"sourceFilters,omitempty"`
+	FieldFormats    map[string]*FieldFormat             `json:"fieldFormats,omitempty"`
+	FieldAttrs      map[string]*DataViewFieldAttributes `json:"fieldAttrs,omitempty"`
+	RuntimeFieldMap map[string]*RuntimeField            `json:"runtimeFieldMap,omitempty"`
+	AllowNoIndex    bool                                `json:"allowNoIndex,omitempty"`
+	Namespaces      []string                            `json:"namespaces,omitempty"`
+	Fields          map[string]*DataViewField           `json:"fields,omitempty"`
+}
+
+type DataViewListItem struct {
+	Id         string   `json:"id"`
+	Title      string   `json:"title"`
+	Name       string   `json:"name,omitempty"`
+	Namespaces []string `json:"namespaces,omitempty"`
+}
+
+type DataViewSourceFilter struct {
+	Value string `json:"value"`
+}
+
+type DataViewFieldAttributes struct {
+	CustomLabel string `json:"customLabel,omitempty"`
+	Count       int    `json:"count,omitempty"`
+}
+
+type DataViewField struct {
+	Name              string        `json:"name"`
+	Type              string        `json:"type"`
+	EsTypes           []string      `json:"esTypes,omitempty"`
+	Count             int           `json:"count"`
+	Scripted          bool          `json:"scripted"`
+	Searchable        bool          `json:"searchable"`
+	Aggregatable      bool          `json:"aggregatable"`
+	ReadFromDocValues bool          `json:"readFromDocValues"`
+	CustomLabel       string        `json:"customLabel,omitempty"`
+	Format            *FieldFormat  `json:"format,omitempty"`
+	RuntimeField      *RuntimeField `json:"runtimeField,omitempty"`
+}
+
+// DataViewFieldUpdate changes the display attributes of a field, unset values are left unchanged
+type DataViewFieldUpdate struct {
+	CustomLabel string       `json:"customLabel,omitempty"`
+	Count       int          `json:"count,omitempty"`
+	Format      *FieldFormat `json:"format,omitempty"`
+}
+
+type CreateDataViewRequest struct {
+	DataView *DataView `json:"data_view"`
+	Override bool      `json:"override,omitempty"`
+}
+
+type UpdateDataViewRequest struct {
+	DataView      *DataViewUpdate `json:"data_view"`
+	RefreshFields bool            `json:"refresh_fields,omitempty"`
+}
+
+// DataViewUpdate holds the attributes of the data view to change, unset values are left unchanged
+type DataViewUpdate struct {
+	Title           string                   `json:"title,omitempty"`
+	Name            string                   `json:"name,omitempty"`
+	TimeFieldName   string                   `json:"timeFieldName,omitempty"`
+	SourceFilters   []*DataViewSourceFilter  `json:"sourceFilters,omitempty"`
+	FieldFormats    map[string]*FieldFormat  `json:"fieldFormats,omitempty"`
+	RuntimeFieldMap map[string]*RuntimeField `json:"runtimeFieldMap,omitempty"`
+	AllowNoIndex    *bool                    `json:"allowNoIndex,omitempty"`
+}
+
+type DataViewRequestBuilder struct {
+	dataView *DataView
+	override bool
+}
+
+type dataViewResponse struct {
+	DataView *DataView `json:"data_view"`
+}
+
+type dataViewListResponse struct {
+	DataView []*DataViewListItem `json:"data_view"`
+}
+
+type dataViewDefault struct {
+	DataViewId string `json:"data_view_id"`
+	Force      bool   `json:"force,omitempty"`
+}
+
+type dataViewFieldsRequest struct {
+	Fields map[string]*DataViewFieldUpdate `json:"fields"`
+}
+
+type dataViewRuntimeFieldRequest struct {
+	Name         string        `json:"name"`
+	RuntimeField *RuntimeField `json:"runtimeField"`
+}
+
+type dataViewRuntimeFieldResponse struct {
+	Fields []*DataViewField `json:"fields"`
+}
+
+func NewDataViewRequestBuilder() *DataViewRequestBuilder {
+	return &DataViewRequestBuilder{dataView: &DataView{}}
+}
+
+// WithId sets the id of the data view, by default kibana generates an id
+func (builder *DataViewRequestBuilder) WithId(id string) *DataViewRequestBuilder {
+	builder.dataView.Id = id
+	return builder
+}
+
+// WithTitle sets the index pattern of the data view, such as logstash-*
+func (builder *DataViewRequestBuilder) WithTitle(title string) *DataViewRequestBuilder {
+	builder.dataView.Title = title
+	return builder
+}
+
+// WithName sets the display name of the data view, by default kibana shows the title
+func (builder *DataViewRequestBuilder) WithName(name string) *DataViewRequestBuilder {
+	builder.dataView.Name = name
+	return builder
+}
+
+func (builder *DataViewRequestBuilder) WithTimeFieldName(timeFieldName string) *DataViewRequestBuilder {
+	builder.dataView.TimeFieldName = timeFieldName
+	return builder
+}
+
+func (builder *DataViewRequestBuilder) WithSourceFilters(values ...string) *DataViewRequestBuilder {
+	for _, value := range values {
+		builder.dataView.SourceFilters = append(builder.dataView.SourceFilters, &DataViewSourceFilter{Value: value})
+	}
+
+	return builder
+}
+
+func (builder *DataViewRequestBuilder) WithFieldFormat(fieldName string, format *FieldFormat) *DataViewRequestBuilder {
+	if builder.dataView.FieldFormats == nil {
+		builder.dataView.FieldFormats = map[string]*FieldFormat{}
+	}
+
+	builder.dataView.FieldFormats[fieldName] = format
+	return builder
+}
+
+func (builder *DataViewRequestBuilder) WithRuntimeField(name string, field *RuntimeField) *DataViewRequestBuilder {
+	if builder.dataView.RuntimeFieldMap == nil {
+		builder.dataView.RuntimeFieldMap = map[string]*RuntimeField{}
+	}
+
+	builder.dataView.RuntimeFieldMap[name] = field
+	return builder
+}
+
+// WithAllowNoIndex lets the data view be created when no index matches its title
+func (builder *DataViewRequestBuilder) WithAllowNoIndex() *DataViewRequestBuilder {
+	builder.dataView.AllowNoIndex = true
+	return builder
+}
+
+// WithOverride replaces a data view with the same title instead of failing
+func (builder *DataViewRequestBuilder) WithOverride() *DataViewRequestBuilder {
+	builder.override = true
+	return builder
+}
+
+func (builder *DataViewRequestBuilder) Build() (*CreateDataViewRequest, error) {
+	if builder.dataView.Title == "" {
+		return nil, errors.New("data view title is required")
+	}
+
+	dataView := *builder.dataView
+	return &CreateDataViewRequest{DataView: &dataView, Override: builder.override}, nil
+}
+
+func (api *dataViewClient800) Create(request *CreateDataViewRequest) (*DataView, error) {
+	if err := api.checkVersion(); err != nil {
+		return nil, err
+	}
+
+	response, body, errs := api.client.
+		Post(api.config.KibanaBaseUri+dataViewsPath+"/data_view").
+		Operation("DataView.Create", "data-view", request.DataView.Id).
+		Set("kbn-version", api.config.KibanaVersion).
+		Send(request).
+		End()
+
+	if errs != nil {
+		return nil, errs[0]
+	}
+
+	if response.StatusCode >= 300 {
+		return nil, NewError(response, body, "Could not create data view")
+	}
+
+	return parseDataViewResponse(body, "create data view")
+}
+
+func (api *dataViewClient800) GetById(id string) (*DataView, error) {
+	if err := api.checkVersion(); err != nil {
+		return nil, err
+	}
+
+	response, body, errs := api.client.
+		Get(api.config.KibanaBaseUri+dataViewsPath+"/data_view/"+id).
+		Operation("DataView.GetById", "data-view", id).
+		Set("kbn-version", api.config.KibanaVersion).
+		End()
+
+	if errs != nil {
+		return nil, errs[0]
+	}
+
+	if response.StatusCode >= 300 {
+		return nil, NewError(response, body, "Could not fetch data view")
+	}
+
+	return parseDataViewResponse(body, "get data view")
+}
+
+func (api *dataViewClient800) List() ([]*DataViewListItem, error) {
+	if err := api.checkVersion(); err != nil {
+		return nil, err
+	}
+
+	response, body, errs := api.client.
+		Get(api.config.KibanaBaseUri+dataViewsPath).
+		Operation("DataView.List", "data-view", "").
+		Set("kbn-version", api.config.KibanaVersion).
+		End()
+
+	if errs != nil {
+		return nil, errs[0]
+	}
+
+	if response.StatusCode >= 300 {
+		return nil, NewError(response, body, "Could not fetch data views")
+	}
+
+	result := &dataViewListResponse{}
+	if err := json.Unmarshal([]byte(body), result); err != nil {
+		return nil, fmt.Errorf("could not parse fields from list data views response, error: %v", err)
+	}
+
+	return result.DataView, nil
+}
+
+func (api *dataViewClient800) Update(id string, request *UpdateDataViewRequest) (*DataView, error) {
+	if err := api.checkVersion(); err != nil {
+		return nil, err
+	}
+
+	response, body, errs := api.client.
+		Post(api.config.KibanaBaseUri+dataViewsPath+"/data_view/"+id).
+		Operation("DataView.Update", "data-view", id).
+		Set("kbn-version", api.config.KibanaVersion).
+		Send(request).
+		End()
+
+	if errs != nil {
+		return nil, errs[0]
+	}
+
+	if response.StatusCode >= 300 {
+		return nil, NewError(response, body, "Could not update data view")
+	}
+
+	return parseDataViewResponse(body, "update data view")
+}
+
+func (api *dataViewClient800) Delete(id string) error {
+	if err := api.checkVersion(); err != nil {
+		return err
+	}
+
+	response, body, errs := api.client.
+		Delete(api.config.KibanaBaseUri+dataViewsPath+"/data_view/"+id).
+		Operation("DataView.Delete", "data-view", id).
+		Set("kbn-version", api.config.KibanaVersion).
+		End()
+
+	if errs != nil {
+		return errs[0]
+	}
+
+	if response.StatusCode >= 300 {
+		return NewError(response, body, "Could not delete data view")
+	}
+
+	return nil
+}
+
+// SetDefault makes the data view the default data view, replacing the current default data view
+func (api *dataViewClient800) SetDefault(id string) error {
+	if err := api.checkVersion(); err != nil {
+		return err
+	}
+
+	response, body, errs := api.client.
+		Post(api.config.KibanaBaseUri+dataViewsPath+"/default").
+		Operation("DataView.SetDefault", "data-view", id).
+		Set("kbn-version", api.config.KibanaVersion).
+		Send(&dataViewDefault{DataViewId: id, Force: true}).
+		End()
+
+	if errs != nil {
+		return errs[0]
+	}
+
+	if response.StatusCode >= 300 {
+		return NewError(response, body, "Could not set default data view")
+	}
+
+	return nil
+}
+
+// GetDefault returns the default data view, or nil when no default data view is set
+func (api *dataViewClient800) GetDefault() (*DataView, error) {
+	if err := api.checkVersion(); err != nil {
+		return nil, err
+	}
+
+	response, body, errs := api.client.
+		Get(api.config.KibanaBaseUri+dataViewsPath+"/default").
+		Operation("DataView.GetDefault", "data-view", "").
+		Set("kbn-version", api.config.KibanaVersion).
+		End()
+
+	if errs != nil {
+		return nil, errs[0]
+	}
+
+	if response.StatusCode >= 300 {
+		return nil, NewError(response, body, "Could not fetch default data view")
+	}
+
+	result := &dataViewDefault{}
+	if err := json.Unmarshal([]byte(body), result); err != nil {
+		return nil, fmt.Errorf("could not parse fields from get default data view response, error: %v", err)
+	}
+
+	if result.DataViewId == "" {
+		return nil, nil
+	}
+
+	return api.GetById(result.DataViewId)
+}
+
+// UpdateFields changes the custom label, popularity count and formatter of the fields by field name
+func (api *dataViewClient800) UpdateFields(id string, fields map[string]*DataViewFieldUpdate) (*DataView, error) {
+	if err := api.checkVersion(); err != nil {
+		return nil, err
+	}
+
+	response, body, errs := api.client.
+		Post(api.config.KibanaBaseUri+dataViewsPath+"/data_view/"+id+"/fields").
+		Operation("DataView.UpdateFields", "data-view", id).
+		Set("kbn-version", api.config.KibanaVersion).
+		Send(&dataViewFieldsRequest{Fields: fields}).
+		End()
+
+	if errs != nil {
+		return nil, errs[0]
+	}
+
+	if response.StatusCode >= 300 {
+		return nil, NewError(response, body, "Could not update data view fields")
+	}
+
+	return parseDataViewResponse(body, "update data view fields")
+}
+
+// SetRuntimeField creates the runtime field or replaces the runtime field of the same name
+func (api *dataViewClient800) SetRuntimeField(id string, name string, field *RuntimeField) (*DataView, error) {
+	if err := api.checkVersion(); err != nil {
+		return nil, err
+	}
+
+	response, body, errs := api.client.
+		Put(api.config.KibanaBaseUri+dataViewsPath+"/data_view/"+id+"/runtime_field").
+		Operation("DataView.SetRuntimeField", "data-view", id).
+		Set("kbn-version", api.config.KibanaVersion).
+		Send(&dataViewRuntimeFieldRequest{Name: name, RuntimeField: field}).
+		End()
+
+	if errs != nil {
+		return nil, errs[0]
+	}
+
+	if response.StatusCode >= 300 {
+		return nil, NewError(response, body, "Could not set data view runtime field")
+	}
+
+	return parseDataViewResponse(body, "set data view runtime field")
+}
+
+func (api *dataViewClient800) GetRuntimeField(id string, name string) (*RuntimeField, error) {
+	if err := api.checkVersion(); err != nil {
+		return nil, err
+	}
+
+	response, body, errs := api.client.
+		Get(api.config.KibanaBaseUri+dataViewsPath+"/data_view/"+id+"/runtime_field/"+name).
+		Operation("DataView.GetRuntimeField", "data-view", id).
+		Set("kbn-version", api.config.KibanaVersion).
+		End()
+
+	if errs != nil {
+		return nil, errs[0]
+	}
+
+	if response.StatusCode >= 300 {
+		return nil, NewError(response, body, "Could not fetch data view runtime field")
+	}
+
+	result := &dataViewRuntimeFieldResponse{}
+	if err := json.Unmarshal([]byte(body), result); err != nil {
+		return nil, fmt.Errorf("could not parse fields from get data view runtime field response, error: %v", err)
+	}
+
+	if len(result.Fields) == 0 || result.Fields[0].RuntimeField == nil {
+		return nil, fmt.Errorf("data view %s has no runtime field %s", id, name)
+	}
+
+	return result.Fields[0].RuntimeField, nil
+}
+
+func (api *dataViewClient800) DeleteRuntimeField(id string, name string) error {
+	if err := api.checkVersion(); err != nil {
+		return err
+	}
+
+	response, body, errs := api.client.
+		Delete(api.config.KibanaBaseUri+dataViewsPath+"/data_view/"+id+"/runtime_field/"+name).
+		Operation("DataView.DeleteRuntimeField", "data-view", id).
+		Set("kbn-version", api.config.KibanaVersion).
+		End()
+
+	if errs != nil {
+		return errs[0]
+	}
+
+	if response.StatusCode >= 300 {
+		return NewError(response, body, "Could not delete data view runtime field")
+	}
+
+	return nil
+}
+
+func (api *dataViewClient800) checkVersion() error {
+	if goversion.Compare(api.config.KibanaVersion, "8.0.0", "<") {
+		return fmt.Errorf("data views are not supported by kibana %s, they require kibana 8.0 or later, use the index pattern client instead", api.config.KibanaVersion)
+	}
+
+	return nil
+}
+
+func parseDataViewResponse(body string, action string) (*DataView, error) {
+	result := &dataViewResponse{}
+	if err := json.Unmarshal([]byte(body), result); err != nil {
+		return nil, fmt.Errorf("could not parse fields from %s response, error: %v", action, err)
+	}
+
+	if result.DataView == nil {
+		return nil, fmt.Errorf("could not parse fields from %s response, error: missing data_view", action)
+	}
+
+	return result.DataView, nil
+}
@@ -0,0 +1,135 @@
+package kibana
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_DataViewLifecycle(t *testing.T) {
+	fake := NewFakeKibana(DefaultKibanaVersion8)
+	defer fake.Close()
+
+	client := NewClient(fake.Config())
+	dataViewApi := client.DataView()
+
+	request, err := NewDataViewRequestBuilder().
+		WithId("logs").
+		WithTitle("logs-*").
+		WithName("Logs").
+		WithTimeFieldName("@timestamp").
+		WithSourceFilters("message").
+		WithFieldFormat("bytes", NewBytesFieldFormat("0,0.[0]b")).
+		Build()
+	require.NoError(t, err)
+
+	created, err := dataViewApi.Create(request)
+	require.NoError(t, err)
+	assert.Equal(t, "logs", created.Id)
+	assert.Equal(t, "Logs", created.Name)
+	assert.Equal(t, FieldFormatBytes, created.FieldFormats["bytes"].Id)
+	assert.Equal(t, FieldFormatBytes, created.Fields["bytes"].Format.Id)
+
+	_, err = dataViewApi.Create(request)
+	assert.Error(t, err, "a data view with the same title is rejected")
+
+	dataView, err := dataViewApi.GetById(created.Id)
+	require.NoError(t, err)
+	assert.Equal(t, "logs-*", dataView.Title)
+	require.Len(t, dataView.SourceFilters, 1)
+	assert.Equal(t, "message", dataView.SourceFilters[0].Value)
+
+	dataViews, err := dataViewApi.List()
+	require.NoError(t, err)
+	require.Len(t, dataViews, 1)
+	assert.Equal(t, "logs-*", dataViews[0].Title)
+
+	updated, err := dataViewApi.Update(created.Id, &UpdateDataViewRequest{DataView: &DataViewUpdate{Name: "Application logs"}})
+	require.NoError(t, err)
+	assert.Equal(t, "Application logs", updated.Name)
+	assert.Equal(t, "@timestamp", updated.TimeFieldName, "unset attributes are kept")
+
+	updated, err = dataViewApi.UpdateFields(created.Id, map[string]*DataViewFieldUpdate{
+		"host": {CustomLabel: "Host name", Count: 3},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "Host name", updated.Fields["host"].CustomLabel)
+	assert.Equal(t, 3, updated.Fields["host"].Count)
+
+	indexPattern, err := client.IndexPattern().GetById(created.Id)
+	require.NoError(t, err)
+	formats, err := indexPattern.Attributes.FieldFormats()
+	require.NoError(t, err)
+	assert.Contains(t, formats, "bytes", "data views are stored as index patterns")
+
+	require.NoError(t, dataViewApi.Delete(created.Id))
+	_, err = dataViewApi.GetById(created.Id)
+	assertNotFound(t, err)
+}
+
+func Test_DataViewDefault(t *testing.T) {
+	fake := NewFakeKibana(DefaultKibanaVersion8)
+	defer fake.Close()
+
+	dataViewApi := NewClient(fake.Config()).DataView()
+
+	dataView, err := dataViewApi.GetDefault()
+	require.NoError(t, err)
+	assert.Nil(t, dataView)
+
+	request, err := NewDataViewRequestBuilder().WithTitle("metrics-*").Build()
+	require.NoError(t, err)
+	created, err := dataViewApi.Create(request)
+	require.NoError(t, err)
+
+	require.NoError(t, dataViewApi.SetDefault(created.Id))
+	dataView, err = dataViewApi.GetDefault()
+	require.NoError(t, err)
+	require.NotNil(t, dataView)
+	assert.Equal(t, created.Id, dataView.Id)
+}
+
+func Test_DataViewRuntimeFields(t *testing.T) {
+	fake := NewFakeKibana(DefaultKibanaVersion8)
+	defer fake.Close()
+
+	dataViewApi := NewClient(fake.Config()).DataView()
+	request, err := NewDataViewRequestBuilder().
+		WithTitle("logs-*").
+		WithRuntimeField("status_class", NewRuntimeField("keyword", "emit(doc['response'].value.substring(0, 1) + 'xx')")).
+		Build()
+	require.NoError(t, err)
+	created, err := dataViewApi.Create(request)
+	require.NoError(t, err)
+
+	field, err := dataViewApi.GetRuntimeField(created.Id, "status_class")
+	require.NoError(t, err)
+	assert.Equal(t, "keyword", field.Type)
+
+	dataView, err := dataViewApi.SetRuntimeField(created.Id, "day_of_week", NewRuntimeField("keyword", "emit(doc['@timestamp'].value.dayOfWeekEnum.toString())"))
+	require.NoError(t, err)
+	assert.Len(t, dataView.RuntimeFieldMap, 2)
+	assert.Equal(t, "keyword", dataView.Fields["day_of_week"].Type)
+
+	require.NoError(t, dataViewApi.DeleteRuntimeField(created.Id, "status_class"))
+	_, err = dataViewApi.GetRuntimeField(created.Id, "status_class")
+	assertNotFound(t, err)
+
+	dataView, err = dataViewApi.GetById(created.Id)
+	require.NoError(t, err)
+	assert.Len(t, dataView.RuntimeFieldMap, 1)
+}
+
+func Test_DataViewRequestBuilder_requires_title(t *testing.T) {
+	_, err := NewDataViewRequestBuilder().WithName("Logs").Build()
+	assert.EqualError(t, err, "data view title is required")
+}
+
+func Test_DataView_requires_kibana_8(t *testing.T) {
+	fake := NewFakeKibana(DefaultKibanaVersion7)
+	defer fake.Close()
+
+	_, err := NewClient(fake.Config()).DataView().List()
+	assert.EqualError(t, err, "data views are not supported by kibana "+DefaultKibanaVersion7+", they require kibana 8.0 or later, use the index pattern client instead")
+}
@@ -0,0 +1,254 @@
+package kibana
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"sort"
+	"strings"
+)
+
+type FieldClient interface {
+	GetFields(indexPatternTitle string) ([]*IndexPatternField, error)
+}
+
+type fieldClient553 struct {
+	config *Config
+	client *HttpAgent
+}
+
+type fieldClient600 struct {
+	config *Config
+	client *HttpAgent
+}
+
+type fieldCapsResponse struct {
+	Fields map[string]map[string]*fieldCapability `json:"fields"`
+}
+
+type fieldCapability struct {
+	Type         string `json:"type"`
+	Searchable   bool   `json:"searchable"`
+	Aggregatable bool   `json:"aggregatable"`
+}
+
+type consoleProxyQuery struct {
+	Path   string `json:"path"`
+	Method string `json:"method"`
+}
+
+// kibanaFieldTypes maps the elasticsearch field types to the field types kibana shows, elasticsearch types
+// missing from the map are unknown to kibana
+var kibanaFieldTypes = map[string]string{
+	"text":             "string",
+	"keyword":          "string",
+	"string":           "string",
+	"constant_keyword": "string",
+	"wildcard":         "string",
+	"version":          "string",
+	"long":             "number",
+	"integer":          "number",
+	"short":            "number",
+	"byte":             "number",
+	"double":           "number",
+	"float":            "number",
+	"half_float":       "number",
+	"scaled_float":     "number",
+	"unsigned_long":    "number",
+	"token_count":      "number",
+	"date":             "date",
+	"date_nanos":       "date",
+	"boolean":          "boolean",
+	"ip":               "ip",
+	"geo_point":        "geo_point",
+	"geo_shape":        "geo_shape",
+	"shape":            "geo_shape",
+	"murmur3":          "murmur3",
+	"attachment":       "attachment",
+	"histogram":        "histogram",
+	"_source":          "_source",
+}
+
+// GetFields reads the fields of the indices matching the index pattern title from the elasticsearch field
+// capabilities, using the kibana server proxy to elasticsearch
+func (api *fieldClient553) GetFields(indexPatternTitle string) ([]*IndexPatternField, error) {
+	response, body, errs := api.client.
+		Get(fmt.Sprintf("%s/elasticsearch/%s/_field_caps?fields=*", api.config.KibanaBaseUri, url.PathEscape(indexPatternTitle))).
+		Operation("Field.GetFields", "index-pattern", indexPatternTitle).
+		Set("kbn-version", api.config.KibanaVersion).
+		End()
+
+	if errs != nil {
+		return nil, errs[0]
+	}
+
+	if response.StatusCode >= 300 {
+		return nil, NewError(response, body, "Could not fetch field capabilities")
+	}
+
+	return parseFieldCaps(body)
+}
+
+// GetFields reads the fields of the indices matching the index pattern title from the elasticsearch field
+// capabilities, using the kibana console proxy to elasticsearch
+func (api *fieldClient600) GetFields(indexPatternTitle string) ([]*IndexPatternField, error) {
+	response, body, errs := api.client.
+		Post(api.config.KibanaBaseUri+"/api/console/proxy").
+		Operation("Field.GetFields", "index-pattern", indexPatternTitle).
+		Set("kbn-version", api.config.KibanaVersion).
+		Query(consoleProxyQuery{Path: url.PathEscape(indexPatternTitle) + "/_field_caps?fields=*", Method: "GET"}).
+		End()
+
+	if errs != nil {
+		return nil, errs[0]
+	}
+
+	if response.StatusCode >= 300 {
+		return nil, NewError(response, body, "Could not fetch field capabilities")
+	}
+
+	return parseFieldCaps(body)
+}
+
+// parseFieldCaps converts the field capabilities to index pattern fields sorted by name. Object and nested
+// fields are left out as their sub fields are listed separately, a field mapped to different kibana types in
+// different indices has the conflict type.
+func parseFieldCaps(body string) ([]*IndexPatternField, error) {
+	result := &fieldCapsResponse{}
+	if err := json.Unmarshal([]byte(body), result); err != nil {
+		return nil, fmt.Errorf("could not parse field capabilities response, error: %v", err)
+	}
+
+	var metaFields []string
+	json.Unmarshal([]byte(indexPatternMetaFields), &metaFields)
+
+	fields := []*IndexPatternField{}
+	for name, capabilities := range result.Fields {
+		if strings.HasPrefix(name, "_") && !containsFieldType(metaFields, name) {
+			continue
+		}
+
+		field := &IndexPatternField{Name: name}
+		for esType, capability := range capabilities {
+			if esType == "object" || esType == "nested" {
+				continue
+			}
+
+			kibanaType, ok := kibanaFieldTypes[esType]
+			if !ok {
+				kibanaType = "unknown"
+			}
+
+			if field.Type == "" {
+				field.Type = kibanaType
+			} else if field.Type != kibanaType {
+				field.Type = "conflict"
+			}
+
+			field.EsTypes = append(field.EsTypes, esType)
+			field.Searchable = field.Searchable || capability.Searchable
+			field.Aggregatable = field.Aggregatable || capability.Aggregatable
+		}
+
+		if field.Type == "" {
+			continue
+		}
+
+		sort.Strings(field.EsTypes)
+		field.ReadFromDocValues = field.Aggregatable && !strings.HasPrefix(name, "_") && !containsFieldType(field.EsTypes, "text")
+		fields = append(fields, field)
+	}
+
+	sort.Slice(fields, func(i, j int) bool { return fields[i].Name < fields[j].Name })
+	return fields, nil
+}
+
+// ValidateSearch checks that the columns, sort fields and filters of the search use existing fields of the
+// index pattern, that sort fields are aggregatable and that filter fields are searchable
+func ValidateSearch(attributes *SearchAttributes, fields []*IndexPatternField) error {
+	fieldsByName := map[string]*IndexPatternField{}
+	for _, field := range fields {
+		fieldsByName[field.Name] = field
+	}
+
+	var problems []string
+	for _, column := range attributes.Columns {
+		if column == "_source" {
+			continue
+		}
+
+		if _, ok := fieldsByName[column]; !ok {
+			problems = append(problems, fmt.Sprintf("column %s does not exist in the index pattern", column))
+		}
+	}
+
+	for _, sortField := range attributes.Sort {
+		name := sortField.Field
+		if name == "_score" {
+			continue
+		}
+
+		field, ok := fieldsByName[name]
+		if !ok {
+			problems = append(problems, fmt.Sprintf("sort field %s does not exist in the index pattern", name))
+		} else if !field.Aggregatable {
+			problems = append(problems, fmt.Sprintf("sort field %s is not sortable", name))
+		}
+	}
+
+	if attributes.KibanaSavedObjectMeta != nil && attributes.KibanaSavedObjectMeta.SearchSourceJSON != "" {
+		searchSource := &SearchSource{}
+		if err := json.Unmarshal([]byte(attributes.KibanaSavedObjectMeta.SearchSourceJSON), searchSource); err != nil {
+			return fmt.Errorf("could not parse search source, error: %v", err)
+		}
+
+		for _, name := range searchFilterFields(searchSource.Filter) {
+			field, ok := fieldsByName[name]
+			if !ok {
+				problems = append(problems, fmt.Sprintf("filter field %s does not exist in the index pattern", name))
+			} else if !field.Searchable {
+				problems = append(problems, fmt.Sprintf("filter field %s is not searchable", name))
+			}
+		}
+	}
+
+	if len(problems) > 0 {
+		return fmt.Errorf("invalid search, %s", strings.Join(problems, "; "))
+	}
+
+	return nil
+}
+
+// searchFilterFields returns the names of the fields the filters match on, in order and without duplicates.
+// Custom filters are left out as the fields of their query dsl are not known.
+func searchFilterFields(filters []*SearchFilter) []string {
+	var names []string
+	seen := map[string]bool{}
+	for _, filter := range filters {
+		params, err := filter.TypedParams()
+		if err != nil {
+			continue
+		}
+
+		var name string
+		switch params := params.(type) {
+		case *PhraseFilterParams:
+			name = params.Field
+		case *PhrasesFilterParams:
+			name = params.Field
+		case *RangeFilterParams:
+			name = params.Field
+		case *ExistsFilterParams:
+			name = params.Field
+		case *GeoBoundingBoxFilterParams:
+			name = params.Field
+		}
+
+		if name != "" && !seen[name] {
+			seen[name] = true
+			names = append(names, name)
+		}
+	}
+
+	return names
+}
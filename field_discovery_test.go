@@ -0,0 +1,107 @@
+package kibana
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_FieldGetFields(t *testing.T) {
+	for _, kibanaVersion := range []string{DefaultKibanaVersion553, DefaultKibanaVersion7} {
+		t.Run(kibanaVersion, func(t *testing.T) {
+			fake := NewFakeKibana(kibanaVersion)
+			defer fake.Close()
+
+			fields, err := NewClient(fake.Config()).Field().GetFields("logstash-*")
+			require.NoError(t, err)
+
+			byName := map[string]*IndexPatternField{}
+			for _, field := range fields {
+				byName[field.Name] = field
+			}
+
+			require.Contains(t, byName, "bytes")
+			assert.Equal(t, "number", byName["bytes"].Type)
+			assert.Equal(t, []string{"long"}, byName["bytes"].EsTypes)
+			assert.True(t, byName["bytes"].Aggregatable)
+			assert.True(t, byName["bytes"].ReadFromDocValues)
+
+			require.Contains(t, byName, "message")
+			assert.Equal(t, "string", byName["message"].Type)
+			assert.True(t, byName["message"].Searchable)
+			assert.False(t, byName["message"].Aggregatable)
+
+			assert.NotContains(t, byName, "_score", "_score is not part of the mapping")
+		})
+	}
+}
+
+func Test_parseFieldCaps(t *testing.T) {
+	fields, err := parseFieldCaps(`{"fields": {
+		"status": {"long": {"type": "long", "searchable": true, "aggregatable": true},
+		           "keyword": {"type": "keyword", "searchable": true, "aggregatable": true}},
+		"latency": {"long": {"type": "long", "searchable": true, "aggregatable": true},
+		            "float": {"type": "float", "searchable": true, "aggregatable": true}},
+		"geo": {"object": {"type": "object", "searchable": false, "aggregatable": false}},
+		"message": {"text": {"type": "text", "searchable": true, "aggregatable": false}},
+		"_seq_no": {"_seq_no": {"type": "_seq_no", "searchable": true, "aggregatable": true}},
+		"_id": {"_id": {"type": "_id", "searchable": true, "aggregatable": true}}
+	}}`)
+	require.NoError(t, err)
+
+	require.Len(t, fields, 4)
+	assert.Equal(t, "_id", fields[0].Name)
+	assert.Equal(t, "unknown", fields[0].Type)
+	assert.False(t, fields[0].ReadFromDocValues)
+	assert.Equal(t, "latency", fields[1].Name)
+	assert.Equal(t, "number", fields[1].Type, "numeric types are all numbers")
+	assert.Equal(t, []string{"float", "long"}, fields[1].EsTypes)
+	assert.Equal(t, "message", fields[2].Name)
+	assert.False(t, fields[2].ReadFromDocValues)
+	assert.Equal(t, "status", fields[3].Name)
+	assert.Equal(t, "conflict", fields[3].Type)
+}
+
+func Test_ValidateSearch(t *testing.T) {
+	fake := NewFakeKibana(DefaultKibanaVersion7)
+	defer fake.Close()
+
+	client := NewClient(fake.Config())
+	fields, err := client.Field().GetFields("logstash-*")
+	require.NoError(t, err)
+
+	request, _, err := createSearchRequest(client.Search(), client.Config.DefaultIndexId, t)
+	require.NoError(t, err)
+	assert.NoError(t, ValidateSearch(request.Attributes, fields))
+
+	searchSource, err := client.Search().NewSearchSource().
+		WithIndexId(client.Config.DefaultIndexId).
+		WithFilter(&SearchFilter{Exists: &SearchFilterExists{Field: "geo.dest"}}).
+		Build()
+	require.NoError(t, err)
+
+	request, err = NewSearchRequestBuilder().
+		WithTitle("Invalid search").
+		WithDisplayColumns([]string{"host", "agent"}).
+		WithSortColumns([]string{"message"}, Descending).
+		WithSearchSource(searchSource).
+		Build()
+	require.NoError(t, err)
+
+	assert.EqualError(t, ValidateSearch(request.Attributes, fields), "invalid search, column agent does not exist in the index pattern; "+
+		"sort field message is not sortable; filter field geo.dest does not exist in the index pattern")
+}
+
+func Test_ValidateAggregations_with_discovered_fields(t *testing.T) {
+	fake := NewFakeKibana(DefaultKibanaVersion7)
+	defer fake.Close()
+
+	fields, err := NewClient(fake.Config()).Field().GetFields("logstash-*")
+	require.NoError(t, err)
+
+	aggregation, err := NewAggregation("2", AggregationSchemaSegment, &TermsParams{Field: "message", Size: 5, Order: "desc", OrderBy: "1"})
+	require.NoError(t, err)
+	assert.EqualError(t, ValidateAggregations([]*VisualizationAggregation{aggregation}, fields),
+		"invalid aggregations, aggregation 2: field message is not aggregatable")
+}
@@ -1,9 +1,12 @@
 package kibana
 
 import (
+	"context"
 	"crypto/tls"
 	"log"
+	"net/http"
 	"os"
+	"sync"
 
 	"github.com/parnurzeal/gorequest"
 )
@@ -13,6 +16,18 @@ type HttpAgent struct {
 	authHandler AuthenticationHandler
 	config      *Config
 	logger      *log.Logger
+	handlers    *httpAgentHandlers
+	ctx         context.Context
+	operation   *operationName
+}
+
+// httpAgentHandlers are shared by an agent and the agents created from it, so the recorder, hooks and rate
+// limiter set on an agent also apply to the agents returned by WithContext
+type httpAgentHandlers struct {
+	mutex       sync.RWMutex
+	recorder    *Recorder
+	hooks       []RequestHook
+	rateLimiter *RateLimiter
 }
 
 type AuthenticationHandler interface {
@@ -52,6 +67,7 @@ func NewHttpAgent(config *Config, authHandler AuthenticationHandler) *HttpAgent
 		authHandler: authHandler,
 		config:      config,
 		logger:      log.New(os.Stderr, "", log.LstdFlags),
+		handlers:    &httpAgentHandlers{},
 	}
 }
 
@@ -104,7 +120,20 @@ func (authClient *HttpAgent) End(callback ...func(response gorequest.Response, b
 		return nil, "", []error{err}
 	}
 
-	return authClient.client.End(callback...)
+	if rateLimiter := authClient.handlers.limiter(); rateLimiter != nil {
+		release, err := rateLimiter.Wait(authClient.context())
+		if err != nil {
+			return nil, "", []error{err}
+		}
+
+		defer release()
+	}
+
+	endOperation := authClient.startOperation()
+	response, body, errs := authClient.client.End(callback...)
+	endOperation(response, errs)
+
+	return response, body, errs
 }
 
 func (authClient *HttpAgent) SetLogger(logger *log.Logger) *HttpAgent {
@@ -112,6 +141,44 @@ func (authClient *HttpAgent) SetLogger(logger *log.Logger) *HttpAgent {
 	return authClient
 }
 
+func (authClient *HttpAgent) SetRecorder(recorder *Recorder) *HttpAgent {
+	authClient.handlers.mutex.Lock()
+	defer authClient.handlers.mutex.Unlock()
+
+	authClient.handlers.recorder = recorder
+	return authClient
+}
+
+func (authClient *HttpAgent) SetRateLimiter(rateLimiter *RateLimiter) *HttpAgent {
+	authClient.handlers.mutex.Lock()
+	defer authClient.handlers.mutex.Unlock()
+
+	authClient.handlers.rateLimiter = rateLimiter
+	return authClient
+}
+
+func (authClient *HttpAgent) AddHook(hook RequestHook) *HttpAgent {
+	authClient.handlers.mutex.Lock()
+	defer authClient.handlers.mutex.Unlock()
+
+	authClient.handlers.hooks = append(append([]RequestHook{}, authClient.handlers.hooks...), hook)
+	return authClient
+}
+
+func (handlers *httpAgentHandlers) limiter() *RateLimiter {
+	handlers.mutex.RLock()
+	defer handlers.mutex.RUnlock()
+
+	return handlers.rateLimiter
+}
+
+func (handlers *httpAgentHandlers) roundTrippers() (*Recorder, []RequestHook) {
+	handlers.mutex.RLock()
+	defer handlers.mutex.RUnlock()
+
+	return handlers.recorder, handlers.hooks
+}
+
 func NewBasicAuthentication(userName string, password string) *BasicAuthenticationHandler {
 	return &BasicAuthenticationHandler{userName: userName, password: password}
 }
@@ -134,7 +201,30 @@ func (auth *NoAuthenticationHandler) ChangeAccount(accountId string, agent *Http
 }
 
 func (authClient *HttpAgent) clone() *HttpAgent {
-	return &HttpAgent{authHandler: authClient.authHandler, client: authClient.createSuperAgent(), config: authClient.config}
+	return &HttpAgent{
+		authHandler: authClient.authHandler,
+		client:      authClient.createSuperAgent(),
+		config:      authClient.config,
+		logger:      authClient.logger,
+		handlers:    authClient.handlers,
+		ctx:         authClient.ctx,
+	}
+}
+
+// WithContext returns a copy of the agent whose requests belong to ctx, the copy shares the recorder, hooks and
+// rate limiter of the agent
+func (authClient *HttpAgent) WithContext(ctx context.Context) *HttpAgent {
+	agent := *authClient
+	agent.ctx = ctx
+	return &agent
+}
+
+func (authClient *HttpAgent) context() context.Context {
+	if authClient.ctx == nil {
+		return context.Background()
+	}
+
+	return authClient.ctx
 }
 
 func (authClient *HttpAgent) createSuperAgent() *gorequest.SuperAgent {
@@ -144,5 +234,39 @@ func (authClient *HttpAgent) createSuperAgent() *gorequest.SuperAgent {
 	if authClient.config.Insecure {
 		superAgent.TLSClientConfig(&tls.Config{InsecureSkipVerify: true})
 	}
+
+	if recorder, hooks := authClient.handlers.roundTrippers(); recorder != nil || len(hooks) > 0 {
+		useRoundTripper(superAgent, func(next http.RoundTripper) http.RoundTripper {
+			return chainRoundTrippers(recorder, hooks, next)
+		})
+	}
+
 	return superAgent
 }
+
+// chainRoundTrippers chains the hooks in front of the recorder, so hooks also observe replayed requests
+func chainRoundTrippers(recorder *Recorder, hooks []RequestHook, next http.RoundTripper) http.RoundTripper {
+	if recorder != nil {
+		next = recorder.roundTripper(next)
+	}
+
+	if len(hooks) > 0 {
+		next = newHookRoundTripper(hooks)(next)
+	}
+
+	return next
+}
+
+// useRoundTripper sends the requests of the super agent through the round tripper returned by wrap. gorequest
+// always installs its own *http.Transport on the client, so the round tripper is registered on that transport
+// as the handler of the http and https schemes, and a copy of the original transport is passed on as next.
+// http2 is disabled on the original transport as it would otherwise claim the https scheme for itself.
+func useRoundTripper(superAgent *gorequest.SuperAgent, wrap func(next http.RoundTripper) http.RoundTripper) {
+	superAgent.Transport.TLSNextProto = map[string]func(string, *tls.Conn) http.RoundTripper{}
+	next := superAgent.Transport.Clone()
+	next.TLSNextProto = nil
+
+	roundTripper := wrap(next)
+	superAgent.Transport.RegisterProtocol("http", roundTripper)
+	superAgent.Transport.RegisterProtocol("https", roundTripper)
+}
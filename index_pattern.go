@@ -35,9 +35,20 @@ func getUrlFromVersion(version string, key string, config *Config, name string,
 }
 
 type IndexPatternClient interface {
+	Create(request *CreateIndexPatternRequest) (*IndexPatternCreateResult, error)
+	GetById(id string) (*IndexPattern, error)
+	List() ([]*IndexPattern, error)
+	// Update changes the attributes set in the request, the attributes left empty, the title included, keep their value
+	Update(id string, request *UpdateIndexPatternRequest) (*IndexPattern, error)
+	Delete(id string) error
 	SetDefault(indexPatternId string) error
-	Create() (*IndexPatternCreateResult, error)
+	GetDefault() (*IndexPattern, error)
 	RefreshFields(indexPatternId string) error
+	SetScriptedField(indexPatternId string, field *IndexPatternField) error
+	DeleteScriptedField(indexPatternId string, name string) error
+	SetRuntimeField(indexPatternId string, name string, field *RuntimeField) error
+	DeleteRuntimeField(indexPatternId string, name string) error
+	SetFieldFormat(indexPatternId string, fieldName string, format *FieldFormat) error
 }
 
 type IndexPatternClient553 struct {
@@ -51,9 +62,46 @@ type IndexPatternClient600 struct {
 }
 
 type IndexPattern struct {
+	Id         string                  `json:"id,omitempty"`
+	Type       string                  `json:"type,omitempty"`
+	Version    version                 `json:"version,omitempty"`
 	Attributes *IndexPatternAttributes `json:"attributes"`
 }
 
+type CreateIndexPatternRequest struct {
+	Id         string                  `json:"-"`
+	Attributes *IndexPatternAttributes `json:"attributes"`
+}
+
+type UpdateIndexPatternRequest struct {
+	Attributes *IndexPatternAttributes `json:"attributes"`
+}
+
+type IndexPatternRequestBuilder struct {
+	id            string
+	title         string
+	timeFieldName string
+	fields        []*IndexPatternField
+}
+
+type indexPatternReadResult553 struct {
+	Id      string                  `json:"_id"`
+	Type    string                  `json:"_type"`
+	Version version                 `json:"_version"`
+	Source  *IndexPatternAttributes `json:"_source"`
+}
+
+type indexPatternSettings struct {
+	Settings map[string]struct {
+		UserValue interface{} `json:"userValue"`
+	} `json:"settings"`
+}
+
+type fieldsForWildcardQuery struct {
+	Pattern    string `json:"pattern"`
+	MetaFields string `json:"meta_fields"`
+}
+
 type IndexPatternCreateResult struct {
 	Id         string                  `json:"id"`
 	Type       string                  `json:"type"`
@@ -68,58 +116,116 @@ type IndexPatternCreateResult553 struct {
 }
 
 type IndexPatternAttributes struct {
-	Title         string `json:"title"`
-	TimeFieldName string `json:"timeFieldName"`
-	Fields        string `json:"fields"`
+	Title           string `json:"title,omitempty"`
+	TimeFieldName   string `json:"timeFieldName,omitempty"`
+	Fields          string `json:"fields,omitempty"`
+	FieldFormatMap  string `json:"fieldFormatMap,omitempty"`
+	RuntimeFieldMap string `json:"runtimeFieldMap,omitempty"`
 }
 
 type metaFieldsResult struct {
-	Fields []metaFields `json:"fields"`
+	Fields []*IndexPatternField `json:"fields"`
 }
 
-type metaFields struct {
-	Name              string `json:"name"`
-	Type              string `json:"type"`
-	Count             int    `json:"count"`
-	Scripted          bool   `json:"scripted"`
-	Searchable        bool   `json:"searchable"`
-	Aggregatable      bool   `json:"aggregatable"`
-	ReadFromDocValues bool   `json:"readFromDocValues"`
+type IndexPatternField struct {
+	Name              string   `json:"name"`
+	Type              string   `json:"type"`
+	EsTypes           []string `json:"esTypes,omitempty"`
+	Count             int      `json:"count"`
+	Scripted          bool     `json:"scripted"`
+	Searchable        bool     `json:"searchable"`
+	Aggregatable      bool     `json:"aggregatable"`
+	ReadFromDocValues bool     `json:"readFromDocValues"`
+	Script            string   `json:"script,omitempty"`
+	Lang              string   `json:"lang,omitempty"`
 }
 
 type valuePair struct {
 	Value string `json:"value"`
 }
 
-func (api *IndexPatternClient600) SetDefault(indexPatternId string) error {
-	response, body, err := api.client.Post(fmt.Sprintf("%s/api/kibana/settings/defaultIndex", api.config.KibanaBaseUri)).
-		Set("kbn-version", api.config.KibanaVersion).
-		Send(&valuePair{Value: indexPatternId}).
-		End()
+const indexPatternMetaFields = `["_source","_id","_type","_index","_score"]`
 
-	if err != nil {
-		return err[0]
+func NewIndexPatternRequestBuilder() *IndexPatternRequestBuilder {
+	return &IndexPatternRequestBuilder{}
+}
+
+// WithId sets the id of the index pattern, by default kibana 6.0 and later generate an id and earlier versions
+// use the title
+func (builder *IndexPatternRequestBuilder) WithId(id string) *IndexPatternRequestBuilder {
+	builder.id = id
+	return builder
+}
+
+func (builder *IndexPatternRequestBuilder) WithTitle(title string) *IndexPatternRequestBuilder {
+	builder.title = title
+	return builder
+}
+
+func (builder *IndexPatternRequestBuilder) WithTimeFieldName(timeFieldName string) *IndexPatternRequestBuilder {
+	builder.timeFieldName = timeFieldName
+	return builder
+}
+
+func (builder *IndexPatternRequestBuilder) WithFields(fields []*IndexPatternField) *IndexPatternRequestBuilder {
+	builder.fields = fields
+	return builder
+}
+
+func (builder *IndexPatternRequestBuilder) Build() (*CreateIndexPatternRequest, error) {
+	if builder.title == "" {
+		return nil, errors.New("index pattern title is required")
 	}
 
-	if response.StatusCode >= 300 {
-		return errors.New(fmt.Sprintf("Status: %d, %s", response.StatusCode, body))
+	attributes := &IndexPatternAttributes{
+		Title:         builder.title,
+		TimeFieldName: builder.timeFieldName,
 	}
 
-	return nil
+	if len(builder.fields) > 0 {
+		fieldJson, err := json.Marshal(builder.fields)
+		if err != nil {
+			return nil, fmt.Errorf("could not marshal index pattern fields, error: %v", err)
+		}
+
+		attributes.Fields = string(fieldJson)
+	}
+
+	return &CreateIndexPatternRequest{Id: builder.id, Attributes: attributes}, nil
+}
+
+// FieldList decodes the fields of the index pattern, which kibana stores as a json string
+func (attributes *IndexPatternAttributes) FieldList() ([]*IndexPatternField, error) {
+	fields := []*IndexPatternField{}
+	if attributes.Fields == "" {
+		return fields, nil
+	}
+
+	if err := json.Unmarshal([]byte(attributes.Fields), &fields); err != nil {
+		return nil, fmt.Errorf("could not parse index pattern fields, error: %v", err)
+	}
+
+	return fields, nil
 }
 
-func (api *IndexPatternClient600) Create() (*IndexPatternCreateResult, error) {
-	uri := getUrlFromVersion(api.config.KibanaVersion, "create_index", api.config, "logstash-*", "")
+func (api *IndexPatternClient600) Create(request *CreateIndexPatternRequest) (*IndexPatternCreateResult, error) {
+	uri := getUrlFromVersion(api.config.KibanaVersion, "create_index", api.config, request.Attributes.Title, request.Id)
+	if request.Id != "" {
+		uri += "/" + request.Id
+	}
+
 	response, body, errs := api.client.Post(uri).
+		Operation("IndexPattern.Create", "index-pattern", request.Id).
 		Set("kbn-version", api.config.KibanaVersion).
-		Send("{\"attributes\":{\"title\":\"logstash-*\",\"timeFieldName\":\"@timestamp\"}}").End()
+		Send(request).
+		End()
 
 	if errs != nil {
 		return nil, errs[0]
 	}
 
 	if response.StatusCode >= 300 {
-		return nil, errors.New(fmt.Sprintf("Status: %d, %s", response.StatusCode, body))
+		return nil, NewError(response, body, "Could not create index pattern")
 	}
 
 	indexPatternCreateResult := &IndexPatternCreateResult{}
@@ -131,41 +237,63 @@ func (api *IndexPatternClient600) Create() (*IndexPatternCreateResult, error) {
 	return indexPatternCreateResult, nil
 }
 
-func (api *IndexPatternClient600) RefreshFields(indexPatternId string) error {
-	response, body, errs := api.client.Get(api.config.KibanaBaseUri + "/api/index_patterns/_fields_for_wildcard").
-		Query(`{ "pattern" : "logstash-*", "meta_fields" : "[\"_source\",\"_id\",\"_type\",\"_index\",\"_score\"]" }`).End()
+func (api *IndexPatternClient600) GetById(id string) (*IndexPattern, error) {
+	response, body, errs := api.client.
+		Get(api.config.KibanaBaseUri+savedObjectsPath+"index-pattern/"+id).
+		Operation("IndexPattern.GetById", "index-pattern", id).
+		Set("kbn-version", api.config.KibanaVersion).
+		End()
 
 	if errs != nil {
-		return errs[0]
+		return nil, errs[0]
 	}
 
 	if response.StatusCode >= 300 {
-		return errors.New(fmt.Sprintf("Status: %d, %s", response.StatusCode, body))
+		return nil, NewError(response, body, "Could not fetch index pattern")
 	}
 
-	fields := &metaFieldsResult{}
-	err := json.Unmarshal([]byte(body), fields)
-	if err != nil {
-		return fmt.Errorf("could not parse fields for wildcard response, error: %v", err)
+	indexPattern := &IndexPattern{}
+	if err := json.Unmarshal([]byte(body), indexPattern); err != nil {
+		return nil, fmt.Errorf("could not parse fields from get index pattern response, error: %v", err)
 	}
 
-	fieldJson, err := json.Marshal(fields.Fields)
-	if err != nil {
-		return fmt.Errorf("could not marshal fields from wildcard response, error: %v", err)
+	return indexPattern, nil
+}
+
+func (api *IndexPatternClient600) List() ([]*IndexPattern, error) {
+	savedObjects := &savedObjectsClient600{config: api.config, client: api.client}
+	return listIndexPatterns(savedObjects)
+}
+
+func (api *IndexPatternClient600) Update(id string, request *UpdateIndexPatternRequest) (*IndexPattern, error) {
+	uri := getUrlFromVersion(api.config.KibanaVersion, "refresh_index", api.config, request.Attributes.Title, id)
+	response, body, errs := api.client.Put(uri).
+		Operation("IndexPattern.Update", "index-pattern", id).
+		Set("kbn-version", api.config.KibanaVersion).
+		Send(request).
+		End()
+
+	if errs != nil {
+		return nil, errs[0]
 	}
 
-	indexPattern := &IndexPattern{
-		Attributes: &IndexPatternAttributes{
-			Title:         "logstash-*",
-			TimeFieldName: "@timestamp",
-			Fields:        string(fieldJson),
-		},
+	if response.StatusCode >= 300 {
+		return nil, NewError(response, body, "Could not update index pattern")
 	}
 
-	uri := getUrlFromVersion(api.config.KibanaVersion, "refresh_index", api.config, "logstash-*", indexPatternId)
-	response, body, errs = api.client.Put(uri).
+	indexPattern := &IndexPattern{}
+	if err := json.Unmarshal([]byte(body), indexPattern); err != nil {
+		return nil, fmt.Errorf("could not parse fields from update index pattern response, error: %v", err)
+	}
+
+	return indexPattern, nil
+}
+
+func (api *IndexPatternClient600) Delete(id string) error {
+	response, body, errs := api.client.
+		Delete(api.config.KibanaBaseUri+savedObjectsPath+"index-pattern/"+id).
+		Operation("IndexPattern.Delete", "index-pattern", id).
 		Set("kbn-version", api.config.KibanaVersion).
-		Send(indexPattern).
 		End()
 
 	if errs != nil {
@@ -173,34 +301,55 @@ func (api *IndexPatternClient600) RefreshFields(indexPatternId string) error {
 	}
 
 	if response.StatusCode >= 300 {
-		return errors.New(fmt.Sprintf("Status: %d, %s", response.StatusCode, body))
+		return NewError(response, body, "Could not delete index pattern")
 	}
 
 	return nil
 }
 
-func (api *IndexPatternClient553) SetDefault(indexPatternId string) error {
-	response, body, err := api.client.Post(fmt.Sprintf("%s/api/kibana/settings/defaultIndex", api.config.KibanaBaseUri)).
-		Set("kbn-version", api.config.KibanaVersion).
-		Send(&valuePair{Value: indexPatternId}).
-		End()
+func (api *IndexPatternClient600) SetDefault(indexPatternId string) error {
+	return setDefaultIndexPattern(api.config, api.client, indexPatternId)
+}
+
+// GetDefault returns the default index pattern, or nil when no default index pattern is set
+func (api *IndexPatternClient600) GetDefault() (*IndexPattern, error) {
+	id, err := getDefaultIndexPatternId(api.config, api.client)
+	if err != nil || id == "" {
+		return nil, err
+	}
 
+	return api.GetById(id)
+}
+
+// RefreshFields reads the fields of the indices matching the index pattern title and stores them with the
+// index pattern
+func (api *IndexPatternClient600) RefreshFields(indexPatternId string) error {
+	indexPattern, err := api.GetById(indexPatternId)
 	if err != nil {
-		return err[0]
+		return err
 	}
 
-	if response.StatusCode >= 300 {
-		return errors.New(fmt.Sprintf("Status: %d, %s", response.StatusCode, body))
+	attributes, err := refreshIndexPatternFields(api.config, api.client, indexPatternId, indexPattern.Attributes)
+	if err != nil {
+		return err
 	}
 
-	return nil
+	_, err = api.Update(indexPatternId, &UpdateIndexPatternRequest{Attributes: attributes})
+	return err
 }
 
-func (api *IndexPatternClient553) Create() (*IndexPatternCreateResult, error) {
-	uri := getUrlFromVersion(api.config.KibanaVersion, "create_index", api.config, "logstash-*", "")
+func (api *IndexPatternClient553) Create(request *CreateIndexPatternRequest) (*IndexPatternCreateResult, error) {
+	id := request.Id
+	if id == "" {
+		id = request.Attributes.Title
+	}
+
+	uri := getUrlFromVersion(api.config.KibanaVersion, "create_index", api.config, id, "")
 	response, body, errs := api.client.Post(uri).
+		Operation("IndexPattern.Create", "index-pattern", id).
 		Set("kbn-version", api.config.KibanaVersion).
-		Send("{\"title\":\"logstash-*\",\"timeFieldName\":\"@timestamp\"}").End()
+		Send(request.Attributes).
+		End()
 
 	if errs != nil {
 		return nil, errs[0]
@@ -208,12 +357,13 @@ func (api *IndexPatternClient553) Create() (*IndexPatternCreateResult, error) {
 
 	if response.StatusCode == 409 {
 		return &IndexPatternCreateResult{
-			Id:      "logstash-*",
-			Type:    "index-pattern",
-			Version: "1",
+			Id:         id,
+			Type:       "index-pattern",
+			Version:    "1",
+			Attributes: request.Attributes,
 		}, nil
 	} else if response.StatusCode >= 300 {
-		return nil, errors.New(fmt.Sprintf("Status: %d, %s", response.StatusCode, body))
+		return nil, NewError(response, body, "Could not create index pattern")
 	}
 
 	result := &IndexPatternCreateResult553{}
@@ -224,55 +374,260 @@ func (api *IndexPatternClient553) Create() (*IndexPatternCreateResult, error) {
 	}
 
 	return &IndexPatternCreateResult{
-		Id:      result.Id,
-		Type:    result.Type,
-		Version: result.Version,
+		Id:         result.Id,
+		Type:       result.Type,
+		Version:    result.Version,
+		Attributes: request.Attributes,
+	}, nil
+}
+
+func (api *IndexPatternClient553) GetById(id string) (*IndexPattern, error) {
+	response, body, errs := api.client.
+		Get(api.config.BuildFullPath("/%s/%s", "index-pattern", id)).
+		Operation("IndexPattern.GetById", "index-pattern", id).
+		Set("kbn-version", api.config.KibanaVersion).
+		End()
+
+	if errs != nil {
+		return nil, errs[0]
+	}
+
+	if response.StatusCode >= 300 {
+		return nil, NewError(response, body, "Could not fetch index pattern")
+	}
+
+	readResult := &indexPatternReadResult553{}
+	if err := json.Unmarshal([]byte(body), readResult); err != nil {
+		return nil, fmt.Errorf("could not parse fields from get index pattern response, error: %v", err)
+	}
+
+	return &IndexPattern{
+		Id:         readResult.Id,
+		Type:       readResult.Type,
+		Version:    readResult.Version,
+		Attributes: readResult.Source,
 	}, nil
+}
 
+func (api *IndexPatternClient553) List() ([]*IndexPattern, error) {
+	savedObjects := &savedObjectsClient553{config: api.config, client: api.client}
+	return listIndexPatterns(savedObjects)
 }
 
-func (api *IndexPatternClient553) RefreshFields(indexPatternId string) error {
-	response, body, errs := api.client.Get(api.config.KibanaBaseUri + "/api/index_patterns/_fields_for_wildcard").
-		Query(`{ "pattern" : "logstash-*", "meta_fields" : "[\"_source\",\"_id\",\"_type\",\"_index\",\"_score\"]" }`).End()
+// Update changes the attributes set in the request and keeps the other attributes of the index pattern, the same
+// as the partial update of the saved objects api of kibana 6.0 and later. Kibana 5.x replaces the whole document,
+// so the document is read and the attributes are merged into it before it is written.
+func (api *IndexPatternClient553) Update(id string, request *UpdateIndexPatternRequest) (*IndexPattern, error) {
+	response, body, errs := api.client.
+		Get(api.config.BuildFullPath("/%s/%s", "index-pattern", id)).
+		Operation("IndexPattern.Update", "index-pattern", id).
+		Set("kbn-version", api.config.KibanaVersion).
+		End()
 
 	if errs != nil {
-		return errs[0]
+		return nil, errs[0]
 	}
 
 	if response.StatusCode >= 300 {
-		return errors.New(fmt.Sprintf("Status: %d, %s", response.StatusCode, body))
+		return nil, NewError(response, body, "Could not fetch index pattern")
 	}
 
-	fields := &metaFieldsResult{}
-	err := json.Unmarshal([]byte(body), fields)
-	if err != nil {
-		return fmt.Errorf("could not parse fields for wildcard response, error: %v", err)
+	existing := &struct {
+		Source map[string]json.RawMessage `json:"_source"`
+	}{}
+	if err := json.Unmarshal([]byte(body), existing); err != nil {
+		return nil, fmt.Errorf("could not parse fields from get index pattern response, error: %v", err)
 	}
 
-	fieldJson, err := json.Marshal(fields.Fields)
+	data, err := json.Marshal(request.Attributes)
 	if err != nil {
-		return fmt.Errorf("could not marshal fields from wildcard response, error: %v", err)
+		return nil, fmt.Errorf("could not encode index pattern attributes, error: %v", err)
+	}
+
+	attributes := map[string]json.RawMessage{}
+	if err := json.Unmarshal(data, &attributes); err != nil {
+		return nil, fmt.Errorf("could not encode index pattern attributes, error: %v", err)
+	}
+
+	document := existing.Source
+	if document == nil {
+		document = map[string]json.RawMessage{}
+	}
+
+	for name, value := range attributes {
+		document[name] = value
+	}
+
+	response, body, errs = api.client.
+		Put(getUrlFromVersion(api.config.KibanaVersion, "refresh_index", api.config, id, id)).
+		Operation("IndexPattern.Update", "index-pattern", id).
+		Set("kbn-version", api.config.KibanaVersion).
+		Send(document).
+		End()
+
+	if errs != nil {
+		return nil, errs[0]
 	}
 
-	indexPattern := &IndexPatternAttributes{
-		Title:         "logstash-*",
-		TimeFieldName: "@timestamp",
-		Fields:        string(fieldJson),
+	if response.StatusCode >= 300 {
+		return nil, NewError(response, body, "Could not update index pattern")
 	}
 
-	uri := getUrlFromVersion(api.config.KibanaVersion, "refresh_index", api.config, "logstash-*", indexPatternId)
-	response, body, errs = api.client.Put(uri).
+	return api.GetById(id)
+}
+
+func (api *IndexPatternClient553) Delete(id string) error {
+	response, body, errs := api.client.
+		Delete(api.config.BuildFullPath("/%s/%s", "index-pattern", id)).
+		Operation("IndexPattern.Delete", "index-pattern", id).
 		Set("kbn-version", api.config.KibanaVersion).
-		Send(indexPattern).
 		End()
 
 	if errs != nil {
 		return errs[0]
 	}
 
+	if response.StatusCode >= 300 {
+		return NewError(response, body, "Could not delete index pattern")
+	}
+
+	return nil
+}
+
+func (api *IndexPatternClient553) SetDefault(indexPatternId string) error {
+	return setDefaultIndexPattern(api.config, api.client, indexPatternId)
+}
+
+// GetDefault returns the default index pattern, or nil when no default index pattern is set
+func (api *IndexPatternClient553) GetDefault() (*IndexPattern, error) {
+	id, err := getDefaultIndexPatternId(api.config, api.client)
+	if err != nil || id == "" {
+		return nil, err
+	}
+
+	return api.GetById(id)
+}
+
+// RefreshFields reads the fields of the indices matching the index pattern title and stores them with the
+// index pattern
+func (api *IndexPatternClient553) RefreshFields(indexPatternId string) error {
+	indexPattern, err := api.GetById(indexPatternId)
+	if err != nil {
+		return err
+	}
+
+	attributes, err := refreshIndexPatternFields(api.config, api.client, indexPatternId, indexPattern.Attributes)
+	if err != nil {
+		return err
+	}
+
+	_, err = api.Update(indexPatternId, &UpdateIndexPatternRequest{Attributes: attributes})
+	return err
+}
+
+func setDefaultIndexPattern(config *Config, client *HttpAgent, indexPatternId string) error {
+	response, body, err := client.Post(fmt.Sprintf("%s/api/kibana/settings/defaultIndex", config.KibanaBaseUri)).
+		Operation("IndexPattern.SetDefault", "index-pattern", indexPatternId).
+		Set("kbn-version", config.KibanaVersion).
+		Send(&valuePair{Value: indexPatternId}).
+		End()
+
+	if err != nil {
+		return err[0]
+	}
+
 	if response.StatusCode >= 300 {
 		return errors.New(fmt.Sprintf("Status: %d, %s", response.StatusCode, body))
 	}
 
 	return nil
 }
+
+func getDefaultIndexPatternId(config *Config, client *HttpAgent) (string, error) {
+	response, body, errs := client.Get(fmt.Sprintf("%s/api/kibana/settings", config.KibanaBaseUri)).
+		Operation("IndexPattern.GetDefault", "index-pattern", "").
+		Set("kbn-version", config.KibanaVersion).
+		End()
+
+	if errs != nil {
+		return "", errs[0]
+	}
+
+	if response.StatusCode >= 300 {
+		return "", NewError(response, body, "Could not fetch kibana settings")
+	}
+
+	settings := &indexPatternSettings{}
+	if err := json.Unmarshal([]byte(body), settings); err != nil {
+		return "", fmt.Errorf("could not parse kibana settings response, error: %v", err)
+	}
+
+	defaultIndex, ok := settings.Settings["defaultIndex"]
+	if !ok || defaultIndex.UserValue == nil {
+		return "", nil
+	}
+
+	return fmt.Sprint(defaultIndex.UserValue), nil
+}
+
+func refreshIndexPatternFields(config *Config, client *HttpAgent, indexPatternId string, attributes *IndexPatternAttributes) (*IndexPatternAttributes, error) {
+	response, body, errs := client.Get(config.KibanaBaseUri+"/api/index_patterns/_fields_for_wildcard").
+		Operation("IndexPattern.RefreshFields", "index-pattern", indexPatternId).
+		Query(fieldsForWildcardQuery{Pattern: attributes.Title, MetaFields: indexPatternMetaFields}).
+		End()
+
+	if errs != nil {
+		return nil, errs[0]
+	}
+
+	if response.StatusCode >= 300 {
+		return nil, errors.New(fmt.Sprintf("Status: %d, %s", response.StatusCode, body))
+	}
+
+	fields := &metaFieldsResult{}
+	err := json.Unmarshal([]byte(body), fields)
+	if err != nil {
+		return nil, fmt.Errorf("could not parse fields for wildcard response, error: %v", err)
+	}
+
+	fieldJson, err := json.Marshal(fields.Fields)
+	if err != nil {
+		return nil, fmt.Errorf("could not marshal fields from wildcard response, error: %v", err)
+	}
+
+	refreshed := *attributes
+	refreshed.Fields = string(fieldJson)
+	return &refreshed, nil
+}
+
+func listIndexPatterns(savedObjects SavedObjectsClient) ([]*IndexPattern, error) {
+	response, err := savedObjects.GetByType(NewSavedObjectRequestBuilder().
+		WithType("index-pattern").
+		WithPerPage(savedObjectGraphPerPage).
+		Build())
+	if err != nil {
+		return nil, err
+	}
+
+	indexPatterns := []*IndexPattern{}
+	for _, object := range response.SavedObjects {
+		data, err := json.Marshal(object.Attributes)
+		if err != nil {
+			return nil, fmt.Errorf("could not marshal index pattern attributes, error: %v", err)
+		}
+
+		attributes := &IndexPatternAttributes{}
+		if err := json.Unmarshal(data, attributes); err != nil {
+			return nil, fmt.Errorf("could not parse index pattern attributes, error: %v", err)
+		}
+
+		indexPatterns = append(indexPatterns, &IndexPattern{
+			Id:         object.Id,
+			Type:       "index-pattern",
+			Version:    object.Version,
+			Attributes: attributes,
+		})
+	}
+
+	return indexPatterns, nil
+}
@@ -0,0 +1,374 @@
+package kibana
+
+import (
+	"encoding/json"
+	"fmt"
+
+	goversion "github.com/mcuadros/go-version"
+)
+
+const (
+	ScriptLanguagePainless = "painless"
+
+	FieldFormatUrl      = "url"
+	FieldFormatBytes    = "bytes"
+	FieldFormatNumber   = "number"
+	FieldFormatPercent  = "percent"
+	FieldFormatDuration = "duration"
+	FieldFormatDate     = "date"
+	FieldFormatString   = "string"
+)
+
+// RuntimeField is a field computed by elasticsearch at query time, supported from kibana 7.11
+type RuntimeField struct {
+	Type   string              `json:"type"`
+	Script *RuntimeFieldScript `json:"script,omitempty"`
+}
+
+type RuntimeFieldScript struct {
+	Source string `json:"source"`
+}
+
+// FieldFormat sets how kibana displays the values of a field, Id is the name of the formatter such as url,
+// bytes or duration
+type FieldFormat struct {
+	Id     string                 `json:"id"`
+	Params map[string]interface{} `json:"params,omitempty"`
+}
+
+// NewScriptedField creates a painless scripted field of the given kibana field type, such as number or string
+func NewScriptedField(name string, fieldType string, script string) *IndexPatternField {
+	return &IndexPatternField{
+		Name:         name,
+		Type:         fieldType,
+		Scripted:     true,
+		Script:       script,
+		Lang:         ScriptLanguagePainless,
+		Searchable:   true,
+		Aggregatable: true,
+	}
+}
+
+// NewRuntimeField creates a runtime field of the given elasticsearch type, such as keyword or long, the painless
+// script emits the values of the field
+func NewRuntimeField(fieldType string, script string) *RuntimeField {
+	field := &RuntimeField{Type: fieldType}
+	if script != "" {
+		field.Script = &RuntimeFieldScript{Source: script}
+	}
+
+	return field
+}
+
+// NewUrlFieldFormat creates a formatter showing the value as a link, the templates use {{value}} for the value
+func NewUrlFieldFormat(urlTemplate string, labelTemplate string) *FieldFormat {
+	return &FieldFormat{Id: FieldFormatUrl, Params: map[string]interface{}{
+		"urlTemplate":   urlTemplate,
+		"labelTemplate": labelTemplate,
+	}}
+}
+
+// NewBytesFieldFormat creates a formatter showing the value as a number of bytes using a numeral.js pattern
+// such as 0,0.[0]b
+func NewBytesFieldFormat(pattern string) *FieldFormat {
+	return newPatternFieldFormat(FieldFormatBytes, pattern)
+}
+
+// NewNumberFieldFormat creates a formatter showing the value using a numeral.js pattern such as 0,0.[000]
+func NewNumberFieldFormat(pattern string) *FieldFormat {
+	return newPatternFieldFormat(FieldFormatNumber, pattern)
+}
+
+// NewPercentFieldFormat creates a formatter showing the value as a percentage using a numeral.js pattern
+func NewPercentFieldFormat(pattern string) *FieldFormat {
+	return newPatternFieldFormat(FieldFormatPercent, pattern)
+}
+
+// NewDurationFieldFormat creates a formatter converting a duration between units, such as milliseconds to
+// humanize
+func NewDurationFieldFormat(inputFormat string, outputFormat string, outputPrecision int) *FieldFormat {
+	return &FieldFormat{Id: FieldFormatDuration, Params: map[string]interface{}{
+		"inputFormat":     inputFormat,
+		"outputFormat":    outputFormat,
+		"outputPrecision": outputPrecision,
+	}}
+}
+
+func newPatternFieldFormat(id string, pattern string) *FieldFormat {
+	format := &FieldFormat{Id: id}
+	if pattern != "" {
+		format.Params = map[string]interface{}{"pattern": pattern}
+	}
+
+	return format
+}
+
+// SetFieldList encodes the fields of the index pattern
+func (attributes *IndexPatternAttributes) SetFieldList(fields []*IndexPatternField) error {
+	data, err := json.Marshal(fields)
+	if err != nil {
+		return fmt.Errorf("could not encode index pattern fields, error: %v", err)
+	}
+
+	attributes.Fields = string(data)
+	return nil
+}
+
+// ScriptedFields returns the scripted fields of the index pattern
+func (attributes *IndexPatternAttributes) ScriptedFields() ([]*IndexPatternField, error) {
+	fields, err := attributes.FieldList()
+	if err != nil {
+		return nil, err
+	}
+
+	scripted := []*IndexPatternField{}
+	for _, field := range fields {
+		if field.Scripted {
+			scripted = append(scripted, field)
+		}
+	}
+
+	return scripted, nil
+}
+
+// FieldFormats decodes the field formatters of the index pattern by field name
+func (attributes *IndexPatternAttributes) FieldFormats() (map[string]*FieldFormat, error) {
+	formats := map[string]*FieldFormat{}
+	if attributes.FieldFormatMap == "" {
+		return formats, nil
+	}
+
+	if err := json.Unmarshal([]byte(attributes.FieldFormatMap), &formats); err != nil {
+		return nil, fmt.Errorf("could not parse index pattern field formats, error: %v", err)
+	}
+
+	return formats, nil
+}
+
+func (attributes *IndexPatternAttributes) SetFieldFormats(formats map[string]*FieldFormat) error {
+	data, err := json.Marshal(formats)
+	if err != nil {
+		return fmt.Errorf("could not encode index pattern field formats, error: %v", err)
+	}
+
+	attributes.FieldFormatMap = string(data)
+	return nil
+}
+
+// RuntimeFields decodes the runtime fields of the index pattern by field name
+func (attributes *IndexPatternAttributes) RuntimeFields() (map[string]*RuntimeField, error) {
+	fields := map[string]*RuntimeField{}
+	if attributes.RuntimeFieldMap == "" {
+		return fields, nil
+	}
+
+	if err := json.Unmarshal([]byte(attributes.RuntimeFieldMap), &fields); err != nil {
+		return nil, fmt.Errorf("could not parse index pattern runtime fields, error: %v", err)
+	}
+
+	return fields, nil
+}
+
+func (attributes *IndexPatternAttributes) SetRuntimeFields(fields map[string]*RuntimeField) error {
+	data, err := json.Marshal(fields)
+	if err != nil {
+		return fmt.Errorf("could not encode index pattern runtime fields, error: %v", err)
+	}
+
+	attributes.RuntimeFieldMap = string(data)
+	return nil
+}
+
+func (api *IndexPatternClient600) SetScriptedField(indexPatternId string, field *IndexPatternField) error {
+	return setScriptedField(api, indexPatternId, field)
+}
+
+func (api *IndexPatternClient600) DeleteScriptedField(indexPatternId string, name string) error {
+	return deleteScriptedField(api, indexPatternId, name)
+}
+
+func (api *IndexPatternClient600) SetRuntimeField(indexPatternId string, name string, field *RuntimeField) error {
+	if goversion.Compare(api.config.KibanaVersion, "7.11.0", "<") {
+		return fmt.Errorf("runtime fields are not supported by kibana %s, they require kibana 7.11 or later", api.config.KibanaVersion)
+	}
+
+	return updateIndexPatternAttributes(api, indexPatternId, func(attributes *IndexPatternAttributes) error {
+		fields, err := attributes.RuntimeFields()
+		if err != nil {
+			return err
+		}
+
+		fields[name] = field
+		return attributes.SetRuntimeFields(fields)
+	})
+}
+
+func (api *IndexPatternClient600) DeleteRuntimeField(indexPatternId string, name string) error {
+	return updateIndexPatternAttributes(api, indexPatternId, func(attributes *IndexPatternAttributes) error {
+		fields, err := attributes.RuntimeFields()
+		if err != nil {
+			return err
+		}
+
+		if _, ok := fields[name]; !ok {
+			return fmt.Errorf("index pattern %s has no runtime field %s", indexPatternId, name)
+		}
+
+		delete(fields, name)
+		return attributes.SetRuntimeFields(fields)
+	})
+}
+
+func (api *IndexPatternClient600) SetFieldFormat(indexPatternId string, fieldName string, format *FieldFormat) error {
+	return setFieldFormat(api, indexPatternId, fieldName, format)
+}
+
+func (api *IndexPatternClient553) SetScriptedField(indexPatternId string, field *IndexPatternField) error {
+	return setScriptedField(api, indexPatternId, field)
+}
+
+func (api *IndexPatternClient553) DeleteScriptedField(indexPatternId string, name string) error {
+	return deleteScriptedField(api, indexPatternId, name)
+}
+
+func (api *IndexPatternClient553) SetRuntimeField(indexPatternId string, name string, field *RuntimeField) error {
+	return fmt.Errorf("runtime fields are not supported by kibana %s, they require kibana 7.11 or later", api.config.KibanaVersion)
+}
+
+func (api *IndexPatternClient553) DeleteRuntimeField(indexPatternId string, name string) error {
+	return fmt.Errorf("runtime fields are not supported by kibana %s, they require kibana 7.11 or later", api.config.KibanaVersion)
+}
+
+func (api *IndexPatternClient553) SetFieldFormat(indexPatternId string, fieldName string, format *FieldFormat) error {
+	return setFieldFormat(api, indexPatternId, fieldName, format)
+}
+
+// setScriptedField adds the scripted field to the index pattern, replacing a scripted field of the same name
+func setScriptedField(client IndexPatternClient, indexPatternId string, field *IndexPatternField) error {
+	if !field.Scripted || field.Script == "" {
+		return fmt.Errorf("field %s is not a scripted field", field.Name)
+	}
+
+	encoded, err := json.Marshal(field)
+	if err != nil {
+		return fmt.Errorf("could not encode index pattern field %s, error: %v", field.Name, err)
+	}
+
+	return updateIndexPatternAttributes(client, indexPatternId, func(attributes *IndexPatternAttributes) error {
+		fields, err := attributes.rawFieldList()
+		if err != nil {
+			return err
+		}
+
+		replaced := false
+		for index, existing := range fields {
+			name, scripted := rawFieldName(existing)
+			if name != field.Name {
+				continue
+			}
+
+			if !scripted {
+				return fmt.Errorf("index pattern %s already has a field %s which is not scripted", indexPatternId, field.Name)
+			}
+
+			fields[index] = encoded
+			replaced = true
+		}
+
+		if !replaced {
+			fields = append(fields, encoded)
+		}
+
+		return attributes.setRawFieldList(fields)
+	})
+}
+
+func deleteScriptedField(client IndexPatternClient, indexPatternId string, name string) error {
+	return updateIndexPatternAttributes(client, indexPatternId, func(attributes *IndexPatternAttributes) error {
+		fields, err := attributes.rawFieldList()
+		if err != nil {
+			return err
+		}
+
+		remaining := []json.RawMessage{}
+		for _, field := range fields {
+			if fieldName, scripted := rawFieldName(field); !scripted || fieldName != name {
+				remaining = append(remaining, field)
+			}
+		}
+
+		if len(remaining) == len(fields) {
+			return fmt.Errorf("index pattern %s has no scripted field %s", indexPatternId, name)
+		}
+
+		return attributes.setRawFieldList(remaining)
+	})
+}
+
+// rawFieldList decodes the list of fields of the index pattern without decoding the fields, so that the fields
+// which are not changed keep what IndexPatternField does not decode, such as the subType of nested fields
+func (attributes *IndexPatternAttributes) rawFieldList() ([]json.RawMessage, error) {
+	fields := []json.RawMessage{}
+	if attributes.Fields == "" {
+		return fields, nil
+	}
+
+	if err := json.Unmarshal([]byte(attributes.Fields), &fields); err != nil {
+		return nil, fmt.Errorf("could not parse index pattern fields, error: %v", err)
+	}
+
+	return fields, nil
+}
+
+func (attributes *IndexPatternAttributes) setRawFieldList(fields []json.RawMessage) error {
+	data, err := json.Marshal(fields)
+	if err != nil {
+		return fmt.Errorf("could not encode index pattern fields, error: %v", err)
+	}
+
+	attributes.Fields = string(data)
+	return nil
+}
+
+// rawFieldName returns the name of an encoded field and whether it is a scripted field
+func rawFieldName(field json.RawMessage) (string, bool) {
+	var decoded struct {
+		Name     string `json:"name"`
+		Scripted bool   `json:"scripted"`
+	}
+
+	json.Unmarshal(field, &decoded)
+	return decoded.Name, decoded.Scripted
+}
+
+// setFieldFormat sets the formatter of the field, a nil format removes the formatter
+func setFieldFormat(client IndexPatternClient, indexPatternId string, fieldName string, format *FieldFormat) error {
+	return updateIndexPatternAttributes(client, indexPatternId, func(attributes *IndexPatternAttributes) error {
+		formats, err := attributes.FieldFormats()
+		if err != nil {
+			return err
+		}
+
+		if format == nil {
+			delete(formats, fieldName)
+		} else {
+			formats[fieldName] = format
+		}
+
+		return attributes.SetFieldFormats(formats)
+	})
+}
+
+func updateIndexPatternAttributes(client IndexPatternClient, indexPatternId string, update func(attributes *IndexPatternAttributes) error) error {
+	indexPattern, err := client.GetById(indexPatternId)
+	if err != nil {
+		return err
+	}
+
+	if err := update(indexPattern.Attributes); err != nil {
+		return err
+	}
+
+	_, err = client.Update(indexPatternId, &UpdateIndexPatternRequest{Attributes: indexPattern.Attributes})
+	return err
+}
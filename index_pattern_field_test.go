@@ -0,0 +1,137 @@
+package kibana
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_IndexPatternScriptedFields(t *testing.T) {
+	client := DefaultTestKibanaClient()
+	indexPatternApi := client.IndexPattern()
+	indexPattern := createTestIndexPattern(t, indexPatternApi)
+	defer indexPatternApi.Delete(indexPattern.Id)
+
+	require.NoError(t, indexPatternApi.SetScriptedField(indexPattern.Id, NewScriptedField("double_bytes", "number", "doc['bytes'].value * 2")))
+	require.NoError(t, indexPatternApi.SetScriptedField(indexPattern.Id, NewScriptedField("double_bytes", "number", "doc['bytes'].value * 3")))
+
+	read, err := indexPatternApi.GetById(indexPattern.Id)
+	require.NoError(t, err)
+	scripted, err := read.Attributes.ScriptedFields()
+	require.NoError(t, err)
+	require.Len(t, scripted, 1)
+	assert.Equal(t, "doc['bytes'].value * 3", scripted[0].Script)
+	assert.Equal(t, ScriptLanguagePainless, scripted[0].Lang)
+
+	err = indexPatternApi.SetScriptedField(indexPattern.Id, &IndexPatternField{Name: "bytes", Type: "number"})
+	assert.EqualError(t, err, "field bytes is not a scripted field")
+
+	require.NoError(t, indexPatternApi.DeleteScriptedField(indexPattern.Id, "double_bytes"))
+	read, err = indexPatternApi.GetById(indexPattern.Id)
+	require.NoError(t, err)
+	scripted, err = read.Attributes.ScriptedFields()
+	require.NoError(t, err)
+	assert.Empty(t, scripted)
+
+	err = indexPatternApi.DeleteScriptedField(indexPattern.Id, "double_bytes")
+	assert.EqualError(t, err, "index pattern "+indexPattern.Id+" has no scripted field double_bytes")
+}
+
+func Test_IndexPatternScriptedFields_keep_other_fields(t *testing.T) {
+	client := DefaultTestKibanaClient()
+	indexPatternApi := client.IndexPattern()
+	indexPattern := createTestIndexPattern(t, indexPatternApi)
+	defer indexPatternApi.Delete(indexPattern.Id)
+
+	nestedField := `{"name":"user.name","type":"string","esTypes":["keyword"],"count":0,"scripted":false,"searchable":true,"aggregatable":true,"readFromDocValues":true,"subType":{"nested":{"path":"user"}},"customLabel":"User"}`
+	read, err := indexPatternApi.GetById(indexPattern.Id)
+	require.NoError(t, err)
+	read.Attributes.Fields = "[" + nestedField + "]"
+	_, err = indexPatternApi.Update(indexPattern.Id, &UpdateIndexPatternRequest{Attributes: read.Attributes})
+	require.NoError(t, err)
+
+	require.NoError(t, indexPatternApi.SetScriptedField(indexPattern.Id, NewScriptedField("double_bytes", "number", "doc['bytes'].value * 2")))
+	read, err = indexPatternApi.GetById(indexPattern.Id)
+	require.NoError(t, err)
+	fields, err := read.Attributes.rawFieldList()
+	require.NoError(t, err)
+	require.Len(t, fields, 2)
+	assert.JSONEq(t, nestedField, string(fields[0]), "the fields which are not changed keep their subType")
+
+	require.NoError(t, indexPatternApi.DeleteScriptedField(indexPattern.Id, "double_bytes"))
+	read, err = indexPatternApi.GetById(indexPattern.Id)
+	require.NoError(t, err)
+	assert.JSONEq(t, "["+nestedField+"]", read.Attributes.Fields)
+}
+
+func Test_IndexPatternFieldFormats(t *testing.T) {
+	client := DefaultTestKibanaClient()
+	indexPatternApi := client.IndexPattern()
+	indexPattern := createTestIndexPattern(t, indexPatternApi)
+	defer indexPatternApi.Delete(indexPattern.Id)
+
+	require.NoError(t, indexPatternApi.SetFieldFormat(indexPattern.Id, "bytes", NewBytesFieldFormat("0,0.[0]b")))
+	require.NoError(t, indexPatternApi.SetFieldFormat(indexPattern.Id, "request", NewUrlFieldFormat("https://example.com/{{value}}", "")))
+
+	read, err := indexPatternApi.GetById(indexPattern.Id)
+	require.NoError(t, err)
+	formats, err := read.Attributes.FieldFormats()
+	require.NoError(t, err)
+	require.Len(t, formats, 2)
+	assert.Equal(t, FieldFormatBytes, formats["bytes"].Id)
+	assert.Equal(t, "0,0.[0]b", formats["bytes"].Params["pattern"])
+	assert.Equal(t, FieldFormatUrl, formats["request"].Id)
+
+	require.NoError(t, indexPatternApi.SetFieldFormat(indexPattern.Id, "request", nil))
+	read, err = indexPatternApi.GetById(indexPattern.Id)
+	require.NoError(t, err)
+	formats, err = read.Attributes.FieldFormats()
+	require.NoError(t, err)
+	assert.Len(t, formats, 1)
+	assert.NotContains(t, formats, "request")
+}
+
+func Test_IndexPatternRuntimeFields(t *testing.T) {
+	fake := NewFakeKibana("7.12.0")
+	defer fake.Close()
+
+	indexPatternApi := NewClient(fake.Config()).IndexPattern()
+	indexPattern := createTestIndexPattern(t, indexPatternApi)
+
+	require.NoError(t, indexPatternApi.SetRuntimeField(indexPattern.Id, "day_of_week", NewRuntimeField("keyword", "emit(doc['@timestamp'].value.dayOfWeekEnum.toString())")))
+
+	read, err := indexPatternApi.GetById(indexPattern.Id)
+	require.NoError(t, err)
+	fields, err := read.Attributes.RuntimeFields()
+	require.NoError(t, err)
+	require.Contains(t, fields, "day_of_week")
+	assert.Equal(t, "keyword", fields["day_of_week"].Type)
+	assert.Equal(t, "emit(doc['@timestamp'].value.dayOfWeekEnum.toString())", fields["day_of_week"].Script.Source)
+
+	require.NoError(t, indexPatternApi.DeleteRuntimeField(indexPattern.Id, "day_of_week"))
+	err = indexPatternApi.DeleteRuntimeField(indexPattern.Id, "day_of_week")
+	assert.EqualError(t, err, "index pattern "+indexPattern.Id+" has no runtime field day_of_week")
+
+	legacyFake := NewFakeKibana("7.3.1")
+	defer legacyFake.Close()
+
+	err = NewClient(legacyFake.Config()).IndexPattern().SetRuntimeField(indexPattern.Id, "day_of_week", NewRuntimeField("keyword", ""))
+	assert.EqualError(t, err, "runtime fields are not supported by kibana 7.3.1, they require kibana 7.11 or later")
+}
+
+func createTestIndexPattern(t *testing.T, indexPatternApi IndexPatternClient) *IndexPatternCreateResult {
+	request, err := NewIndexPatternRequestBuilder().
+		WithTitle("fields-*").
+		WithTimeFieldName("@timestamp").
+		WithFields([]*IndexPatternField{
+			{Name: "bytes", Type: "number", Searchable: true, Aggregatable: true},
+		}).
+		Build()
+	require.NoError(t, err)
+
+	indexPattern, err := indexPatternApi.Create(request)
+	require.NoError(t, err)
+
+	return indexPattern
+}
@@ -0,0 +1,126 @@
+package kibana
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_IndexPatternLifecycle(t *testing.T) {
+	client := DefaultTestKibanaClient()
+	indexPatternApi := client.IndexPattern()
+
+	request, err := NewIndexPatternRequestBuilder().
+		WithId("metrics-test").
+		WithTitle("metrics-*").
+		WithTimeFieldName("timestamp").
+		Build()
+	require.NoError(t, err)
+
+	created, err := indexPatternApi.Create(request)
+	require.NoError(t, err)
+	defer indexPatternApi.Delete(created.Id)
+	assert.Equal(t, "metrics-test", created.Id)
+
+	indexPattern, err := indexPatternApi.GetById(created.Id)
+	require.NoError(t, err)
+	assert.Equal(t, "metrics-*", indexPattern.Attributes.Title)
+	assert.Equal(t, "timestamp", indexPattern.Attributes.TimeFieldName)
+
+	indexPatterns, err := indexPatternApi.List()
+	require.NoError(t, err)
+	titles := []string{}
+	for _, item := range indexPatterns {
+		titles = append(titles, item.Attributes.Title)
+	}
+	assert.Contains(t, titles, "metrics-*")
+	assert.Contains(t, titles, DefaultKibanaIndexId)
+
+	indexPattern.Attributes.TimeFieldName = "@timestamp"
+	_, err = indexPatternApi.Update(created.Id, &UpdateIndexPatternRequest{Attributes: indexPattern.Attributes})
+	require.NoError(t, err)
+
+	require.NoError(t, indexPatternApi.RefreshFields(created.Id))
+	indexPattern, err = indexPatternApi.GetById(created.Id)
+	require.NoError(t, err)
+	assert.Equal(t, "metrics-*", indexPattern.Attributes.Title, "refreshing fields keeps the title")
+	assert.Equal(t, "@timestamp", indexPattern.Attributes.TimeFieldName)
+	fields, err := indexPattern.Attributes.FieldList()
+	require.NoError(t, err)
+	assert.NotEmpty(t, fields)
+
+	require.NoError(t, indexPatternApi.Delete(created.Id))
+	_, err = indexPatternApi.GetById(created.Id)
+	assertNotFound(t, err)
+}
+
+func Test_IndexPatternUpdate_keeps_attributes_not_set(t *testing.T) {
+	client := DefaultTestKibanaClient()
+	indexPatternApi := client.IndexPattern()
+
+	request, err := NewIndexPatternRequestBuilder().
+		WithId("update-test").
+		WithTitle("update-*").
+		WithTimeFieldName("timestamp").
+		WithFields([]*IndexPatternField{{Name: "bytes", Type: "number", Searchable: true, Aggregatable: true}}).
+		Build()
+	require.NoError(t, err)
+
+	created, err := indexPatternApi.Create(request)
+	require.NoError(t, err)
+	defer indexPatternApi.Delete(created.Id)
+
+	_, err = indexPatternApi.Update(created.Id, &UpdateIndexPatternRequest{Attributes: &IndexPatternAttributes{
+		Title:          "update-*",
+		FieldFormatMap: `{"bytes":{"id":"bytes"}}`,
+	}})
+	require.NoError(t, err)
+
+	indexPattern, err := indexPatternApi.GetById(created.Id)
+	require.NoError(t, err)
+	assert.Equal(t, `{"bytes":{"id":"bytes"}}`, indexPattern.Attributes.FieldFormatMap)
+	assert.Equal(t, "timestamp", indexPattern.Attributes.TimeFieldName, "kibana %s keeps the attributes the update does not set", client.Config.KibanaVersion)
+	assert.Equal(t, request.Attributes.Fields, indexPattern.Attributes.Fields)
+}
+
+func Test_IndexPatternUpdate_keeps_title_not_set(t *testing.T) {
+	client := DefaultTestKibanaClient()
+	indexPatternApi := client.IndexPattern()
+
+	request, err := NewIndexPatternRequestBuilder().
+		WithId("update-title-test").
+		WithTitle("update-title-*").
+		WithTimeFieldName("timestamp").
+		Build()
+	require.NoError(t, err)
+
+	created, err := indexPatternApi.Create(request)
+	require.NoError(t, err)
+	defer indexPatternApi.Delete(created.Id)
+
+	_, err = indexPatternApi.Update(created.Id, &UpdateIndexPatternRequest{Attributes: &IndexPatternAttributes{
+		TimeFieldName: "@timestamp",
+	}})
+	require.NoError(t, err)
+
+	indexPattern, err := indexPatternApi.GetById(created.Id)
+	require.NoError(t, err)
+	assert.Equal(t, "update-title-*", indexPattern.Attributes.Title, "kibana %s keeps the title the update does not set", client.Config.KibanaVersion)
+	assert.Equal(t, "@timestamp", indexPattern.Attributes.TimeFieldName)
+}
+
+func Test_IndexPatternGetDefault(t *testing.T) {
+	client := DefaultTestKibanaClient()
+
+	indexPattern, err := client.IndexPattern().GetDefault()
+	require.NoError(t, err)
+	require.NotNil(t, indexPattern)
+	assert.Equal(t, client.Config.DefaultIndexId, indexPattern.Id)
+	assert.Equal(t, DefaultKibanaIndexId, indexPattern.Attributes.Title)
+}
+
+func Test_IndexPatternRequestBuilder_requires_title(t *testing.T) {
+	_, err := NewIndexPatternRequestBuilder().WithTimeFieldName("@timestamp").Build()
+	assert.EqualError(t, err, "index pattern title is required")
+}
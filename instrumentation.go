@@ -0,0 +1,86 @@
+package kibana
+
+import (
+	"context"
+	"net/http"
+	"time"
+)
+
+// Instrumentation observes the operations performed by the clients, it is enabled by setting
+// Config.Instrumentation. The kibanaotel module provides an OpenTelemetry implementation.
+type Instrumentation interface {
+	// StartOperation is called before the request of an operation is sent and returns the function
+	// which is called once the request has completed
+	StartOperation(ctx context.Context, operation *Operation) func(result *OperationResult)
+}
+
+// Operation describes a client call, i.e. Dashboard.Create, along with the saved object it acts on when known.
+// Headers added to Header by the instrumentation are sent with the request, i.e. to propagate a trace.
+type Operation struct {
+	Name       string
+	ObjectType string
+	ObjectId   string
+	Method     string
+	Url        string
+	Header     http.Header
+}
+
+// OperationResult is the outcome of an operation, Err is only set when no response was received
+type OperationResult struct {
+	StatusCode int
+	Duration   time.Duration
+	Err        error
+}
+
+type operationName struct {
+	name       string
+	objectType string
+	objectId   string
+}
+
+// Operation names the client call the next request belongs to, i.e. Dashboard.GetById
+func (authClient *HttpAgent) Operation(name string, objectType string, objectId string) *HttpAgent {
+	authClient.operation = &operationName{name: name, objectType: objectType, objectId: objectId}
+	return authClient
+}
+
+func (authClient *HttpAgent) startOperation() func(response *http.Response, errs []error) {
+	instrumentation := authClient.config.Instrumentation
+	if instrumentation == nil {
+		return func(response *http.Response, errs []error) {}
+	}
+
+	operation := &Operation{
+		Name:   "HTTP " + authClient.client.Method,
+		Method: authClient.client.Method,
+		Url:    authClient.client.Url,
+		Header: http.Header{},
+	}
+
+	if authClient.operation != nil {
+		operation.Name = authClient.operation.name
+		operation.ObjectType = authClient.operation.objectType
+		operation.ObjectId = authClient.operation.objectId
+	}
+
+	end := instrumentation.StartOperation(authClient.context(), operation)
+	for name, values := range operation.Header {
+		for _, value := range values {
+			authClient.client.Set(name, value)
+		}
+	}
+
+	start := time.Now()
+	return func(response *http.Response, errs []error) {
+		result := &OperationResult{Duration: time.Since(start)}
+		if response != nil {
+			result.StatusCode = response.StatusCode
+		}
+
+		if len(errs) > 0 {
+			result.Err = errs[0]
+		}
+
+		end(result)
+	}
+}
@@ -0,0 +1,92 @@
+package kibana
+
+import (
+	"context"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type contextKey string
+
+type recordedOperation struct {
+	operation *Operation
+	result    *OperationResult
+	ctx       context.Context
+}
+
+type recordingInstrumentation struct {
+	mutex      sync.Mutex
+	operations []*recordedOperation
+}
+
+func (instrumentation *recordingInstrumentation) StartOperation(ctx context.Context, operation *Operation) func(result *OperationResult) {
+	operation.Header.Set("Traceparent", "00-0af7651916cd43dd8448eb211c80319c-b7ad6b7169203331-01")
+	recorded := &recordedOperation{operation: operation, ctx: ctx}
+
+	instrumentation.mutex.Lock()
+	instrumentation.operations = append(instrumentation.operations, recorded)
+	instrumentation.mutex.Unlock()
+
+	return func(result *OperationResult) {
+		recorded.result = result
+	}
+}
+
+func Test_Instrumentation_names_operations(t *testing.T) {
+	fake := NewFakeKibana(DefaultKibanaVersion7)
+	defer fake.Close()
+
+	instrumentation := &recordingInstrumentation{}
+	config := fake.Config()
+	config.Instrumentation = instrumentation
+	hook := &testRequestHook{}
+	client := NewClient(config).AddHook(hook)
+
+	ctx := context.WithValue(context.Background(), contextKey("trace"), "parent")
+	dashboard, err := client.WithContext(ctx).Dashboard().Create(&CreateDashboardRequest{
+		Attributes: &DashboardAttributes{Title: "instrumented"},
+	})
+	require.NoError(t, err)
+
+	_, err = client.Dashboard().GetById("missing")
+	require.Error(t, err)
+
+	require.Len(t, instrumentation.operations, 2)
+	create := instrumentation.operations[0]
+	assert.Equal(t, "Dashboard.Create", create.operation.Name)
+	assert.Equal(t, "dashboard", create.operation.ObjectType)
+	assert.Equal(t, "POST", create.operation.Method)
+	assert.Equal(t, "parent", create.ctx.Value(contextKey("trace")))
+	assert.Equal(t, 200, create.result.StatusCode)
+	assert.NoError(t, create.result.Err)
+	assert.NotEmpty(t, dashboard.Id)
+
+	getById := instrumentation.operations[1]
+	assert.Equal(t, "Dashboard.GetById", getById.operation.Name)
+	assert.Equal(t, "missing", getById.operation.ObjectId)
+	assert.Equal(t, 404, getById.result.StatusCode)
+	assert.Nil(t, getById.ctx.Value(contextKey("trace")))
+
+	require.NotEmpty(t, hook.requests)
+	assert.Equal(t, "00-0af7651916cd43dd8448eb211c80319c-b7ad6b7169203331-01", hook.requests[0].Header.Get("Traceparent"))
+}
+
+func Test_Instrumentation_reports_transport_errors(t *testing.T) {
+	fake := NewFakeKibana(DefaultKibanaVersion7)
+	config := fake.Config()
+	fake.Close()
+
+	instrumentation := &recordingInstrumentation{}
+	config.Instrumentation = instrumentation
+
+	_, err := NewClient(config).Space().GetByID("default")
+	require.Error(t, err)
+
+	require.Len(t, instrumentation.operations, 1)
+	assert.Equal(t, "Space.GetByID", instrumentation.operations[0].operation.Name)
+	assert.Error(t, instrumentation.operations[0].result.Err)
+	assert.Equal(t, 0, instrumentation.operations[0].result.StatusCode)
+}
@@ -1,6 +1,7 @@
 package kibana
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"log"
@@ -11,6 +12,7 @@ import (
 	"strings"
 
 	"github.com/google/go-querystring/query"
+	goversion "github.com/mcuadros/go-version"
 )
 
 const EnvElasticSearchPath = "ELASTIC_SEARCH_PATH"
@@ -28,6 +30,7 @@ const DefaultKibanaUri = "http://localhost:5601"
 const DefaultElasticSearchPath = "/es_admin/.kibana"
 const DefaultKibanaVersion6 = "6.0.0"
 const DefaultKibanaVersion7 = "7.3.1"
+const DefaultKibanaVersion8 = "8.0.0"
 const DefaultLogzioVersion = "6.3.2"
 const DefaultLogzioClientId = "kydHH8LqsLR6D6d2dlHTpPEdf0Bztz4c"
 const DefaultKibanaVersion553 = "5.5.3"
@@ -86,6 +89,7 @@ type Config struct {
 	KibanaVersion     string
 	KibanaType        KibanaType
 	Insecure          bool
+	Instrumentation   Instrumentation
 }
 
 type KibanaClient struct {
@@ -219,6 +223,75 @@ func getSpaceClientFromVersion(version string, kibanaClient *KibanaClient) Space
 	return spaceClient(kibanaClient)
 }
 
+var fieldClientFromVersion = map[string]func(kibanaClient *KibanaClient) FieldClient{
+	DefaultKibanaVersion6: func(kibanaClient *KibanaClient) FieldClient {
+		return &fieldClient600{config: kibanaClient.Config, client: kibanaClient.client}
+	},
+	"5.5.3": func(kibanaClient *KibanaClient) FieldClient {
+		return &fieldClient553{config: kibanaClient.Config, client: kibanaClient.client}
+	},
+}
+
+func getFieldClientFromVersion(version string, kibanaClient *KibanaClient) FieldClient {
+	fieldClient, ok := fieldClientFromVersion[version]
+	if !ok {
+		fieldClient = fieldClientFromVersion[DefaultKibanaVersion6]
+	}
+
+	return fieldClient(kibanaClient)
+}
+
+var dataViewClientFromVersion = map[string]func(kibanaClient *KibanaClient) DataViewClient{
+	DefaultKibanaVersion8: func(kibanaClient *KibanaClient) DataViewClient {
+		return &dataViewClient800{config: kibanaClient.Config, client: kibanaClient.client}
+	},
+}
+
+func getDataViewClientFromVersion(version string, kibanaClient *KibanaClient) DataViewClient {
+	dataViewClient, ok := dataViewClientFromVersion[version]
+	if !ok {
+		dataViewClient = dataViewClientFromVersion[DefaultKibanaVersion8]
+	}
+
+	return dataViewClient(kibanaClient)
+}
+
+var savedQueryClientFromVersion = map[string]func(kibanaClient *KibanaClient) SavedQueryClient{
+	DefaultKibanaVersion7: func(kibanaClient *KibanaClient) SavedQueryClient {
+		return &savedQueryClient700{config: kibanaClient.Config, client: kibanaClient.client}
+	},
+	DefaultKibanaVersion8: func(kibanaClient *KibanaClient) SavedQueryClient {
+		return &savedQueryClient800{config: kibanaClient.Config, client: kibanaClient.client}
+	},
+}
+
+func getSavedQueryClientFromVersion(version string, kibanaClient *KibanaClient) SavedQueryClient {
+	savedQueryClient, ok := savedQueryClientFromVersion[version]
+	if !ok {
+		savedQueryClient = savedQueryClientFromVersion[DefaultKibanaVersion7]
+		if goversion.Compare(version, "8.0.0", ">=") {
+			savedQueryClient = savedQueryClientFromVersion[DefaultKibanaVersion8]
+		}
+	}
+
+	return savedQueryClient(kibanaClient)
+}
+
+var lensClientFromVersion = map[string]func(kibanaClient *KibanaClient) LensClient{
+	DefaultKibanaVersion7: func(kibanaClient *KibanaClient) LensClient {
+		return &lensClient700{config: kibanaClient.Config, client: kibanaClient.client}
+	},
+}
+
+func getLensClientFromVersion(version string, kibanaClient *KibanaClient) LensClient {
+	lensClient, ok := lensClientFromVersion[version]
+	if !ok {
+		lensClient = lensClientFromVersion[DefaultKibanaVersion7]
+	}
+
+	return lensClient(kibanaClient)
+}
+
 func NewDefaultConfig() *Config {
 	config := &Config{
 		ElasticSearchPath: DefaultElasticSearchPath,
@@ -290,10 +363,31 @@ func (kibanaClient *KibanaClient) Dashboard() DashboardClient {
 	return getDashboardClientFromVersion(kibanaClient.Config.KibanaVersion, kibanaClient)
 }
 
+// Lens returns the client of the lens visualizations, which require kibana 7.10 or later
+func (kibanaClient *KibanaClient) Lens() LensClient {
+	return getLensClientFromVersion(kibanaClient.Config.KibanaVersion, kibanaClient)
+}
+
 func (kibanaClient *KibanaClient) IndexPattern() IndexPatternClient {
 	return getIndexClientFromVersion(kibanaClient.Config.KibanaVersion, kibanaClient)
 }
 
+// Field returns the client reading the fields of indices from elasticsearch, which can be used to validate
+// searches and visualizations before saving them
+func (kibanaClient *KibanaClient) Field() FieldClient {
+	return getFieldClientFromVersion(kibanaClient.Config.KibanaVersion, kibanaClient)
+}
+
+// DataView returns the client of the data views api, which replaces the index pattern api from kibana 8.0
+func (kibanaClient *KibanaClient) DataView() DataViewClient {
+	return getDataViewClientFromVersion(kibanaClient.Config.KibanaVersion, kibanaClient)
+}
+
+// SavedQuery returns the client of the saved queries, which require kibana 7.3 or later
+func (kibanaClient *KibanaClient) SavedQuery() SavedQueryClient {
+	return getSavedQueryClientFromVersion(kibanaClient.Config.KibanaVersion, kibanaClient)
+}
+
 func (kibanaClient *KibanaClient) SavedObjects() SavedObjectsClient {
 	return getSavedObjectsClientFromVersion(kibanaClient.Config.KibanaVersion, kibanaClient)
 }
@@ -311,6 +405,33 @@ func (kibanaClient *KibanaClient) SetLogger(logger *log.Logger) *KibanaClient {
 	return kibanaClient
 }
 
+func (kibanaClient *KibanaClient) SetRecorder(recorder *Recorder) *KibanaClient {
+	kibanaClient.client.SetRecorder(recorder)
+	return kibanaClient
+}
+
+// AddHook registers a hook which is notified of every request made by the clients of this kibana client
+func (kibanaClient *KibanaClient) AddHook(hook RequestHook) *KibanaClient {
+	kibanaClient.client.AddHook(hook)
+	return kibanaClient
+}
+
+// SetRateLimiter throttles the requests of all the clients created from this kibana client, including
+// the ones created from kibana clients returned by WithContext
+func (kibanaClient *KibanaClient) SetRateLimiter(rateLimiter *RateLimiter) *KibanaClient {
+	kibanaClient.client.SetRateLimiter(rateLimiter)
+	return kibanaClient
+}
+
+// WithContext returns a kibana client whose calls belong to ctx, i.e. their spans are children of the span in ctx
+// and waiting for the rate limiter stops when ctx is done
+func (kibanaClient *KibanaClient) WithContext(ctx context.Context) *KibanaClient {
+	return &KibanaClient{
+		Config: kibanaClient.Config,
+		client: kibanaClient.client.WithContext(ctx),
+	}
+}
+
 func (config *Config) BuildFullPath(format string, a ...interface{}) string {
 	return config.KibanaBaseUri + config.ElasticSearchPath + fmt.Sprintf(format, a...)
 }
@@ -45,7 +45,14 @@ func TestMain(m *testing.M) {
 	client := DefaultTestKibanaClient()
 
 	if client.Config.KibanaType == KibanaTypeVanilla {
-		RunTestsWithContainers(m, client)
+		switch {
+		case UseReplay():
+			RunTestsWithReplay(m)
+		case UseFakeKibana():
+			RunTestsWithFakeKibana(m, client)
+		default:
+			RunTestsWithContainers(m, client)
+		}
 	} else {
 		RunTestsWithoutContainers(m)
 	}
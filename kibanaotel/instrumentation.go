@@ -0,0 +1,164 @@
+// Package kibanaotel reports the calls made by the kibana clients to OpenTelemetry. Every operation,
+// i.e. Dashboard.Create, produces a client span named after it and is counted in the request, error
+// and duration metrics:
+//
+//	config := kibana.NewDefaultConfig()
+//	config.Instrumentation = kibanaotel.NewInstrumentation()
+//	client := kibana.NewClient(config)
+//	dashboard, err := client.WithContext(ctx).Dashboard().GetById(id)
+package kibanaotel
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/ewilde/go-kibana"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/metric"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const instrumentationName = "github.com/ewilde/go-kibana/kibanaotel"
+
+const (
+	AttributeOperation  = attribute.Key("kibana.operation")
+	AttributeObjectType = attribute.Key("kibana.object.type")
+	AttributeObjectId   = attribute.Key("kibana.object.id")
+	AttributeMethod     = attribute.Key("http.request.method")
+	AttributeStatusCode = attribute.Key("http.response.status_code")
+	AttributeUrl        = attribute.Key("url.full")
+)
+
+const (
+	MetricRequests = "kibana.client.requests"
+	MetricErrors   = "kibana.client.errors"
+	MetricDuration = "kibana.client.duration"
+)
+
+// Instrumentation implements kibana.Instrumentation using an OpenTelemetry tracer and meter
+type Instrumentation struct {
+	tracer     trace.Tracer
+	propagator propagation.TextMapPropagator
+	requests   metric.Int64Counter
+	errors     metric.Int64Counter
+	duration   metric.Float64Histogram
+}
+
+type Option func(options *options)
+
+type options struct {
+	tracerProvider trace.TracerProvider
+	meterProvider  metric.MeterProvider
+	propagator     propagation.TextMapPropagator
+}
+
+// WithTracerProvider sets the provider of the tracer, the global provider is used by default
+func WithTracerProvider(provider trace.TracerProvider) Option {
+	return func(options *options) {
+		options.tracerProvider = provider
+	}
+}
+
+// WithMeterProvider sets the provider of the meter, the global provider is used by default
+func WithMeterProvider(provider metric.MeterProvider) Option {
+	return func(options *options) {
+		options.meterProvider = provider
+	}
+}
+
+// WithPropagator sets the propagator injecting the span into the request headers, the global propagator is used by default
+func WithPropagator(propagator propagation.TextMapPropagator) Option {
+	return func(options *options) {
+		options.propagator = propagator
+	}
+}
+
+// NewInstrumentation creates the instrumentation, it panics if the metric instruments cannot be created
+func NewInstrumentation(opts ...Option) *Instrumentation {
+	settings := &options{
+		tracerProvider: otel.GetTracerProvider(),
+		meterProvider:  otel.GetMeterProvider(),
+		propagator:     otel.GetTextMapPropagator(),
+	}
+
+	for _, option := range opts {
+		option(settings)
+	}
+
+	meter := settings.meterProvider.Meter(instrumentationName)
+	requests, err := meter.Int64Counter(MetricRequests,
+		metric.WithDescription("Number of requests sent to kibana"),
+		metric.WithUnit("{request}"))
+	if err != nil {
+		panic(err)
+	}
+
+	errors, err := meter.Int64Counter(MetricErrors,
+		metric.WithDescription("Number of requests to kibana which failed or returned an error status code"),
+		metric.WithUnit("{request}"))
+	if err != nil {
+		panic(err)
+	}
+
+	duration, err := meter.Float64Histogram(MetricDuration,
+		metric.WithDescription("Duration of the requests sent to kibana"),
+		metric.WithUnit("s"))
+	if err != nil {
+		panic(err)
+	}
+
+	return &Instrumentation{
+		tracer:     settings.tracerProvider.Tracer(instrumentationName),
+		propagator: settings.propagator,
+		requests:   requests,
+		errors:     errors,
+		duration:   duration,
+	}
+}
+
+func (instrumentation *Instrumentation) StartOperation(ctx context.Context, operation *kibana.Operation) func(result *kibana.OperationResult) {
+	attributes := []attribute.KeyValue{AttributeOperation.String(operation.Name)}
+	if operation.ObjectType != "" {
+		attributes = append(attributes, AttributeObjectType.String(operation.ObjectType))
+	}
+
+	ctx, span := instrumentation.tracer.Start(ctx, operation.Name,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(attributes...),
+		trace.WithAttributes(
+			AttributeMethod.String(operation.Method),
+			AttributeUrl.String(operation.Url)))
+
+	if operation.ObjectId != "" {
+		span.SetAttributes(AttributeObjectId.String(operation.ObjectId))
+	}
+
+	instrumentation.propagator.Inject(ctx, propagation.HeaderCarrier(operation.Header))
+
+	return func(result *kibana.OperationResult) {
+		defer span.End()
+
+		failed := result.Err != nil || result.StatusCode >= http.StatusBadRequest
+		if result.StatusCode != 0 {
+			span.SetAttributes(AttributeStatusCode.Int(result.StatusCode))
+			attributes = append(attributes, AttributeStatusCode.Int(result.StatusCode))
+		}
+
+		if result.Err != nil {
+			span.RecordError(result.Err)
+			span.SetStatus(codes.Error, result.Err.Error())
+		} else if failed {
+			span.SetStatus(codes.Error, http.StatusText(result.StatusCode))
+		}
+
+		metricAttributes := metric.WithAttributes(attributes...)
+		instrumentation.requests.Add(ctx, 1, metricAttributes)
+		instrumentation.duration.Record(ctx, result.Duration.Seconds(), metricAttributes)
+		if failed {
+			instrumentation.errors.Add(ctx, 1, metricAttributes)
+		}
+	}
+}
@@ -0,0 +1,99 @@
+package kibanaotel
+
+import (
+	"context"
+	"testing"
+
+	"github.com/ewilde/go-kibana"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+type testRequestHook struct {
+	requests []*kibana.RequestEvent
+}
+
+func (hook *testRequestHook) BeforeRequest(event *kibana.RequestEvent) {
+	hook.requests = append(hook.requests, event)
+}
+
+func (hook *testRequestHook) AfterResponse(event *kibana.ResponseEvent) {
+}
+
+func Test_Instrumentation_traces_and_measures_operations(t *testing.T) {
+	fake := kibana.NewFakeKibana(kibana.DefaultKibanaVersion7)
+	defer fake.Close()
+
+	spans := tracetest.NewSpanRecorder()
+	tracerProvider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(spans))
+	reader := sdkmetric.NewManualReader()
+	meterProvider := sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader))
+
+	config := fake.Config()
+	config.Instrumentation = NewInstrumentation(
+		WithTracerProvider(tracerProvider),
+		WithMeterProvider(meterProvider),
+		WithPropagator(propagation.TraceContext{}))
+	hook := &testRequestHook{}
+	client := kibana.NewClient(config).AddHook(hook)
+
+	ctx, parent := tracerProvider.Tracer("test").Start(context.Background(), "parent")
+	search, err := client.WithContext(ctx).Search().Create(&kibana.CreateSearchRequest{
+		Attributes: &kibana.SearchAttributes{Title: "traced"},
+	})
+	parent.End()
+	require.NoError(t, err)
+
+	_, err = client.Dashboard().GetById("missing")
+	require.Error(t, err)
+
+	ended := spans.Ended()
+	require.Len(t, ended, 3)
+
+	create := ended[0]
+	assert.Equal(t, "Search.Create", create.Name())
+	assert.Equal(t, parent.SpanContext().SpanID(), create.Parent().SpanID())
+	assert.Contains(t, create.Attributes(), AttributeObjectType.String("search"))
+	assert.Contains(t, create.Attributes(), AttributeStatusCode.Int(200))
+	assert.Equal(t, codes.Unset, create.Status().Code)
+	assert.NotEmpty(t, search.Id)
+	assert.Contains(t, hook.requests[0].Header.Get("Traceparent"), create.SpanContext().SpanID().String())
+
+	getById := ended[2]
+	assert.Equal(t, "Dashboard.GetById", getById.Name())
+	assert.False(t, getById.Parent().IsValid())
+	assert.Contains(t, getById.Attributes(), AttributeObjectId.String("missing"))
+	assert.Equal(t, codes.Error, getById.Status().Code)
+
+	metrics := &metricdata.ResourceMetrics{}
+	require.NoError(t, reader.Collect(context.Background(), metrics))
+	require.Len(t, metrics.ScopeMetrics, 1)
+
+	values := map[string]metricdata.Aggregation{}
+	for _, value := range metrics.ScopeMetrics[0].Metrics {
+		values[value.Name] = value.Data
+	}
+
+	requests := values[MetricRequests].(metricdata.Sum[int64])
+	require.Len(t, requests.DataPoints, 2)
+	for _, point := range requests.DataPoints {
+		assert.Equal(t, int64(1), point.Value)
+		_, hasId := point.Attributes.Value(AttributeObjectId)
+		assert.False(t, hasId, "The object id should not be a metric dimension")
+	}
+
+	errors := values[MetricErrors].(metricdata.Sum[int64])
+	require.Len(t, errors.DataPoints, 1)
+	operation, _ := errors.DataPoints[0].Attributes.Value(AttributeOperation)
+	assert.Equal(t, attribute.StringValue("Dashboard.GetById"), operation)
+
+	duration := values[MetricDuration].(metricdata.Histogram[float64])
+	assert.Len(t, duration.DataPoints, 2)
+}
@@ -0,0 +1,440 @@
+package kibana
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+type kqlTokenType int
+
+const (
+	kqlTokenEnd kqlTokenType = iota
+	kqlTokenLiteral
+	kqlTokenString
+	kqlTokenColon
+	kqlTokenRange
+	kqlTokenOpenParen
+	kqlTokenCloseParen
+	kqlTokenOpenBrace
+	kqlTokenCloseBrace
+	kqlTokenAnd
+	kqlTokenOr
+	kqlTokenNot
+)
+
+type kqlToken struct {
+	tokenType kqlTokenType
+	text      string
+	position  int
+}
+
+type kqlParser struct {
+	query  []rune
+	tokens []*kqlToken
+	next   int
+}
+
+// kqlQuery is the elasticsearch query dsl a kql query translates to
+type kqlQuery map[string]interface{}
+
+type kqlValue struct {
+	text     string
+	raw      string
+	quoted   bool
+	wildcard bool
+}
+
+var kqlRangeOperators = map[string]string{
+	"<":  "lt",
+	"<=": "lte",
+	">":  "gt",
+	">=": "gte",
+}
+
+// kqlSpecialCharacters must be escaped with a backslash to be part of an unquoted value
+const kqlSpecialCharacters = `\():<>"{}`
+
+// ValidateKQL checks the syntax of a kibana query language query, as written in the kibana query bar from
+// kibana 6.3. It does not check the fields of the query exist.
+func ValidateKQL(query string) error {
+	_, err := kqlToQueryDsl(query)
+	return err
+}
+
+// kqlToQueryDsl translates a kql query to the elasticsearch query dsl, an empty query matches all documents
+func kqlToQueryDsl(query string) (kqlQuery, error) {
+	tokens, err := tokenizeKQL(query)
+	if err != nil {
+		return nil, fmt.Errorf("invalid kql query, %v", err)
+	}
+
+	parser := &kqlParser{query: []rune(query), tokens: tokens}
+	if parser.peek().tokenType == kqlTokenEnd {
+		return kqlQuery{"match_all": kqlQuery{}}, nil
+	}
+
+	dsl, err := parser.parseOr("")
+	if err != nil {
+		return nil, fmt.Errorf("invalid kql query, %v", err)
+	}
+
+	if token := parser.peek(); token.tokenType != kqlTokenEnd {
+		return nil, fmt.Errorf("invalid kql query, %s", unexpectedKQLToken(token))
+	}
+
+	return dsl, nil
+}
+
+func tokenizeKQL(query string) ([]*kqlToken, error) {
+	var tokens []*kqlToken
+	runes := []rune(query)
+	for position := 0; position < len(runes); {
+		character := runes[position]
+		switch {
+		case unicode.IsSpace(character):
+			position++
+		case character == '(':
+			tokens = append(tokens, &kqlToken{tokenType: kqlTokenOpenParen, text: "(", position: position})
+			position++
+		case character == ')':
+			tokens = append(tokens, &kqlToken{tokenType: kqlTokenCloseParen, text: ")", position: position})
+			position++
+		case character == '{':
+			tokens = append(tokens, &kqlToken{tokenType: kqlTokenOpenBrace, text: "{", position: position})
+			position++
+		case character == '}':
+			tokens = append(tokens, &kqlToken{tokenType: kqlTokenCloseBrace, text: "}", position: position})
+			position++
+		case character == ':':
+			tokens = append(tokens, &kqlToken{tokenType: kqlTokenColon, text: ":", position: position})
+			position++
+		case character == '<' || character == '>':
+			text := string(character)
+			if position+1 < len(runes) && runes[position+1] == '=' {
+				text += "="
+			}
+
+			tokens = append(tokens, &kqlToken{tokenType: kqlTokenRange, text: text, position: position})
+			position += len(text)
+		case character == '"':
+			end := position + 1
+			for ; end < len(runes) && runes[end] != '"'; end++ {
+				if runes[end] == '\\' {
+					end++
+				}
+			}
+
+			if end >= len(runes) {
+				return nil, fmt.Errorf("unterminated quoted string at position %d", position)
+			}
+
+			tokens = append(tokens, &kqlToken{tokenType: kqlTokenString, text: string(runes[position : end+1]), position: position})
+			position = end + 1
+		default:
+			end := position
+			for ; end < len(runes) && !unicode.IsSpace(runes[end]) && !strings.ContainsRune(kqlSpecialCharacters, runes[end]); end++ {
+			}
+
+			for end < len(runes) && runes[end] == '\\' {
+				if end+1 >= len(runes) {
+					return nil, fmt.Errorf("trailing escape character at position %d", end)
+				}
+
+				end += 2
+				for ; end < len(runes) && !unicode.IsSpace(runes[end]) && !strings.ContainsRune(kqlSpecialCharacters, runes[end]); end++ {
+				}
+			}
+
+			text := string(runes[position:end])
+			token := &kqlToken{tokenType: kqlTokenLiteral, text: text, position: position}
+			switch strings.ToLower(text) {
+			case "and":
+				token.tokenType = kqlTokenAnd
+			case "or":
+				token.tokenType = kqlTokenOr
+			case "not":
+				token.tokenType = kqlTokenNot
+			}
+
+			tokens = append(tokens, token)
+			position = end
+		}
+	}
+
+	return append(tokens, &kqlToken{tokenType: kqlTokenEnd, position: len(runes)}), nil
+}
+
+func (parser *kqlParser) peek() *kqlToken {
+	return parser.tokens[parser.next]
+}
+
+func (parser *kqlParser) take() *kqlToken {
+	token := parser.tokens[parser.next]
+	if token.tokenType != kqlTokenEnd {
+		parser.next++
+	}
+
+	return token
+}
+
+func (parser *kqlParser) expect(tokenType kqlTokenType) error {
+	if token := parser.take(); token.tokenType != tokenType {
+		return errors.New(unexpectedKQLToken(token))
+	}
+
+	return nil
+}
+
+func (parser *kqlParser) parseOr(prefix string) (kqlQuery, error) {
+	return parser.parseBinary(kqlTokenOr, func() (kqlQuery, error) { return parser.parseAnd(prefix) })
+}
+
+func (parser *kqlParser) parseAnd(prefix string) (kqlQuery, error) {
+	return parser.parseBinary(kqlTokenAnd, func() (kqlQuery, error) { return parser.parseNot(prefix) })
+}
+
+func (parser *kqlParser) parseNot(prefix string) (kqlQuery, error) {
+	if parser.peek().tokenType != kqlTokenNot {
+		return parser.parseSubQuery(prefix)
+	}
+
+	parser.take()
+	query, err := parser.parseSubQuery(prefix)
+	if err != nil {
+		return nil, err
+	}
+
+	return kqlBool("must_not", []kqlQuery{query}), nil
+}
+
+// parseBinary parses operands separated by the and or the or operator, combining them in a bool query
+func (parser *kqlParser) parseBinary(operator kqlTokenType, operand func() (kqlQuery, error)) (kqlQuery, error) {
+	query, err := operand()
+	if err != nil {
+		return nil, err
+	}
+
+	queries := []kqlQuery{query}
+	for parser.peek().tokenType == operator {
+		parser.take()
+		query, err := operand()
+		if err != nil {
+			return nil, err
+		}
+
+		queries = append(queries, query)
+	}
+
+	switch {
+	case len(queries) == 1:
+		return queries[0], nil
+	case operator == kqlTokenOr:
+		return kqlBool("should", queries), nil
+	default:
+		return kqlBool("filter", queries), nil
+	}
+}
+
+// parseSubQuery parses a group in parentheses or an expression, which is a value, a field and a value
+// separated by a colon, a field and a range or a nested query on a field. Fields of a nested query are
+// relative to the nested field given as prefix.
+func (parser *kqlParser) parseSubQuery(prefix string) (kqlQuery, error) {
+	if parser.peek().tokenType == kqlTokenOpenParen {
+		parser.take()
+		query, err := parser.parseOr(prefix)
+		if err != nil {
+			return nil, err
+		}
+
+		return query, parser.expect(kqlTokenCloseParen)
+	}
+
+	value, err := parser.parseValue()
+	if err != nil {
+		return nil, err
+	}
+
+	switch parser.peek().tokenType {
+	case kqlTokenColon:
+		parser.take()
+		field := prefix + value.text
+		switch parser.peek().tokenType {
+		case kqlTokenOpenBrace:
+			parser.take()
+			query, err := parser.parseOr(field + ".")
+			if err != nil {
+				return nil, err
+			}
+
+			return kqlQuery{"nested": kqlQuery{"path": field, "query": query, "score_mode": "none"}}, parser.expect(kqlTokenCloseBrace)
+		case kqlTokenOpenParen:
+			return parser.parseValueGroup(field)
+		default:
+			fieldValue, err := parser.parseValue()
+			if err != nil {
+				return nil, err
+			}
+
+			return fieldValue.fieldQuery(field), nil
+		}
+	case kqlTokenRange:
+		operator := parser.take()
+		bound, err := parser.parseValue()
+		if err != nil {
+			return nil, err
+		}
+
+		return kqlQuery{"range": kqlQuery{prefix + value.text: kqlQuery{kqlRangeOperators[operator.text]: bound.text}}}, nil
+	}
+
+	return value.query(), nil
+}
+
+// parseValueGroup parses a list of values of the field combined with and, or and not, such as
+// response:(200 or 404)
+func (parser *kqlParser) parseValueGroup(field string) (kqlQuery, error) {
+	if err := parser.expect(kqlTokenOpenParen); err != nil {
+		return nil, err
+	}
+
+	var parseValueOr, parseValueAnd, parseValueNot func() (kqlQuery, error)
+	parseValueNot = func() (kqlQuery, error) {
+		negate := parser.peek().tokenType == kqlTokenNot
+		if negate {
+			parser.take()
+		}
+
+		var query kqlQuery
+		if parser.peek().tokenType == kqlTokenOpenParen {
+			parser.take()
+			group, err := parseValueOr()
+			if err != nil {
+				return nil, err
+			}
+
+			if err := parser.expect(kqlTokenCloseParen); err != nil {
+				return nil, err
+			}
+
+			query = group
+		} else {
+			value, err := parser.parseValue()
+			if err != nil {
+				return nil, err
+			}
+
+			query = value.fieldQuery(field)
+		}
+
+		if negate {
+			return kqlBool("must_not", []kqlQuery{query}), nil
+		}
+
+		return query, nil
+	}
+	parseValueAnd = func() (kqlQuery, error) { return parser.parseBinary(kqlTokenAnd, parseValueNot) }
+	parseValueOr = func() (kqlQuery, error) { return parser.parseBinary(kqlTokenOr, parseValueAnd) }
+
+	query, err := parseValueOr()
+	if err != nil {
+		return nil, err
+	}
+
+	return query, parser.expect(kqlTokenCloseParen)
+}
+
+// parseValue parses a quoted string or an unquoted value, unquoted values may contain spaces
+func (parser *kqlParser) parseValue() (*kqlValue, error) {
+	token := parser.take()
+	switch token.tokenType {
+	case kqlTokenString:
+		return &kqlValue{text: unescapeKQL(token.text[1 : len(token.text)-1]), quoted: true}, nil
+	case kqlTokenLiteral:
+		last := token
+		for parser.peek().tokenType == kqlTokenLiteral {
+			last = parser.take()
+		}
+
+		raw := string(parser.query[token.position : last.position+len([]rune(last.text))])
+		return &kqlValue{text: unescapeKQL(raw), raw: raw, wildcard: hasKQLWildcard(raw)}, nil
+	}
+
+	return nil, errors.New(unexpectedKQLToken(token))
+}
+
+// fieldQuery is the query matching the value in the field, quoted values match as a phrase
+func (value *kqlValue) fieldQuery(field string) kqlQuery {
+	switch {
+	case value.quoted:
+		return kqlQuery{"match_phrase": kqlQuery{field: value.text}}
+	case value.raw == "*":
+		return kqlQuery{"exists": kqlQuery{"field": field}}
+	case value.wildcard:
+		return kqlQuery{"query_string": kqlQuery{"fields": []string{field}, "query": value.raw}}
+	default:
+		return kqlQuery{"match": kqlQuery{field: value.text}}
+	}
+}
+
+// query is the query matching the value in any field
+func (value *kqlValue) query() kqlQuery {
+	switch {
+	case value.quoted:
+		return kqlQuery{"multi_match": kqlQuery{"query": value.text, "type": "phrase", "lenient": true}}
+	case value.wildcard:
+		return kqlQuery{"query_string": kqlQuery{"query": value.raw}}
+	default:
+		return kqlQuery{"multi_match": kqlQuery{"query": value.text, "type": "best_fields", "lenient": true}}
+	}
+}
+
+func kqlBool(occurrence string, queries []kqlQuery) kqlQuery {
+	clause := kqlQuery{occurrence: queries}
+	if occurrence == "should" {
+		clause["minimum_should_match"] = 1
+	}
+
+	return kqlQuery{"bool": clause}
+}
+
+func hasKQLWildcard(raw string) bool {
+	escaped := false
+	for _, character := range raw {
+		switch {
+		case escaped:
+			escaped = false
+		case character == '\\':
+			escaped = true
+		case character == '*':
+			return true
+		}
+	}
+
+	return false
+}
+
+func unescapeKQL(text string) string {
+	var unescaped strings.Builder
+	escaped := false
+	for _, character := range text {
+		if character == '\\' && !escaped {
+			escaped = true
+			continue
+		}
+
+		escaped = false
+		unescaped.WriteRune(character)
+	}
+
+	return unescaped.String()
+}
+
+func unexpectedKQLToken(token *kqlToken) string {
+	if token.tokenType == kqlTokenEnd {
+		return "unexpected end of query"
+	}
+
+	return fmt.Sprintf("unexpected %s at position %d", token.text, token.position)
+}
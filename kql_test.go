@@ -0,0 +1,80 @@
+package kibana
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_ValidateKQL(t *testing.T) {
+	var testCases = []struct {
+		query         string
+		expectedError string
+	}{
+		{query: ""},
+		{query: "response:200"},
+		{query: "message:hello world"},
+		{query: `message:"hello world" and not geo.src:CN`},
+		{query: "response:(200 or 404) or (bytes >= 1000 and bytes < 5000)"},
+		{query: "machine.os:win*"},
+		{query: `path:\/index\.html`},
+		{query: "items:{ name:banana and stock > 9 }"},
+		{query: "NOT extension:php"},
+		{query: "response:", expectedError: "invalid kql query, unexpected end of query"},
+		{query: "response:200 and", expectedError: "invalid kql query, unexpected end of query"},
+		{query: "(response:200", expectedError: "invalid kql query, unexpected end of query"},
+		{query: "response:200)", expectedError: "invalid kql query, unexpected ) at position 12"},
+		{query: `message:"hello`, expectedError: "invalid kql query, unterminated quoted string at position 8"},
+		{query: "bytes > and", expectedError: "invalid kql query, unexpected and at position 8"},
+		{query: `path:index\`, expectedError: "invalid kql query, trailing escape character at position 10"},
+		{query: "response::200", expectedError: "invalid kql query, unexpected : at position 9"},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.query, func(t *testing.T) {
+			err := ValidateKQL(testCase.query)
+			if testCase.expectedError == "" {
+				assert.NoError(t, err)
+			} else {
+				assert.EqualError(t, err, testCase.expectedError)
+			}
+		})
+	}
+}
+
+func Test_kqlToQueryDsl(t *testing.T) {
+	var testCases = []struct {
+		query    string
+		expected string
+	}{
+		{query: "", expected: `{"match_all": {}}`},
+		{query: "response:200", expected: `{"match": {"response": "200"}}`},
+		{query: `message:"hello world"`, expected: `{"match_phrase": {"message": "hello world"}}`},
+		{query: "machine.os:*", expected: `{"exists": {"field": "machine.os"}}`},
+		{query: "machine.os:win*", expected: `{"query_string": {"fields": ["machine.os"], "query": "win*"}}`},
+		{query: "hello world", expected: `{"multi_match": {"query": "hello world", "type": "best_fields", "lenient": true}}`},
+		{query: "bytes >= 1000", expected: `{"range": {"bytes": {"gte": "1000"}}}`},
+		{
+			query:    "response:(200 or 404) and not geo.src:CN",
+			expected: `{"bool": {"filter": [{"bool": {"should": [{"match": {"response": "200"}}, {"match": {"response": "404"}}], "minimum_should_match": 1}}, {"bool": {"must_not": [{"match": {"geo.src": "CN"}}]}}]}}`,
+		},
+		{
+			query:    "items:{ name:banana }",
+			expected: `{"nested": {"path": "items", "score_mode": "none", "query": {"match": {"items.name": "banana"}}}}`,
+		},
+		{query: `path:\/index\.html`, expected: `{"match": {"path": "/index.html"}}`},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.query, func(t *testing.T) {
+			queryDsl, err := kqlToQueryDsl(testCase.query)
+			require.NoError(t, err)
+
+			data, err := json.Marshal(queryDsl)
+			require.NoError(t, err)
+			assert.JSONEq(t, testCase.expected, string(data))
+		})
+	}
+}
@@ -0,0 +1,416 @@
+package kibana
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	goversion "github.com/mcuadros/go-version"
+)
+
+type LensClient interface {
+	Create(request *CreateLensRequest) (*Lens, error)
+	GetById(id string) (*Lens, error)
+	List() ([]*Lens, error)
+	Update(id string, request *UpdateLensRequest) (*Lens, error)
+	Delete(id string) error
+}
+
+// lensClient700 manages lens visualizations as lens saved objects, supported from kibana 7.10
+type lensClient700 struct {
+	config *Config
+	client *HttpAgent
+}
+
+// Lens is a lens visualization, it is shown on a dashboard by a panel of type DashboardReferencesTypeLens
+type Lens struct {
+	Id         string                  `json:"id"`
+	Type       string                  `json:"type"`
+	Version    version                 `json:"version"`
+	Attributes *LensAttributes         `json:"attributes"`
+	References []*SavedObjectReference `json:"references,omitempty"`
+}
+
+// LensAttributes are the attributes of a lens visualization, VisualizationType is set from the typed
+// visualization of the state when it is saved
+type LensAttributes struct {
+	Title             string
+	Description       string
+	VisualizationType LensVisualizationType
+	State             *LensState
+}
+
+// CreateLensRequest creates a lens visualization, the index patterns of the layers are added to the references
+// unless the references are set explicitly
+type CreateLensRequest struct {
+	Attributes *LensAttributes
+	References []*SavedObjectReference
+}
+
+type UpdateLensRequest struct {
+	Attributes *LensAttributes
+	References []*SavedObjectReference
+}
+
+type LensRequestBuilder struct {
+	title         string
+	description   string
+	layers        map[string]*LensLayer
+	visualization LensVisualization
+	query         *SearchQuery600
+	filters       []*SearchFilter
+}
+
+type lensAttributesJson struct {
+	Title             string                `json:"title"`
+	Description       string                `json:"description"`
+	VisualizationType LensVisualizationType `json:"visualizationType"`
+	State             json.RawMessage       `json:"state"`
+}
+
+type lensRequest struct {
+	Attributes interface{}             `json:"attributes"`
+	References []*SavedObjectReference `json:"references"`
+}
+
+func NewLensRequestBuilder() *LensRequestBuilder {
+	return &LensRequestBuilder{layers: map[string]*LensLayer{}}
+}
+
+func (builder *LensRequestBuilder) WithTitle(title string) *LensRequestBuilder {
+	builder.title = title
+	return builder
+}
+
+func (builder *LensRequestBuilder) WithDescription(description string) *LensRequestBuilder {
+	builder.description = description
+	return builder
+}
+
+// WithLayer adds a datasource layer, the visualization refers to it by layer id
+func (builder *LensRequestBuilder) WithLayer(layerId string, layer *LensLayer) *LensRequestBuilder {
+	builder.layers[layerId] = layer
+	return builder
+}
+
+func (builder *LensRequestBuilder) WithVisualization(visualization LensVisualization) *LensRequestBuilder {
+	builder.visualization = visualization
+	return builder
+}
+
+// WithQuery sets the text of the query and its language, kuery or lucene
+func (builder *LensRequestBuilder) WithQuery(query string, language string) *LensRequestBuilder {
+	builder.query = &SearchQuery600{Query: query, Language: language}
+	return builder
+}
+
+func (builder *LensRequestBuilder) WithFilter(filter *SearchFilter) *LensRequestBuilder {
+	builder.filters = append(builder.filters, filter)
+	return builder
+}
+
+// Build creates the request, checking every layer has an index pattern and the visualization only refers to
+// columns of the layers
+func (builder *LensRequestBuilder) Build() (*CreateLensRequest, error) {
+	if builder.title == "" {
+		return nil, errors.New("lens title is required")
+	}
+
+	if builder.visualization == nil {
+		return nil, errors.New("lens visualization is required")
+	}
+
+	if len(builder.layers) == 0 {
+		return nil, errors.New("lens visualization requires at least one layer")
+	}
+
+	for layerId, layer := range builder.layers {
+		if layer.IndexPatternId == "" {
+			return nil, fmt.Errorf("invalid lens layer %s, the layer has no index pattern", layerId)
+		}
+	}
+
+	for layerId, accessors := range builder.visualization.layerAccessors() {
+		layer, ok := builder.layers[layerId]
+		if !ok {
+			return nil, fmt.Errorf("invalid lens visualization, layer %s does not exist", layerId)
+		}
+
+		for _, accessor := range accessors {
+			if _, ok := layer.Columns[accessor]; !ok {
+				return nil, fmt.Errorf("invalid lens visualization, column %s does not exist in layer %s", accessor, layerId)
+			}
+		}
+	}
+
+	query := builder.query
+	if query != nil && query.Language == QueryLanguageKuery {
+		if err := ValidateKQL(query.Query); err != nil {
+			return nil, err
+		}
+	}
+
+	return &CreateLensRequest{
+		Attributes: &LensAttributes{
+			Title:             builder.title,
+			Description:       builder.description,
+			VisualizationType: builder.visualization.visualizationType(),
+			State: &LensState{
+				Datasource:    &LensDatasourceState{Layers: builder.layers},
+				Visualization: builder.visualization,
+				Query:         query,
+				Filters:       builder.filters,
+			},
+		},
+	}, nil
+}
+
+// UnmarshalJSON decodes the attributes, the visualization of the state is decoded for the visualization type
+func (attributes *LensAttributes) UnmarshalJSON(data []byte) error {
+	raw := &lensAttributesJson{}
+	if err := json.Unmarshal(data, raw); err != nil {
+		return err
+	}
+
+	*attributes = LensAttributes{Title: raw.Title, Description: raw.Description, VisualizationType: raw.VisualizationType}
+	if len(raw.State) == 0 || string(raw.State) == "null" {
+		return nil
+	}
+
+	var rawState struct {
+		Visualization json.RawMessage `json:"visualization"`
+	}
+
+	if err := json.Unmarshal(raw.State, &rawState); err != nil {
+		return fmt.Errorf("could not parse lens state, error: %v", err)
+	}
+
+	attributes.State = &LensState{}
+	if err := json.Unmarshal(raw.State, attributes.State); err != nil {
+		return fmt.Errorf("could not parse lens state, error: %v", err)
+	}
+
+	newVisualization, ok := lensVisualizationFromType[raw.VisualizationType]
+	if ok && len(rawState.Visualization) > 0 && string(rawState.Visualization) != "null" {
+		visualization := newVisualization()
+		if err := json.Unmarshal(rawState.Visualization, visualization); err != nil {
+			return fmt.Errorf("could not parse %s lens visualization, error: %v", raw.VisualizationType, err)
+		}
+
+		attributes.State.Visualization = visualization
+	}
+
+	return nil
+}
+
+// forVersion encodes the attributes for the kibana version
+func (attributes *LensAttributes) forVersion(version string) (interface{}, error) {
+	if goversion.Compare(version, "7.10.0", "<") {
+		return nil, fmt.Errorf("lens visualizations are not supported by kibana %s, they require kibana 7.10 or later", version)
+	}
+
+	encoded := map[string]interface{}{
+		"title":             attributes.Title,
+		"description":       attributes.Description,
+		"visualizationType": attributes.VisualizationType,
+	}
+
+	if attributes.State != nil {
+		if attributes.State.Visualization != nil && attributes.State.Visualization.visualizationType() != "" {
+			encoded["visualizationType"] = attributes.State.Visualization.visualizationType()
+		}
+
+		encoded["state"] = attributes.State.stateForVersion(version)
+	}
+
+	return encoded, nil
+}
+
+// MarshalJSON encodes the attributes for kibana 8.0, clients encode them for the version of kibana they call
+func (attributes *LensAttributes) MarshalJSON() ([]byte, error) {
+	encoded, err := attributes.forVersion(DefaultKibanaVersion8)
+	if err != nil {
+		return nil, err
+	}
+
+	return json.Marshal(encoded)
+}
+
+func (attributes *LensAttributes) references() []*SavedObjectReference {
+	if attributes.State == nil {
+		return []*SavedObjectReference{}
+	}
+
+	return attributes.State.references()
+}
+
+func (api *lensClient700) Create(request *CreateLensRequest) (*Lens, error) {
+	if err := api.checkVersion(); err != nil {
+		return nil, err
+	}
+
+	body, err := api.lensRequest(request.Attributes, request.References)
+	if err != nil {
+		return nil, err
+	}
+
+	response, responseBody, errs := api.client.
+		Post(api.config.KibanaBaseUri+savedObjectsPath+"lens").
+		Operation("Lens.Create", "lens", "").
+		Set("kbn-version", api.config.KibanaVersion).
+		Send(body).
+		End()
+
+	if errs != nil {
+		return nil, errs[0]
+	}
+
+	if response.StatusCode >= 300 {
+		return nil, NewError(response, responseBody, "Could not create lens visualization")
+	}
+
+	return parseLensResponse(responseBody, "create lens visualization")
+}
+
+func (api *lensClient700) GetById(id string) (*Lens, error) {
+	if err := api.checkVersion(); err != nil {
+		return nil, err
+	}
+
+	response, body, errs := api.client.
+		Get(api.config.KibanaBaseUri+savedObjectsPath+"lens/"+id).
+		Operation("Lens.GetById", "lens", id).
+		Set("kbn-version", api.config.KibanaVersion).
+		End()
+
+	if errs != nil {
+		return nil, errs[0]
+	}
+
+	if response.StatusCode >= 300 {
+		return nil, NewError(response, body, "Could not fetch lens visualization")
+	}
+
+	return parseLensResponse(body, "get lens visualization")
+}
+
+func (api *lensClient700) List() ([]*Lens, error) {
+	if err := api.checkVersion(); err != nil {
+		return nil, err
+	}
+
+	response, body, errs := api.client.
+		Get(api.config.KibanaBaseUri+savedObjectsPath+"_find?type=lens&per_page=9999").
+		Operation("Lens.List", "lens", "").
+		Set("kbn-version", api.config.KibanaVersion).
+		End()
+
+	if errs != nil {
+		return nil, errs[0]
+	}
+
+	if response.StatusCode >= 300 {
+		return nil, NewError(response, body, "Could not fetch lens visualizations")
+	}
+
+	result := &struct {
+		SavedObjects []*Lens `json:"saved_objects"`
+	}{}
+	if err := json.Unmarshal([]byte(body), result); err != nil {
+		return nil, fmt.Errorf("could not parse fields from list lens visualizations response, error: %v", err)
+	}
+
+	for _, lens := range result.SavedObjects {
+		lens.resolveReferences()
+	}
+
+	return result.SavedObjects, nil
+}
+
+func (api *lensClient700) Update(id string, request *UpdateLensRequest) (*Lens, error) {
+	if err := api.checkVersion(); err != nil {
+		return nil, err
+	}
+
+	body, err := api.lensRequest(request.Attributes, request.References)
+	if err != nil {
+		return nil, err
+	}
+
+	response, responseBody, errs := api.client.
+		Put(api.config.KibanaBaseUri+savedObjectsPath+"lens/"+id).
+		Operation("Lens.Update", "lens", id).
+		Set("kbn-version", api.config.KibanaVersion).
+		Send(body).
+		End()
+
+	if errs != nil {
+		return nil, errs[0]
+	}
+
+	if response.StatusCode >= 300 {
+		return nil, NewError(response, responseBody, "Could not update lens visualization")
+	}
+
+	return parseLensResponse(responseBody, "update lens visualization")
+}
+
+func (api *lensClient700) Delete(id string) error {
+	if err := api.checkVersion(); err != nil {
+		return err
+	}
+
+	response, body, errs := api.client.
+		Delete(api.config.KibanaBaseUri+savedObjectsPath+"lens/"+id).
+		Operation("Lens.Delete", "lens", id).
+		Set("kbn-version", api.config.KibanaVersion).
+		End()
+
+	if errs != nil {
+		return errs[0]
+	}
+
+	if response.StatusCode >= 300 {
+		return NewError(response, body, "Could not delete lens visualization")
+	}
+
+	return nil
+}
+
+func (api *lensClient700) checkVersion() error {
+	if goversion.Compare(api.config.KibanaVersion, "7.10.0", "<") {
+		return fmt.Errorf("lens visualizations are not supported by kibana %s, they require kibana 7.10 or later", api.config.KibanaVersion)
+	}
+
+	return nil
+}
+
+func (api *lensClient700) lensRequest(attributes *LensAttributes, references []*SavedObjectReference) (*lensRequest, error) {
+	encoded, err := attributes.forVersion(api.config.KibanaVersion)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(references) == 0 {
+		references = attributes.references()
+	}
+
+	return &lensRequest{Attributes: encoded, References: references}, nil
+}
+
+// resolveReferences sets the index patterns of the layers from the references
+func (lens *Lens) resolveReferences() {
+	if lens.Attributes != nil && lens.Attributes.State != nil {
+		lens.Attributes.State.resolveReferences(lens.References)
+	}
+}
+
+func parseLensResponse(body string, operation string) (*Lens, error) {
+	lens := &Lens{}
+	if err := json.Unmarshal([]byte(body), lens); err != nil {
+		return nil, fmt.Errorf("could not parse fields from %s response, error: %v", operation, err)
+	}
+
+	lens.resolveReferences()
+	return lens, nil
+}
@@ -0,0 +1,768 @@
+package kibana
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+
+	goversion "github.com/mcuadros/go-version"
+)
+
+const (
+	LensVisualizationTypeXY        LensVisualizationType = "lnsXY"
+	LensVisualizationTypeMetric    LensVisualizationType = "lnsMetric"
+	LensVisualizationTypePie       LensVisualizationType = "lnsPie"
+	LensVisualizationTypeDatatable LensVisualizationType = "lnsDatatable"
+)
+
+const (
+	LensSeriesTypeBar            LensSeriesType = "bar"
+	LensSeriesTypeBarStacked     LensSeriesType = "bar_stacked"
+	LensSeriesTypeBarHorizontal  LensSeriesType = "bar_horizontal"
+	LensSeriesTypeBarPercentage  LensSeriesType = "bar_percentage_stacked"
+	LensSeriesTypeLine           LensSeriesType = "line"
+	LensSeriesTypeArea           LensSeriesType = "area"
+	LensSeriesTypeAreaStacked    LensSeriesType = "area_stacked"
+	LensSeriesTypeAreaPercentage LensSeriesType = "area_percentage_stacked"
+)
+
+const (
+	LensPieShapePie     LensPieShape = "pie"
+	LensPieShapeDonut   LensPieShape = "donut"
+	LensPieShapeTreemap LensPieShape = "treemap"
+)
+
+const (
+	LensOperationCount         LensOperation = "count"
+	LensOperationSum           LensOperation = "sum"
+	LensOperationAverage       LensOperation = "average"
+	LensOperationMin           LensOperation = "min"
+	LensOperationMax           LensOperation = "max"
+	LensOperationMedian        LensOperation = "median"
+	LensOperationUniqueCount   LensOperation = "unique_count"
+	LensOperationLastValue     LensOperation = "last_value"
+	LensOperationTerms         LensOperation = "terms"
+	LensOperationDateHistogram LensOperation = "date_histogram"
+	LensOperationRange         LensOperation = "range"
+	LensOperationFilters       LensOperation = "filters"
+)
+
+const (
+	lensLayerTypeData                = "data"
+	lensDatasourceIndexPattern       = "indexpattern"
+	lensDatasourceFormBased          = "formBased"
+	lensLayerReferencePrefix         = "indexpattern-datasource-layer-"
+	lensCurrentIndexPatternReference = "indexpattern-datasource-current-indexpattern"
+)
+
+type LensVisualizationType string
+
+func (t LensVisualizationType) String() string {
+	return string(t)
+}
+
+type LensSeriesType string
+
+type LensPieShape string
+
+type LensOperation string
+
+// LensState is the typed form of the state attribute of a lens visualization. Visualization holds one of the
+// typed visualizations below depending on the visualization type, or RawLensVisualization for the other types.
+type LensState struct {
+	Datasource    *LensDatasourceState
+	Visualization LensVisualization
+	Query         *SearchQuery600
+	Filters       []*SearchFilter
+
+	// otherDatasources are the states of datasources without a typed model, such as the text based datasource,
+	// they are saved unchanged
+	otherDatasources map[string]json.RawMessage
+}
+
+// LensDatasourceState holds the layers of the form based datasource, named indexpattern before kibana 8.6.
+// The layers are keyed by layer id.
+type LensDatasourceState struct {
+	Layers map[string]*LensLayer `json:"layers"`
+}
+
+// LensLayer is a table of columns computed from the index pattern, IndexPatternId is saved as a reference of the
+// lens visualization
+type LensLayer struct {
+	IndexPatternId string                 `json:"-"`
+	ColumnOrder    []string               `json:"columnOrder"`
+	Columns        map[string]*LensColumn `json:"columns"`
+}
+
+// LensColumn is a bucket or metric of a layer, Params depends on the operation
+type LensColumn struct {
+	Label         string                 `json:"label"`
+	DataType      string                 `json:"dataType"`
+	OperationType LensOperation          `json:"operationType"`
+	SourceField   string                 `json:"sourceField,omitempty"`
+	IsBucketed    bool                   `json:"isBucketed"`
+	Scale         string                 `json:"scale,omitempty"`
+	CustomLabel   bool                   `json:"customLabel,omitempty"`
+	Params        map[string]interface{} `json:"params,omitempty"`
+}
+
+// LensVisualization is the chart drawn from the datasource layers, it is encoded differently depending on the
+// kibana version
+type LensVisualization interface {
+	visualizationType() LensVisualizationType
+	visualizationForVersion(version string) interface{}
+	layerAccessors() map[string][]string
+}
+
+// RawLensVisualization is the state of lens visualization types without a typed model
+type RawLensVisualization map[string]interface{}
+
+type LensLegend struct {
+	IsVisible bool   `json:"isVisible"`
+	Position  string `json:"position"`
+}
+
+// LensXYVisualization is a bar, line or area chart, each layer draws the accessor columns against the x
+// accessor column, split into series by the split accessor column
+type LensXYVisualization struct {
+	Legend              *LensLegend    `json:"legend"`
+	ValueLabels         string         `json:"valueLabels"`
+	PreferredSeriesType LensSeriesType `json:"preferredSeriesType"`
+	Layers              []*LensXYLayer `json:"layers"`
+}
+
+type LensXYLayer struct {
+	LayerId       string         `json:"layerId"`
+	LayerType     string         `json:"layerType,omitempty"`
+	SeriesType    LensSeriesType `json:"seriesType"`
+	XAccessor     string         `json:"xAccessor,omitempty"`
+	SplitAccessor string         `json:"splitAccessor,omitempty"`
+	Accessors     []string       `json:"accessors"`
+}
+
+// LensMetricVisualization shows the value of a single column, kibana 8.5 and later can also show a secondary
+// metric, a maximum and break the metric down by a bucket column
+type LensMetricVisualization struct {
+	LayerId                 string `json:"layerId"`
+	LayerType               string `json:"layerType,omitempty"`
+	MetricAccessor          string `json:"metricAccessor"`
+	SecondaryMetricAccessor string `json:"secondaryMetricAccessor,omitempty"`
+	MaxAccessor             string `json:"maxAccessor,omitempty"`
+	BreakdownByAccessor     string `json:"breakdownByAccessor,omitempty"`
+}
+
+// LensPieVisualization is a pie, donut or treemap chart of the metrics sliced by the group columns, kibana
+// versions before 8.6 show a single metric
+type LensPieVisualization struct {
+	Shape  LensPieShape    `json:"shape"`
+	Layers []*LensPieLayer `json:"layers"`
+}
+
+type LensPieLayer struct {
+	LayerId         string   `json:"layerId"`
+	LayerType       string   `json:"layerType,omitempty"`
+	PrimaryGroups   []string `json:"primaryGroups"`
+	Metrics         []string `json:"metrics"`
+	NumberDisplay   string   `json:"numberDisplay"`
+	CategoryDisplay string   `json:"categoryDisplay"`
+	LegendDisplay   string   `json:"legendDisplay"`
+	NestedLegend    bool     `json:"nestedLegend"`
+}
+
+// LensDatatableVisualization is a table of the columns of a layer
+type LensDatatableVisualization struct {
+	LayerId   string                 `json:"layerId"`
+	LayerType string                 `json:"layerType,omitempty"`
+	Columns   []*LensDatatableColumn `json:"columns"`
+}
+
+type LensDatatableColumn struct {
+	ColumnId     string `json:"columnId"`
+	IsTransposed bool   `json:"isTransposed,omitempty"`
+	Hidden       bool   `json:"hidden,omitempty"`
+	Width        int    `json:"width,omitempty"`
+}
+
+type lensStateJson struct {
+	DatasourceStates map[string]json.RawMessage `json:"datasourceStates"`
+	Visualization    json.RawMessage            `json:"visualization"`
+	Query            *SearchQuery600            `json:"query"`
+	Filters          []*SearchFilter            `json:"filters"`
+}
+
+type lensMetricVisualization700 struct {
+	LayerId   string `json:"layerId"`
+	LayerType string `json:"layerType,omitempty"`
+	Accessor  string `json:"accessor"`
+}
+
+// lensPieLayer800 hides the metrics of the layer, the nil Metrics field is left out
+type lensPieLayer800 struct {
+	*LensPieLayer
+	Metrics []string `json:"metrics,omitempty"`
+	Metric  string   `json:"metric"`
+}
+
+type lensPieLayer700 struct {
+	*LensPieLayer
+	PrimaryGroups []string `json:"primaryGroups,omitempty"`
+	Metrics       []string `json:"metrics,omitempty"`
+	Groups        []string `json:"groups"`
+	Metric        string   `json:"metric"`
+}
+
+type lensDatatableVisualization700 struct {
+	Layers []*lensDatatableLayer700 `json:"layers"`
+}
+
+type lensDatatableLayer700 struct {
+	LayerId string   `json:"layerId"`
+	Columns []string `json:"columns"`
+}
+
+var lensVisualizationFromType = map[LensVisualizationType]func() LensVisualization{
+	LensVisualizationTypeXY:        func() LensVisualization { return &LensXYVisualization{} },
+	LensVisualizationTypeMetric:    func() LensVisualization { return &LensMetricVisualization{} },
+	LensVisualizationTypePie:       func() LensVisualization { return &LensPieVisualization{} },
+	LensVisualizationTypeDatatable: func() LensVisualization { return &LensDatatableVisualization{} },
+}
+
+// NewLensLayer creates a layer without columns reading from the index pattern
+func NewLensLayer(indexPatternId string) *LensLayer {
+	return &LensLayer{IndexPatternId: indexPatternId, ColumnOrder: []string{}, Columns: map[string]*LensColumn{}}
+}
+
+// WithColumn adds the column after the columns already in the layer
+func (layer *LensLayer) WithColumn(columnId string, column *LensColumn) *LensLayer {
+	if _, ok := layer.Columns[columnId]; !ok {
+		layer.ColumnOrder = append(layer.ColumnOrder, columnId)
+	}
+
+	layer.Columns[columnId] = column
+	return layer
+}
+
+// NewLensCountColumn counts the documents
+func NewLensCountColumn() *LensColumn {
+	return &LensColumn{Label: "Count of records", DataType: "number", OperationType: LensOperationCount, Scale: "ratio"}
+}
+
+// NewLensMetricColumn computes the operation, such as sum or average, over the values of the field
+func NewLensMetricColumn(operation LensOperation, field string) *LensColumn {
+	return &LensColumn{
+		Label:         fmt.Sprintf("%s of %s", lensOperationLabel(operation), field),
+		DataType:      "number",
+		OperationType: operation,
+		SourceField:   field,
+		Scale:         "ratio",
+	}
+}
+
+// NewLensDateHistogramColumn buckets the documents by the date field, an interval of auto lets kibana pick the
+// interval from the time range
+func NewLensDateHistogramColumn(field string, interval string) *LensColumn {
+	return &LensColumn{
+		Label:         field,
+		DataType:      "date",
+		OperationType: LensOperationDateHistogram,
+		SourceField:   field,
+		IsBucketed:    true,
+		Scale:         "interval",
+		Params:        map[string]interface{}{"interval": interval},
+	}
+}
+
+// NewLensTermsColumn buckets the documents by the most frequent values of the field, ordered by the column
+// orderByColumnId or alphabetically when it is empty
+func NewLensTermsColumn(field string, size int, orderByColumnId string) *LensColumn {
+	orderBy := map[string]interface{}{"type": "alphabetical"}
+	orderDirection := "asc"
+	if orderByColumnId != "" {
+		orderBy = map[string]interface{}{"type": "column", "columnId": orderByColumnId}
+		orderDirection = "desc"
+	}
+
+	return &LensColumn{
+		Label:         fmt.Sprintf("Top values of %s", field),
+		DataType:      "string",
+		OperationType: LensOperationTerms,
+		SourceField:   field,
+		IsBucketed:    true,
+		Scale:         "ordinal",
+		Params:        map[string]interface{}{"size": size, "orderBy": orderBy, "orderDirection": orderDirection},
+	}
+}
+
+// WithLabel sets a custom label on the column
+func (column *LensColumn) WithLabel(label string) *LensColumn {
+	column.Label = label
+	column.CustomLabel = true
+	return column
+}
+
+// NewLensXYVisualization returns the defaults kibana uses for a new bar, line or area chart
+func NewLensXYVisualization(seriesType LensSeriesType) *LensXYVisualization {
+	return &LensXYVisualization{
+		Legend:              &LensLegend{IsVisible: true, Position: "right"},
+		ValueLabels:         "hide",
+		PreferredSeriesType: seriesType,
+		Layers:              []*LensXYLayer{},
+	}
+}
+
+// WithLayer draws the accessor columns of the layer against the x accessor column, series take the preferred
+// series type of the chart
+func (visualization *LensXYVisualization) WithLayer(layerId string, xAccessor string, splitAccessor string, accessors ...string) *LensXYVisualization {
+	visualization.Layers = append(visualization.Layers, &LensXYLayer{
+		LayerId:       layerId,
+		SeriesType:    visualization.PreferredSeriesType,
+		XAccessor:     xAccessor,
+		SplitAccessor: splitAccessor,
+		Accessors:     accessors,
+	})
+
+	return visualization
+}
+
+func NewLensMetricVisualization(layerId string, metricAccessor string) *LensMetricVisualization {
+	return &LensMetricVisualization{LayerId: layerId, MetricAccessor: metricAccessor}
+}
+
+// NewLensPieVisualization returns the defaults kibana uses for a new pie, donut or treemap chart of the layer
+func NewLensPieVisualization(shape LensPieShape, layerId string, groups []string, metrics ...string) *LensPieVisualization {
+	return &LensPieVisualization{
+		Shape: shape,
+		Layers: []*LensPieLayer{
+			{
+				LayerId:         layerId,
+				PrimaryGroups:   groups,
+				Metrics:         metrics,
+				NumberDisplay:   "percent",
+				CategoryDisplay: "default",
+				LegendDisplay:   "default",
+			},
+		},
+	}
+}
+
+// NewLensDatatableVisualization shows the columns of the layer in the given order
+func NewLensDatatableVisualization(layerId string, columnIds ...string) *LensDatatableVisualization {
+	visualization := &LensDatatableVisualization{LayerId: layerId, Columns: []*LensDatatableColumn{}}
+	for _, columnId := range columnIds {
+		visualization.Columns = append(visualization.Columns, &LensDatatableColumn{ColumnId: columnId})
+	}
+
+	return visualization
+}
+
+func (state *LensState) UnmarshalJSON(data []byte) error {
+	raw := &lensStateJson{}
+	if err := json.Unmarshal(data, raw); err != nil {
+		return err
+	}
+
+	*state = LensState{Query: raw.Query, Filters: raw.Filters}
+	for name, datasource := range raw.DatasourceStates {
+		if name != lensDatasourceFormBased && name != lensDatasourceIndexPattern {
+			if state.otherDatasources == nil {
+				state.otherDatasources = map[string]json.RawMessage{}
+			}
+
+			state.otherDatasources[name] = datasource
+			continue
+		}
+
+		if state.Datasource != nil && name == lensDatasourceIndexPattern {
+			continue
+		}
+
+		state.Datasource = &LensDatasourceState{}
+		if err := json.Unmarshal(datasource, state.Datasource); err != nil {
+			return fmt.Errorf("could not parse %s datasource of lens visualization, error: %v", name, err)
+		}
+	}
+
+	// the visualization is decoded by LensAttributes, which knows its type
+	if len(raw.Visualization) > 0 && string(raw.Visualization) != "null" {
+		visualization := RawLensVisualization{}
+		if err := json.Unmarshal(raw.Visualization, &visualization); err != nil {
+			return fmt.Errorf("could not parse lens visualization, error: %v", err)
+		}
+
+		state.Visualization = visualization
+	}
+
+	return nil
+}
+
+// stateForVersion encodes the state for the kibana version, the datasource is named formBased from kibana 8.6
+func (state *LensState) stateForVersion(version string) map[string]interface{} {
+	datasourceStates := map[string]interface{}{}
+	for name, datasource := range state.otherDatasources {
+		datasourceStates[name] = datasource
+	}
+
+	if state.Datasource != nil {
+		layers := map[string]interface{}{}
+		for layerId, layer := range state.Datasource.Layers {
+			layers[layerId] = layer.layerForVersion(version)
+		}
+
+		name := lensDatasourceFormBased
+		if goversion.Compare(version, "8.6.0", "<") {
+			name = lensDatasourceIndexPattern
+		}
+
+		datasourceStates[name] = map[string]interface{}{"layers": layers}
+	}
+
+	query := state.Query
+	if query == nil {
+		query = &SearchQuery600{Query: "", Language: QueryLanguageKuery}
+	}
+
+	filters := state.Filters
+	if filters == nil {
+		filters = []*SearchFilter{}
+	}
+
+	encoded := map[string]interface{}{
+		"datasourceStates": datasourceStates,
+		"query":            query,
+		"filters":          filters,
+	}
+
+	if state.Visualization != nil {
+		encoded["visualization"] = state.Visualization.visualizationForVersion(version)
+	}
+
+	return encoded
+}
+
+// MarshalJSON encodes the state for kibana 8.0, clients encode it for the version of kibana they call
+func (state *LensState) MarshalJSON() ([]byte, error) {
+	return json.Marshal(state.stateForVersion(DefaultKibanaVersion8))
+}
+
+// references returns the index patterns of the layers, kibana reads the index pattern of a layer from the
+// reference named after the layer
+func (state *LensState) references() []*SavedObjectReference {
+	references := []*SavedObjectReference{}
+	if state.Datasource == nil {
+		return references
+	}
+
+	var layerIds []string
+	for layerId := range state.Datasource.Layers {
+		layerIds = append(layerIds, layerId)
+	}
+
+	sort.Strings(layerIds)
+	for _, layerId := range layerIds {
+		indexPatternId := state.Datasource.Layers[layerId].IndexPatternId
+		if len(references) == 0 {
+			references = append(references, &SavedObjectReference{Name: lensCurrentIndexPatternReference, Type: "index-pattern", Id: indexPatternId})
+		}
+
+		references = append(references, &SavedObjectReference{Name: lensLayerReferencePrefix + layerId, Type: "index-pattern", Id: indexPatternId})
+	}
+
+	return references
+}
+
+func (state *LensState) resolveReferences(references []*SavedObjectReference) {
+	if state.Datasource == nil {
+		return
+	}
+
+	for layerId, layer := range state.Datasource.Layers {
+		for _, reference := range references {
+			if reference.Name == lensLayerReferencePrefix+layerId {
+				layer.IndexPatternId = reference.Id
+			}
+		}
+	}
+}
+
+// layerForVersion names the field of count columns Records before kibana 8.0 and ___records___ from 8.0
+func (layer *LensLayer) layerForVersion(version string) *LensLayer {
+	encoded := *layer
+	encoded.Columns = map[string]*LensColumn{}
+	for columnId, column := range layer.Columns {
+		if column.OperationType == LensOperationCount && (column.SourceField == "" || column.SourceField == "Records" || column.SourceField == "___records___") {
+			countColumn := *column
+			countColumn.SourceField = "___records___"
+			if goversion.Compare(version, "8.0.0", "<") {
+				countColumn.SourceField = "Records"
+			}
+
+			column = &countColumn
+		}
+
+		encoded.Columns[columnId] = column
+	}
+
+	if encoded.ColumnOrder == nil {
+		encoded.ColumnOrder = []string{}
+	}
+
+	return &encoded
+}
+
+func (visualization RawLensVisualization) visualizationType() LensVisualizationType {
+	return ""
+}
+
+func (visualization RawLensVisualization) visualizationForVersion(version string) interface{} {
+	return visualization
+}
+
+func (visualization RawLensVisualization) layerAccessors() map[string][]string {
+	return nil
+}
+
+func (visualization *LensXYVisualization) visualizationType() LensVisualizationType {
+	return LensVisualizationTypeXY
+}
+
+func (visualization *LensXYVisualization) visualizationForVersion(version string) interface{} {
+	encoded := *visualization
+	encoded.Layers = []*LensXYLayer{}
+	for _, layer := range visualization.Layers {
+		encodedLayer := *layer
+		encodedLayer.LayerType = lensLayerType(layer.LayerType, version)
+		if encodedLayer.Accessors == nil {
+			encodedLayer.Accessors = []string{}
+		}
+
+		encoded.Layers = append(encoded.Layers, &encodedLayer)
+	}
+
+	return &encoded
+}
+
+func (visualization *LensXYVisualization) layerAccessors() map[string][]string {
+	accessors := map[string][]string{}
+	for _, layer := range visualization.Layers {
+		accessors[layer.LayerId] = append(accessors[layer.LayerId], layer.Accessors...)
+		if layer.XAccessor != "" {
+			accessors[layer.LayerId] = append(accessors[layer.LayerId], layer.XAccessor)
+		}
+		if layer.SplitAccessor != "" {
+			accessors[layer.LayerId] = append(accessors[layer.LayerId], layer.SplitAccessor)
+		}
+	}
+
+	return accessors
+}
+
+func (visualization *LensMetricVisualization) visualizationType() LensVisualizationType {
+	return LensVisualizationTypeMetric
+}
+
+// visualizationForVersion encodes the single value metric of kibana versions before 8.5, which only shows the
+// metric accessor
+func (visualization *LensMetricVisualization) visualizationForVersion(version string) interface{} {
+	if goversion.Compare(version, "8.5.0", "<") {
+		return &lensMetricVisualization700{
+			LayerId:   visualization.LayerId,
+			LayerType: lensLayerType(visualization.LayerType, version),
+			Accessor:  visualization.MetricAccessor,
+		}
+	}
+
+	encoded := *visualization
+	encoded.LayerType = lensLayerType(visualization.LayerType, version)
+	return &encoded
+}
+
+func (visualization *LensMetricVisualization) layerAccessors() map[string][]string {
+	accessors := []string{}
+	for _, accessor := range []string{visualization.MetricAccessor, visualization.SecondaryMetricAccessor, visualization.MaxAccessor, visualization.BreakdownByAccessor} {
+		if accessor != "" {
+			accessors = append(accessors, accessor)
+		}
+	}
+
+	return map[string][]string{visualization.LayerId: accessors}
+}
+
+// UnmarshalJSON reads the metric accessor of both the single value metric and the metric of kibana 8.5
+func (visualization *LensMetricVisualization) UnmarshalJSON(data []byte) error {
+	type plainLensMetricVisualization LensMetricVisualization
+	var raw struct {
+		*plainLensMetricVisualization
+		Accessor string `json:"accessor"`
+	}
+
+	raw.plainLensMetricVisualization = (*plainLensMetricVisualization)(visualization)
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+
+	if visualization.MetricAccessor == "" {
+		visualization.MetricAccessor = raw.Accessor
+	}
+
+	return nil
+}
+
+func (visualization *LensPieVisualization) visualizationType() LensVisualizationType {
+	return LensVisualizationTypePie
+}
+
+// visualizationForVersion names the groups primaryGroups from kibana 8.1 and shows several metrics from 8.6
+func (visualization *LensPieVisualization) visualizationForVersion(version string) interface{} {
+	layers := []interface{}{}
+	for _, layer := range visualization.Layers {
+		encodedLayer := *layer
+		encodedLayer.LayerType = lensLayerType(layer.LayerType, version)
+		if encodedLayer.PrimaryGroups == nil {
+			encodedLayer.PrimaryGroups = []string{}
+		}
+		if encodedLayer.Metrics == nil {
+			encodedLayer.Metrics = []string{}
+		}
+
+		metric := ""
+		if len(encodedLayer.Metrics) > 0 {
+			metric = encodedLayer.Metrics[0]
+		}
+
+		switch {
+		case goversion.Compare(version, "8.1.0", "<"):
+			layers = append(layers, &lensPieLayer700{LensPieLayer: &encodedLayer, Groups: encodedLayer.PrimaryGroups, Metric: metric})
+		case goversion.Compare(version, "8.6.0", "<"):
+			layers = append(layers, &lensPieLayer800{LensPieLayer: &encodedLayer, Metric: metric})
+		default:
+			layers = append(layers, &encodedLayer)
+		}
+	}
+
+	return map[string]interface{}{"shape": visualization.Shape, "layers": layers}
+}
+
+func (visualization *LensPieVisualization) layerAccessors() map[string][]string {
+	accessors := map[string][]string{}
+	for _, layer := range visualization.Layers {
+		accessors[layer.LayerId] = append(append(accessors[layer.LayerId], layer.PrimaryGroups...), layer.Metrics...)
+	}
+
+	return accessors
+}
+
+// UnmarshalJSON reads the groups and metric of kibana versions before 8.6 as well
+func (layer *LensPieLayer) UnmarshalJSON(data []byte) error {
+	type plainLensPieLayer LensPieLayer
+	var raw struct {
+		*plainLensPieLayer
+		Groups []string `json:"groups"`
+		Metric string   `json:"metric"`
+	}
+
+	raw.plainLensPieLayer = (*plainLensPieLayer)(layer)
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+
+	if layer.PrimaryGroups == nil {
+		layer.PrimaryGroups = raw.Groups
+	}
+
+	if layer.Metrics == nil && raw.Metric != "" {
+		layer.Metrics = []string{raw.Metric}
+	}
+
+	return nil
+}
+
+func (visualization *LensDatatableVisualization) visualizationType() LensVisualizationType {
+	return LensVisualizationTypeDatatable
+}
+
+// visualizationForVersion encodes the table of kibana 7.10, which lists the column ids by layer
+func (visualization *LensDatatableVisualization) visualizationForVersion(version string) interface{} {
+	if goversion.Compare(version, "7.11.0", "<") {
+		layer := &lensDatatableLayer700{LayerId: visualization.LayerId, Columns: []string{}}
+		for _, column := range visualization.Columns {
+			layer.Columns = append(layer.Columns, column.ColumnId)
+		}
+
+		return &lensDatatableVisualization700{Layers: []*lensDatatableLayer700{layer}}
+	}
+
+	encoded := *visualization
+	encoded.LayerType = lensLayerType(visualization.LayerType, version)
+	if encoded.Columns == nil {
+		encoded.Columns = []*LensDatatableColumn{}
+	}
+
+	return &encoded
+}
+
+func (visualization *LensDatatableVisualization) layerAccessors() map[string][]string {
+	accessors := []string{}
+	for _, column := range visualization.Columns {
+		accessors = append(accessors, column.ColumnId)
+	}
+
+	return map[string][]string{visualization.LayerId: accessors}
+}
+
+// UnmarshalJSON reads the table of kibana 7.10 as well
+func (visualization *LensDatatableVisualization) UnmarshalJSON(data []byte) error {
+	type plainLensDatatableVisualization LensDatatableVisualization
+	var raw struct {
+		*plainLensDatatableVisualization
+		Layers []*lensDatatableLayer700 `json:"layers"`
+	}
+
+	raw.plainLensDatatableVisualization = (*plainLensDatatableVisualization)(visualization)
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+
+	if visualization.LayerId == "" && len(raw.Layers) > 0 {
+		visualization.LayerId = raw.Layers[0].LayerId
+		for _, columnId := range raw.Layers[0].Columns {
+			visualization.Columns = append(visualization.Columns, &LensDatatableColumn{ColumnId: columnId})
+		}
+	}
+
+	return nil
+}
+
+// lensLayerType is the type of a visualization layer, kibana 7.16 added reference line and annotation layers
+// and types the layers holding data as data
+func lensLayerType(layerType string, version string) string {
+	if goversion.Compare(version, "7.16.0", "<") {
+		return ""
+	}
+
+	if layerType == "" {
+		return lensLayerTypeData
+	}
+
+	return layerType
+}
+
+func lensOperationLabel(operation LensOperation) string {
+	switch operation {
+	case LensOperationSum:
+		return "Sum"
+	case LensOperationAverage:
+		return "Average"
+	case LensOperationMin:
+		return "Minimum"
+	case LensOperationMax:
+		return "Maximum"
+	case LensOperationMedian:
+		return "Median"
+	case LensOperationUniqueCount:
+		return "Unique count"
+	case LensOperationLastValue:
+		return "Last value"
+	}
+
+	return string(operation)
+}
@@ -0,0 +1,212 @@
+package kibana
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestLensRequestBuilder(indexPatternId string, visualization LensVisualization) *LensRequestBuilder {
+	layer := NewLensLayer(indexPatternId).
+		WithColumn("date", NewLensDateHistogramColumn("@timestamp", "auto")).
+		WithColumn("country", NewLensTermsColumn("geo.src", 5, "count")).
+		WithColumn("count", NewLensCountColumn())
+
+	return NewLensRequestBuilder().
+		WithTitle("Requests by country").
+		WithLayer("layer1", layer).
+		WithVisualization(visualization).
+		WithQuery("response >= 500", QueryLanguageKuery)
+}
+
+func Test_LensLifecycle(t *testing.T) {
+	for _, version := range []string{"7.10.2", "8.6.0"} {
+		t.Run(version, func(t *testing.T) {
+			fake := NewFakeKibana(version)
+			defer fake.Close()
+
+			client := NewClient(fake.Config())
+			lensApi := client.Lens()
+
+			request, err := newTestLensRequestBuilder("logs", NewLensXYVisualization(LensSeriesTypeBarStacked).
+				WithLayer("layer1", "date", "country", "count")).
+				Build()
+			require.NoError(t, err)
+
+			created, err := lensApi.Create(request)
+			require.NoError(t, err)
+			assert.Equal(t, "Requests by country", created.Attributes.Title)
+			assert.Equal(t, LensVisualizationTypeXY, created.Attributes.VisualizationType)
+			assert.Contains(t, created.References, &SavedObjectReference{Name: "indexpattern-datasource-layer-layer1", Type: "index-pattern", Id: "logs"})
+
+			lens, err := lensApi.GetById(created.Id)
+			require.NoError(t, err)
+			layer := lens.Attributes.State.Datasource.Layers["layer1"]
+			require.NotNil(t, layer)
+			assert.Equal(t, "logs", layer.IndexPatternId)
+			assert.Equal(t, []string{"date", "country", "count"}, layer.ColumnOrder)
+			assert.Equal(t, LensOperationTerms, layer.Columns["country"].OperationType)
+
+			xy, ok := lens.Attributes.State.Visualization.(*LensXYVisualization)
+			require.True(t, ok, "the visualization is decoded for its type")
+			require.Len(t, xy.Layers, 1)
+			assert.Equal(t, []string{"count"}, xy.Layers[0].Accessors)
+			assert.Equal(t, "country", xy.Layers[0].SplitAccessor)
+
+			lenses, err := lensApi.List()
+			require.NoError(t, err)
+			require.Len(t, lenses, 1)
+			assert.Equal(t, "logs", lenses[0].Attributes.State.Datasource.Layers["layer1"].IndexPatternId)
+
+			lens.Attributes.Title = "Errors by country"
+			xy.PreferredSeriesType = LensSeriesTypeLine
+			xy.Layers[0].SeriesType = LensSeriesTypeLine
+			updated, err := lensApi.Update(lens.Id, &UpdateLensRequest{Attributes: lens.Attributes})
+			require.NoError(t, err)
+			assert.Equal(t, "Errors by country", updated.Attributes.Title)
+			assert.Equal(t, LensSeriesTypeLine, updated.Attributes.State.Visualization.(*LensXYVisualization).Layers[0].SeriesType)
+
+			dashboardRequest, err := NewDashboardRequestBuilder().
+				WithTitle("Requests").
+				WithPanel(NewDashboardPanel(DashboardReferencesTypeLens, lens.Id, 0, 0, 24, 15)).
+				BuildForVersion(version)
+			require.NoError(t, err)
+
+			dashboard, err := client.Dashboard().Create(dashboardRequest)
+			require.NoError(t, err)
+
+			panels, err := dashboard.Panels()
+			require.NoError(t, err)
+			require.Len(t, panels, 1)
+			assert.Equal(t, DashboardReferencesTypeLens, panels[0].Type)
+			assert.Equal(t, lens.Id, panels[0].Id)
+
+			require.NoError(t, lensApi.Delete(lens.Id))
+			_, err = lensApi.GetById(lens.Id)
+			assertNotFound(t, err)
+
+			_, err = lensApi.Update(lens.Id, &UpdateLensRequest{Attributes: lens.Attributes})
+			assertNotFound(t, err)
+		})
+	}
+}
+
+func Test_Lens_not_supported_before_7_10(t *testing.T) {
+	fake := NewFakeKibana(DefaultKibanaVersion7)
+	defer fake.Close()
+
+	request, err := newTestLensRequestBuilder("logs", NewLensMetricVisualization("layer1", "count")).Build()
+	require.NoError(t, err)
+
+	lensApi := NewClient(fake.Config()).Lens()
+	_, err = lensApi.Create(request)
+	assert.EqualError(t, err, "lens visualizations are not supported by kibana 7.3.1, they require kibana 7.10 or later")
+
+	_, err = lensApi.Update("lens", &UpdateLensRequest{Attributes: request.Attributes})
+	assert.EqualError(t, err, "lens visualizations are not supported by kibana 7.3.1, they require kibana 7.10 or later")
+}
+
+func Test_LensAttributes_for_version(t *testing.T) {
+	encode := func(visualization LensVisualization, version string) map[string]interface{} {
+		request, err := newTestLensRequestBuilder("logs", visualization).Build()
+		require.NoError(t, err)
+
+		attributes, err := request.Attributes.forVersion(version)
+		require.NoError(t, err)
+
+		data, err := json.Marshal(attributes)
+		require.NoError(t, err)
+
+		var encoded map[string]interface{}
+		require.NoError(t, json.Unmarshal(data, &encoded))
+		return encoded["state"].(map[string]interface{})
+	}
+
+	visualization := func(state map[string]interface{}) string {
+		data, err := json.Marshal(state["visualization"])
+		require.NoError(t, err)
+		return string(data)
+	}
+
+	state := encode(NewLensMetricVisualization("layer1", "count"), "7.10.2")
+	assert.Contains(t, state["datasourceStates"], "indexpattern")
+	assert.JSONEq(t, `{"layerId":"layer1","accessor":"count"}`, visualization(state))
+	countColumn := state["datasourceStates"].(map[string]interface{})["indexpattern"].(map[string]interface{})["layers"].(map[string]interface{})["layer1"].(map[string]interface{})["columns"].(map[string]interface{})["count"].(map[string]interface{})
+	assert.Equal(t, "Records", countColumn["sourceField"])
+
+	state = encode(NewLensMetricVisualization("layer1", "count"), "8.6.0")
+	assert.Contains(t, state["datasourceStates"], "formBased")
+	assert.JSONEq(t, `{"layerId":"layer1","layerType":"data","metricAccessor":"count"}`, visualization(state))
+	countColumn = state["datasourceStates"].(map[string]interface{})["formBased"].(map[string]interface{})["layers"].(map[string]interface{})["layer1"].(map[string]interface{})["columns"].(map[string]interface{})["count"].(map[string]interface{})
+	assert.Equal(t, "___records___", countColumn["sourceField"])
+
+	pie := NewLensPieVisualization(LensPieShapeDonut, "layer1", []string{"country"}, "count")
+	assert.JSONEq(t, `{"shape":"donut","layers":[{"layerId":"layer1","groups":["country"],"metric":"count","numberDisplay":"percent","categoryDisplay":"default","legendDisplay":"default","nestedLegend":false}]}`,
+		visualization(encode(pie, "7.10.2")))
+	assert.JSONEq(t, `{"shape":"donut","layers":[{"layerId":"layer1","layerType":"data","primaryGroups":["country"],"metric":"count","numberDisplay":"percent","categoryDisplay":"default","legendDisplay":"default","nestedLegend":false}]}`,
+		visualization(encode(pie, "8.2.0")))
+	assert.JSONEq(t, `{"shape":"donut","layers":[{"layerId":"layer1","layerType":"data","primaryGroups":["country"],"metrics":["count"],"numberDisplay":"percent","categoryDisplay":"default","legendDisplay":"default","nestedLegend":false}]}`,
+		visualization(encode(pie, "8.6.0")))
+
+	table := NewLensDatatableVisualization("layer1", "country", "count")
+	assert.JSONEq(t, `{"layers":[{"layerId":"layer1","columns":["country","count"]}]}`, visualization(encode(table, "7.10.2")))
+	assert.JSONEq(t, `{"layerId":"layer1","columns":[{"columnId":"country"},{"columnId":"count"}]}`, visualization(encode(table, "7.12.0")))
+}
+
+func Test_LensAttributes_decode(t *testing.T) {
+	data := `{
+		"title": "Bytes",
+		"description": "",
+		"visualizationType": "lnsMetric",
+		"state": {
+			"datasourceStates": {
+				"indexpattern": {"layers": {"layer1": {"columnOrder": ["sum"], "columns": {"sum": {"label": "Sum of bytes", "dataType": "number", "operationType": "sum", "sourceField": "bytes", "isBucketed": false, "scale": "ratio"}}}}},
+				"textBased": {"layers": {}}
+			},
+			"visualization": {"layerId": "layer1", "accessor": "sum"},
+			"query": {"query": "", "language": "kuery"},
+			"filters": []
+		}
+	}`
+
+	attributes := &LensAttributes{}
+	require.NoError(t, json.Unmarshal([]byte(data), attributes))
+	assert.Equal(t, &LensMetricVisualization{LayerId: "layer1", MetricAccessor: "sum"}, attributes.State.Visualization)
+	assert.Equal(t, NewLensMetricColumn(LensOperationSum, "bytes"), attributes.State.Datasource.Layers["layer1"].Columns["sum"])
+
+	encoded, err := attributes.forVersion("7.10.2")
+	require.NoError(t, err)
+	encodedData, err := json.Marshal(encoded)
+	require.NoError(t, err)
+	assert.JSONEq(t, data, string(encodedData), "a decoded lens visualization is encoded again unchanged")
+
+	attributes = &LensAttributes{}
+	require.NoError(t, json.Unmarshal([]byte(`{"title":"Gauge","visualizationType":"lnsGauge","state":{"visualization":{"shape":"arc"}}}`), attributes))
+	assert.Equal(t, RawLensVisualization{"shape": "arc"}, attributes.State.Visualization)
+
+	encoded, err = attributes.forVersion("8.6.0")
+	require.NoError(t, err)
+	assert.Equal(t, LensVisualizationType("lnsGauge"), encoded.(map[string]interface{})["visualizationType"], "visualizations without a typed model keep their type")
+}
+
+func Test_LensRequestBuilder_validation(t *testing.T) {
+	_, err := NewLensRequestBuilder().WithVisualization(NewLensMetricVisualization("layer1", "count")).Build()
+	assert.EqualError(t, err, "lens title is required")
+
+	_, err = newTestLensRequestBuilder("logs", nil).Build()
+	assert.EqualError(t, err, "lens visualization is required")
+
+	_, err = newTestLensRequestBuilder("", NewLensMetricVisualization("layer1", "count")).Build()
+	assert.EqualError(t, err, "invalid lens layer layer1, the layer has no index pattern")
+
+	_, err = newTestLensRequestBuilder("logs", NewLensMetricVisualization("layer2", "count")).Build()
+	assert.EqualError(t, err, "invalid lens visualization, layer layer2 does not exist")
+
+	_, err = newTestLensRequestBuilder("logs", NewLensMetricVisualization("layer1", "bytes")).Build()
+	assert.EqualError(t, err, "invalid lens visualization, column bytes does not exist in layer layer1")
+
+	_, err = newTestLensRequestBuilder("logs", NewLensMetricVisualization("layer1", "count")).WithQuery("response:(500", QueryLanguageKuery).Build()
+	assert.Error(t, err, "invalid kql is rejected")
+}
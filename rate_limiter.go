@@ -0,0 +1,111 @@
+package kibana
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// RateLimiter throttles the requests sent by the clients of a kibana client with a token bucket which is refilled
+// at requestsPerSecond up to burst tokens, and optionally caps the number of requests in flight. A request waits
+// until it can be sent or its context, set with KibanaClient.WithContext, is done.
+type RateLimiter struct {
+	requestsPerSecond float64
+	burst             float64
+	tokens            float64
+	last              time.Time
+	mutex             sync.Mutex
+	inFlight          chan struct{}
+}
+
+// NewRateLimiter creates a limiter allowing requestsPerSecond requests with bursts of up to burst requests,
+// a requestsPerSecond of zero or less disables the rate limit so only the in flight cap applies
+func NewRateLimiter(requestsPerSecond float64, burst int) *RateLimiter {
+	if burst < 1 {
+		burst = 1
+	}
+
+	return &RateLimiter{
+		requestsPerSecond: requestsPerSecond,
+		burst:             float64(burst),
+		tokens:            float64(burst),
+		last:              time.Now(),
+	}
+}
+
+// WithMaxInFlight caps the number of requests which can be waiting for a response at the same time
+func (limiter *RateLimiter) WithMaxInFlight(maxInFlight int) *RateLimiter {
+	if maxInFlight > 0 {
+		limiter.inFlight = make(chan struct{}, maxInFlight)
+	} else {
+		limiter.inFlight = nil
+	}
+
+	return limiter
+}
+
+// Wait blocks until a request can be sent and returns the function to call once its response has been received,
+// it returns the error of the context when the context is done first. The request takes a token before waiting
+// for a slot in flight, so requests waiting for a token do not hold a slot.
+func (limiter *RateLimiter) Wait(ctx context.Context) (func(), error) {
+	if err := limiter.take(ctx); err != nil {
+		return nil, err
+	}
+
+	inFlight := limiter.inFlight
+	if inFlight == nil {
+		return func() {}, nil
+	}
+
+	select {
+	case inFlight <- struct{}{}:
+		return func() { <-inFlight }, nil
+	case <-ctx.Done():
+		limiter.refund()
+		return nil, ctx.Err()
+	}
+}
+
+func (limiter *RateLimiter) take(ctx context.Context) error {
+	if limiter.requestsPerSecond <= 0 {
+		return ctx.Err()
+	}
+
+	limiter.mutex.Lock()
+	now := time.Now()
+	limiter.tokens += now.Sub(limiter.last).Seconds() * limiter.requestsPerSecond
+	if limiter.tokens > limiter.burst {
+		limiter.tokens = limiter.burst
+	}
+
+	limiter.last = now
+	limiter.tokens--
+	delay := time.Duration(-limiter.tokens / limiter.requestsPerSecond * float64(time.Second))
+	limiter.mutex.Unlock()
+
+	if delay <= 0 {
+		return nil
+	}
+
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		limiter.refund()
+		return ctx.Err()
+	}
+}
+
+// refund returns the token of a request which was not sent
+func (limiter *RateLimiter) refund() {
+	if limiter.requestsPerSecond <= 0 {
+		return
+	}
+
+	limiter.mutex.Lock()
+	limiter.tokens++
+	limiter.mutex.Unlock()
+}
@@ -0,0 +1,144 @@
+package kibana
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_RateLimiter_allows_burst_then_waits(t *testing.T) {
+	limiter := NewRateLimiter(20, 2)
+
+	start := time.Now()
+	for i := 0; i < 3; i++ {
+		release, err := limiter.Wait(context.Background())
+		require.NoError(t, err)
+		release()
+	}
+
+	elapsed := time.Since(start)
+	assert.True(t, elapsed >= 40*time.Millisecond, "The third request should wait for a token, waited %s", elapsed)
+}
+
+func Test_RateLimiter_wait_honors_context_cancellation(t *testing.T) {
+	limiter := NewRateLimiter(0.1, 1)
+	release, err := limiter.Wait(context.Background())
+	require.NoError(t, err)
+	release()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+
+	_, err = limiter.Wait(ctx)
+	assert.Equal(t, context.DeadlineExceeded, err)
+}
+
+func Test_RateLimiter_caps_requests_in_flight(t *testing.T) {
+	limiter := NewRateLimiter(0, 0).WithMaxInFlight(2)
+
+	var inFlight, maxInFlight int32
+	group := sync.WaitGroup{}
+	for i := 0; i < 6; i++ {
+		group.Add(1)
+		go func() {
+			defer group.Done()
+			release, err := limiter.Wait(context.Background())
+			require.NoError(t, err)
+			defer release()
+
+			current := atomic.AddInt32(&inFlight, 1)
+			for {
+				max := atomic.LoadInt32(&maxInFlight)
+				if current <= max || atomic.CompareAndSwapInt32(&maxInFlight, max, current) {
+					break
+				}
+			}
+
+			time.Sleep(10 * time.Millisecond)
+			atomic.AddInt32(&inFlight, -1)
+		}()
+	}
+
+	group.Wait()
+	assert.Equal(t, int32(2), maxInFlight)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	first, err := limiter.Wait(ctx)
+	require.NoError(t, err)
+	second, err := limiter.Wait(ctx)
+	require.NoError(t, err)
+	cancel()
+
+	_, err = limiter.Wait(ctx)
+	assert.Equal(t, context.Canceled, err)
+	first()
+	second()
+}
+
+func Test_RateLimiter_is_shared_by_derived_clients(t *testing.T) {
+	fake := NewFakeKibana(DefaultKibanaVersion7)
+	defer fake.Close()
+
+	hook := &testRequestHook{}
+	client := NewClient(fake.Config()).
+		AddHook(hook).
+		SetRateLimiter(NewRateLimiter(0.1, 1))
+
+	_, err := client.Space().GetByID("default")
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+
+	_, err = client.WithContext(ctx).Dashboard().GetById("missing")
+	assert.Equal(t, context.DeadlineExceeded, err)
+	require.Len(t, hook.requests, 1, "The throttled request should not have been sent")
+	assert.Equal(t, http.MethodGet, hook.requests[0].Method)
+}
+
+func Test_RateLimiter_takes_token_before_slot_in_flight(t *testing.T) {
+	limiter := NewRateLimiter(0.1, 1).WithMaxInFlight(1)
+	release, err := limiter.Wait(context.Background())
+	require.NoError(t, err)
+	release()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	done := make(chan error)
+	go func() {
+		_, err := limiter.Wait(ctx)
+		done <- err
+	}()
+
+	time.Sleep(10 * time.Millisecond)
+	assert.Len(t, limiter.inFlight, 0, "A request waiting for a token should not hold a slot in flight")
+	assert.Equal(t, context.DeadlineExceeded, <-done)
+}
+
+func Test_RateLimiter_and_hooks_set_after_WithContext_apply_to_derived_clients(t *testing.T) {
+	fake := NewFakeKibana(DefaultKibanaVersion7)
+	defer fake.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+
+	client := NewClient(fake.Config())
+	derived := client.WithContext(ctx)
+
+	hook := &testRequestHook{}
+	client.AddHook(hook).SetRateLimiter(NewRateLimiter(0.1, 1))
+
+	_, err := derived.Space().GetByID("default")
+	require.NoError(t, err)
+	require.Len(t, hook.requests, 1, "The hook should see the requests of the derived client")
+
+	_, err = derived.Dashboard().GetById("missing")
+	assert.Equal(t, context.DeadlineExceeded, err, "The rate limiter should throttle the derived client")
+}
@@ -0,0 +1,297 @@
+package kibana
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+)
+
+const redactedValue = "[REDACTED]"
+
+const (
+	RecorderModeUnknown RecorderMode = iota
+	RecorderModeRecord
+	RecorderModeReplay
+)
+
+type RecorderMode int
+
+var recorderModeNames = map[string]RecorderMode{
+	"record": RecorderModeRecord,
+	"replay": RecorderModeReplay,
+}
+
+// DefaultRedactedHeaders are the headers which are always removed from recorded interactions
+var DefaultRedactedHeaders = []string{
+	"Authorization",
+	"Cookie",
+	"Set-Cookie",
+	"X-Auth-Token",
+	"X-Logz-Csrf-Token",
+	"X-Logz-Csrf-Token-V2",
+}
+
+var uuidPattern = regexp.MustCompile(`[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}`)
+
+// Recorder captures the http interactions of a client to a cassette file, or replays them from a previously
+// recorded cassette without talking to kibana. In replay mode a request is matched by method, path, query
+// and body, ignoring the host and any uuids, and a request without a recorded interaction fails.
+type Recorder struct {
+	Cassette        *Cassette
+	RedactHeaders   []string
+	RedactedSecrets []string
+
+	mode  RecorderMode
+	path  string
+	mutex sync.Mutex
+	used  []bool
+}
+
+// Cassette is the on disk format of the recorded interactions, values can be used to store state
+// from the recording session which is needed to replay it, i.e. generated ids
+type Cassette struct {
+	Values       map[string]string      `json:"values,omitempty"`
+	Interactions []*CassetteInteraction `json:"interactions"`
+}
+
+type CassetteInteraction struct {
+	Request  *CassetteRequest  `json:"request"`
+	Response *CassetteResponse `json:"response"`
+}
+
+type CassetteRequest struct {
+	Method  string      `json:"method"`
+	Url     string      `json:"url"`
+	Headers http.Header `json:"headers,omitempty"`
+	Body    string      `json:"body,omitempty"`
+}
+
+type CassetteResponse struct {
+	StatusCode int         `json:"status_code"`
+	Headers    http.Header `json:"headers,omitempty"`
+	Body       string      `json:"body,omitempty"`
+}
+
+type recorderRoundTripper struct {
+	recorder *Recorder
+	next     http.RoundTripper
+}
+
+func ParseRecorderMode(value string) RecorderMode {
+	mode, ok := recorderModeNames[strings.ToLower(value)]
+
+	if !ok {
+		return RecorderModeUnknown
+	}
+
+	return mode
+}
+
+// NewRecorder creates a recorder for the cassette at path, in replay mode the cassette must already exist
+func NewRecorder(path string, mode RecorderMode) (*Recorder, error) {
+	recorder := &Recorder{
+		Cassette:      &Cassette{Values: map[string]string{}},
+		RedactHeaders: DefaultRedactedHeaders,
+		mode:          mode,
+		path:          path,
+	}
+
+	switch mode {
+	case RecorderModeRecord:
+		return recorder, nil
+	case RecorderModeReplay:
+		data, err := ioutil.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("could not read cassette %s, error: %v", path, err)
+		}
+
+		if err := json.Unmarshal(data, recorder.Cassette); err != nil {
+			return nil, fmt.Errorf("could not parse cassette %s, error: %v", path, err)
+		}
+
+		recorder.used = make([]bool, len(recorder.Cassette.Interactions))
+		return recorder, nil
+	default:
+		return nil, fmt.Errorf("unknown recorder mode %d", mode)
+	}
+}
+
+func (recorder *Recorder) Mode() RecorderMode {
+	return recorder.mode
+}
+
+// Stop saves the recorded interactions to the cassette file, it does nothing when replaying
+func (recorder *Recorder) Stop() error {
+	if recorder.mode != RecorderModeRecord {
+		return nil
+	}
+
+	recorder.mutex.Lock()
+	defer recorder.mutex.Unlock()
+
+	data, err := json.MarshalIndent(recorder.Cassette, "", "  ")
+	if err != nil {
+		return fmt.Errorf("could not marshal cassette %s, error: %v", recorder.path, err)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(recorder.path), 0755); err != nil {
+		return fmt.Errorf("could not create cassette directory for %s, error: %v", recorder.path, err)
+	}
+
+	return ioutil.WriteFile(recorder.path, data, 0644)
+}
+
+func (recorder *Recorder) roundTripper(next http.RoundTripper) http.RoundTripper {
+	return &recorderRoundTripper{recorder: recorder, next: next}
+}
+
+func (transport *recorderRoundTripper) RoundTrip(request *http.Request) (*http.Response, error) {
+	requestBody, err := readBody(&request.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	if transport.recorder.mode == RecorderModeReplay {
+		return transport.recorder.replay(request, requestBody)
+	}
+
+	response, err := transport.next.RoundTrip(request)
+	if err != nil {
+		return nil, err
+	}
+
+	responseBody, err := readBody(&response.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	transport.recorder.record(request, requestBody, response, responseBody)
+	return response, nil
+}
+
+func (recorder *Recorder) record(request *http.Request, requestBody string, response *http.Response, responseBody string) {
+	recorder.mutex.Lock()
+	defer recorder.mutex.Unlock()
+
+	recorder.Cassette.Interactions = append(recorder.Cassette.Interactions, &CassetteInteraction{
+		Request: &CassetteRequest{
+			Method:  request.Method,
+			Url:     recorder.redact(request.URL.String()),
+			Headers: recorder.redactHeaders(request.Header),
+			Body:    recorder.redact(requestBody),
+		},
+		Response: &CassetteResponse{
+			StatusCode: response.StatusCode,
+			Headers:    recorder.redactHeaders(response.Header),
+			Body:       recorder.redact(responseBody),
+		},
+	})
+}
+
+func (recorder *Recorder) replay(request *http.Request, requestBody string) (*http.Response, error) {
+	recorder.mutex.Lock()
+	defer recorder.mutex.Unlock()
+
+	key := interactionKey(request.Method, request.URL.String(), recorder.redact(requestBody))
+	for index, interaction := range recorder.Cassette.Interactions {
+		if recorder.used[index] || !matchesRedacted(interactionKey(interaction.Request.Method, interaction.Request.Url, interaction.Request.Body), key) {
+			continue
+		}
+
+		recorder.used[index] = true
+		return &http.Response{
+			Status:        fmt.Sprintf("%d %s", interaction.Response.StatusCode, http.StatusText(interaction.Response.StatusCode)),
+			StatusCode:    interaction.Response.StatusCode,
+			Proto:         "HTTP/1.1",
+			ProtoMajor:    1,
+			ProtoMinor:    1,
+			Header:        interaction.Response.Headers,
+			Body:          ioutil.NopCloser(strings.NewReader(interaction.Response.Body)),
+			ContentLength: int64(len(interaction.Response.Body)),
+			Request:       request,
+		}, nil
+	}
+
+	return nil, fmt.Errorf("cassette %s has no recorded interaction for %s %s", recorder.path, request.Method, request.URL.String())
+}
+
+func (recorder *Recorder) redact(value string) string {
+	for _, secret := range recorder.RedactedSecrets {
+		if secret != "" {
+			value = strings.Replace(value, secret, redactedValue, -1)
+		}
+	}
+
+	return value
+}
+
+func (recorder *Recorder) redactHeaders(headers http.Header) http.Header {
+	result := RedactHeaders(headers, recorder.RedactHeaders)
+	for _, values := range result {
+		for index, value := range values {
+			values[index] = recorder.redact(value)
+		}
+	}
+
+	return result
+}
+
+// interactionKey identifies a request independently of the host it was sent to and the uuids it contains, so
+// objects created with random ids and a kibana running on a different port still match
+func interactionKey(method string, rawUrl string, body string) string {
+	target := rawUrl
+	if parsed, err := url.Parse(rawUrl); err == nil {
+		target = parsed.Path + "?" + parsed.Query().Encode()
+	}
+
+	return method + " " + uuidPattern.ReplaceAllString(target, "{uuid}") + " " + uuidPattern.ReplaceAllString(body, "{uuid}")
+}
+
+// matchesRedacted compares a recorded key with the key of a request, the secrets removed from the recording
+// are unknown when replaying so any redacted value in the recorded key matches any text
+func matchesRedacted(recorded string, key string) bool {
+	parts := strings.Split(recorded, redactedValue)
+	if len(parts) == 1 {
+		return recorded == key
+	}
+
+	if !strings.HasPrefix(key, parts[0]) || !strings.HasSuffix(key[len(parts[0]):], parts[len(parts)-1]) {
+		return false
+	}
+
+	remaining := key[len(parts[0]) : len(key)-len(parts[len(parts)-1])]
+	for _, part := range parts[1 : len(parts)-1] {
+		index := strings.Index(remaining, part)
+		if index < 0 {
+			return false
+		}
+
+		remaining = remaining[index+len(part):]
+	}
+
+	return true
+}
+
+func readBody(body *io.ReadCloser) (string, error) {
+	if *body == nil {
+		return "", nil
+	}
+
+	data, err := ioutil.ReadAll(*body)
+	(*body).Close()
+	if err != nil {
+		return "", err
+	}
+
+	*body = ioutil.NopCloser(bytes.NewReader(data))
+	return string(data), nil
+}
@@ -0,0 +1,80 @@
+package kibana
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_ParseRecorderMode(t *testing.T) {
+	assert.Equal(t, RecorderModeRecord, ParseRecorderMode("record"))
+	assert.Equal(t, RecorderModeReplay, ParseRecorderMode("Replay"))
+	assert.Equal(t, RecorderModeUnknown, ParseRecorderMode("rewind"))
+}
+
+func Test_Recorder_record_and_replay(t *testing.T) {
+	dir, err := ioutil.TempDir("", "cassettes")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	cassette := filepath.Join(dir, "kibana.json")
+
+	fake := NewFakeKibana(DefaultKibanaVersion7)
+	recorder, err := NewRecorder(cassette, RecorderModeRecord)
+	require.NoError(t, err)
+	recorder.RedactedSecrets = []string{"s3cr3t"}
+
+	client := NewClient(fake.Config()).
+		SetAuth(NewBasicAuthentication("elastic", "s3cr3t")).
+		SetRecorder(recorder)
+	request, _, err := createSearchRequest(client.Search(), "s3cr3t-index", t)
+	require.NoError(t, err)
+
+	recorded, err := client.Search().Create(request)
+	require.NoError(t, err)
+	require.NoError(t, recorder.Stop())
+	fake.Close()
+
+	data, err := ioutil.ReadFile(cassette)
+	require.NoError(t, err)
+	assert.NotContains(t, string(data), "s3cr3t")
+	assert.Contains(t, string(data), redactedValue)
+
+	replayer, err := NewRecorder(cassette, RecorderModeReplay)
+	require.NoError(t, err)
+	replayClient := NewClient(fake.Config()).SetRecorder(replayer)
+
+	replayed, err := replayClient.Search().Create(request)
+	require.NoError(t, err)
+	assert.Equal(t, recorded.Id, replayed.Id)
+	assert.Equal(t, recorded.Attributes.Title, replayed.Attributes.Title)
+
+	_, err = replayClient.Search().Create(request)
+	require.Error(t, err, "Each recorded interaction should only be replayed once")
+	assert.True(t, strings.Contains(err.Error(), "no recorded interaction"), err.Error())
+}
+
+func Test_Recorder_replay_ignores_host_and_uuids(t *testing.T) {
+	assert.Equal(t,
+		interactionKey("GET", "http://localhost:5601/api/saved_objects/search/aca8b340-175b-11e8-accb-65182aaf9591", ""),
+		interactionKey("GET", "http://127.0.0.1:32768/api/saved_objects/search/bc8a1970-175b-11e8-accb-65182aaf9591", ""))
+	assert.NotEqual(t,
+		interactionKey("GET", "http://localhost:5601/api/saved_objects/search/1", ""),
+		interactionKey("DELETE", "http://localhost:5601/api/saved_objects/search/1", ""))
+}
+
+func Test_Recorder_replay_requires_cassette(t *testing.T) {
+	_, err := NewRecorder(filepath.Join("testdata", "missing.json"), RecorderModeReplay)
+	assert.Error(t, err)
+}
+
+func Test_Recorder_redacted_values_match_any_text(t *testing.T) {
+	assert.True(t, matchesRedacted(`POST /api/role {"password":"[REDACTED]"}`, `POST /api/role {"password":"s3cr3t"}`))
+	assert.True(t, matchesRedacted(`[REDACTED] and [REDACTED]`, `one and two`))
+	assert.False(t, matchesRedacted(`POST /api/role {"password":"[REDACTED]"}`, `POST /api/space {"password":"s3cr3t"}`))
+	assert.False(t, matchesRedacted(`GET /api/status`, `GET /api/status/`))
+}
@@ -0,0 +1,380 @@
+package kibana
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+const (
+	searchSourceIndexRefName       = "kibanaSavedObjectMeta.searchSourceJSON.index"
+	searchSourceFilterIndexRefName = "kibanaSavedObjectMeta.searchSourceJSON.filter[%d].meta.index"
+)
+
+// ReferenceResolver checks that the saved objects referred to by dashboards and visualizations exist and
+// rewrites the ids of referred saved objects, for example when copying saved objects to another kibana
+type ReferenceResolver struct {
+	client   SavedObjectsClient
+	mappings map[string]string
+	exists   map[string]bool
+}
+
+func NewReferenceResolver(client SavedObjectsClient) *ReferenceResolver {
+	return &ReferenceResolver{
+		client:   client,
+		mappings: map[string]string{},
+		exists:   map[string]bool{},
+	}
+}
+
+// WithIdMapping rewrites references to the saved object of the given type from one id to another
+func (resolver *ReferenceResolver) WithIdMapping(objectType string, fromId string, toId string) *ReferenceResolver {
+	resolver.mappings[referenceKey(objectType, fromId)] = toId
+	return resolver
+}
+
+// ValidateDashboard returns the references of the dashboard to saved objects which do not exist, references
+// named by a panel or filter but missing from the dashboard references are returned with an empty id
+func (resolver *ReferenceResolver) ValidateDashboard(dashboard *Dashboard) ([]*SavedObjectReference, error) {
+	references, err := dashboard.SavedObjectReferences()
+	if err != nil {
+		return nil, err
+	}
+
+	return resolver.dangling(references)
+}
+
+// ValidateVisualization returns the references of the visualization to saved objects which do not exist,
+// references named by the visualization but missing from its references are returned with an empty id
+func (resolver *ReferenceResolver) ValidateVisualization(visualization *Visualization) ([]*SavedObjectReference, error) {
+	references, err := visualization.SavedObjectReferences()
+	if err != nil {
+		return nil, err
+	}
+
+	return resolver.dangling(references)
+}
+
+// RewriteDashboard returns a copy of the dashboard referring to the mapped ids
+func (resolver *ReferenceResolver) RewriteDashboard(dashboard *Dashboard) (*Dashboard, error) {
+	rewritten := *dashboard
+	attributes := *dashboard.Attributes
+	rewritten.Attributes = &attributes
+
+	rewritten.References = nil
+	for _, reference := range dashboard.References {
+		rewritten.References = append(rewritten.References, &DashboardReferences{
+			Name: reference.Name,
+			Type: reference.Type,
+			Id:   resolver.mappedId(reference.Type.String(), reference.Id),
+		})
+	}
+
+	panelsJson, err := resolver.rewritePanelsJson(attributes.PanelsJson)
+	if err != nil {
+		return nil, err
+	}
+
+	attributes.PanelsJson = panelsJson
+	if attributes.KibanaSavedObjectMeta != nil {
+		searchSourceJson, err := resolver.rewriteSearchSourceJson(attributes.KibanaSavedObjectMeta.SearchSourceJSON)
+		if err != nil {
+			return nil, err
+		}
+
+		attributes.KibanaSavedObjectMeta = &SearchKibanaSavedObjectMeta{SearchSourceJSON: searchSourceJson}
+	}
+
+	return &rewritten, nil
+}
+
+// RewriteVisualization returns a copy of the visualization referring to the mapped ids
+func (resolver *ReferenceResolver) RewriteVisualization(visualization *Visualization) (*Visualization, error) {
+	rewritten := *visualization
+	attributes := *visualization.Attributes
+	rewritten.Attributes = &attributes
+
+	rewritten.References = nil
+	for _, reference := range visualization.References {
+		rewritten.References = append(rewritten.References, &VisualizationReferences{
+			Name: reference.Name,
+			Type: reference.Type,
+			Id:   resolver.mappedId(reference.Type.String(), reference.Id),
+		})
+	}
+
+	if attributes.SavedSearchId != "" {
+		attributes.SavedSearchId = resolver.mappedId(VisualizationReferencesTypeSearch.String(), attributes.SavedSearchId)
+	}
+
+	if attributes.KibanaSavedObjectMeta != nil {
+		searchSourceJson, err := resolver.rewriteSearchSourceJson(attributes.KibanaSavedObjectMeta.SearchSourceJSON)
+		if err != nil {
+			return nil, err
+		}
+
+		attributes.KibanaSavedObjectMeta = &SearchKibanaSavedObjectMeta{SearchSourceJSON: searchSourceJson}
+	}
+
+	return &rewritten, nil
+}
+
+// RewriteSavedObject returns a copy of the saved object referring to the mapped ids, the ids embedded in the
+// attributes of saved objects created before 7.0 are rewritten as well
+func (resolver *ReferenceResolver) RewriteSavedObject(object *SavedObject) (*SavedObject, error) {
+	rewritten := *object
+	rewritten.Attributes = map[string]interface{}{}
+	for name, value := range object.Attributes {
+		rewritten.Attributes[name] = value
+	}
+
+	rewritten.References = nil
+	for _, reference := range object.References {
+		rewritten.References = append(rewritten.References, &SavedObjectReference{
+			Name: reference.Name,
+			Type: reference.Type,
+			Id:   resolver.mappedId(reference.Type, reference.Id),
+		})
+	}
+
+	if panelsJson, ok := rewritten.Attributes["panelsJSON"].(string); ok {
+		panelsJson, err := resolver.rewritePanelsJson(panelsJson)
+		if err != nil {
+			return nil, err
+		}
+
+		rewritten.Attributes["panelsJSON"] = panelsJson
+	}
+
+	if savedSearchId, ok := rewritten.Attributes["savedSearchId"].(string); ok && savedSearchId != "" {
+		rewritten.Attributes["savedSearchId"] = resolver.mappedId(VisualizationReferencesTypeSearch.String(), savedSearchId)
+	}
+
+	if meta, ok := rewritten.Attributes["kibanaSavedObjectMeta"].(map[string]interface{}); ok {
+		if searchSourceJson, ok := meta["searchSourceJSON"].(string); ok {
+			searchSourceJson, err := resolver.rewriteSearchSourceJson(searchSourceJson)
+			if err != nil {
+				return nil, err
+			}
+
+			rewritten.Attributes["kibanaSavedObjectMeta"] = map[string]interface{}{"searchSourceJSON": searchSourceJson}
+		}
+	}
+
+	return &rewritten, nil
+}
+
+// SavedObjectReferences returns the saved objects the dashboard refers to, for dashboards created before 7.0
+// the references are read from the panels and filters
+func (dashboard *Dashboard) SavedObjectReferences() ([]*SavedObjectReference, error) {
+	var references []*SavedObjectReference
+	names := map[string]bool{}
+	for _, reference := range dashboard.References {
+		references = append(references, &SavedObjectReference{Name: reference.Name, Type: reference.Type.String(), Id: reference.Id})
+		names[reference.Name] = true
+	}
+
+	panels, err := decodeDashboardPanels(dashboard.Attributes.PanelsJson, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	for index, panel := range panels {
+		if panel.PanelRefName != "" {
+			if !names[panel.PanelRefName] {
+				references = append(references, &SavedObjectReference{Name: panel.PanelRefName})
+			}
+		} else if panel.Id != "" {
+			references = append(references, &SavedObjectReference{Name: fmt.Sprintf("panel_%d", index), Type: panel.Type.String(), Id: panel.Id})
+		}
+	}
+
+	return appendSearchSourceReferences(references, dashboard.Attributes.KibanaSavedObjectMeta, names)
+}
+
+// SavedObjectReferences returns the saved objects the visualization refers to, for visualizations created
+// before 7.0 the references are read from the saved search id and search source
+func (visualization *Visualization) SavedObjectReferences() ([]*SavedObjectReference, error) {
+	var references []*SavedObjectReference
+	names := map[string]bool{}
+	for _, reference := range visualization.References {
+		references = append(references, &SavedObjectReference{Name: reference.Name, Type: reference.Type.String(), Id: reference.Id})
+		names[reference.Name] = true
+	}
+
+	attributes := visualization.Attributes
+	if attributes.SavedSearchRefName != "" && !names[attributes.SavedSearchRefName] {
+		references = append(references, &SavedObjectReference{Name: attributes.SavedSearchRefName})
+	} else if attributes.SavedSearchId != "" {
+		references = append(references, &SavedObjectReference{Name: "search_0", Type: VisualizationReferencesTypeSearch.String(), Id: attributes.SavedSearchId})
+	}
+
+	return appendSearchSourceReferences(references, attributes.KibanaSavedObjectMeta, names)
+}
+
+func appendSearchSourceReferences(references []*SavedObjectReference, meta *SearchKibanaSavedObjectMeta, names map[string]bool) ([]*SavedObjectReference, error) {
+	if meta == nil || meta.SearchSourceJSON == "" {
+		return references, nil
+	}
+
+	var searchSource struct {
+		Index        string `json:"index"`
+		IndexRefName string `json:"indexRefName"`
+		Filter       []struct {
+			Meta *struct {
+				Index        string `json:"index"`
+				IndexRefName string `json:"indexRefName"`
+			} `json:"meta"`
+		} `json:"filter"`
+	}
+
+	if err := json.Unmarshal([]byte(meta.SearchSourceJSON), &searchSource); err != nil {
+		return nil, fmt.Errorf("could not parse search source, error: %v", err)
+	}
+
+	indexReference := func(name string, refName string, index string) {
+		if refName != "" {
+			if !names[refName] {
+				references = append(references, &SavedObjectReference{Name: refName})
+			}
+		} else if index != "" {
+			references = append(references, &SavedObjectReference{Name: name, Type: string(DashboardReferencesTypeIndexPattern), Id: index})
+		}
+	}
+
+	indexReference(searchSourceIndexRefName, searchSource.IndexRefName, searchSource.Index)
+	for index, filter := range searchSource.Filter {
+		if filter.Meta != nil {
+			indexReference(fmt.Sprintf(searchSourceFilterIndexRefName, index), filter.Meta.IndexRefName, filter.Meta.Index)
+		}
+	}
+
+	return references, nil
+}
+
+func (resolver *ReferenceResolver) dangling(references []*SavedObjectReference) ([]*SavedObjectReference, error) {
+	dangling := []*SavedObjectReference{}
+	for _, reference := range references {
+		if reference.Id == "" || reference.Type == "" {
+			dangling = append(dangling, reference)
+			continue
+		}
+
+		exists, err := resolver.savedObjectExists(reference.Type, reference.Id)
+		if err != nil {
+			return nil, err
+		}
+
+		if !exists {
+			dangling = append(dangling, reference)
+		}
+	}
+
+	return dangling, nil
+}
+
+func (resolver *ReferenceResolver) savedObjectExists(objectType string, id string) (bool, error) {
+	key := referenceKey(objectType, id)
+	if exists, ok := resolver.exists[key]; ok {
+		return exists, nil
+	}
+
+	_, err := resolver.client.GetById(objectType, id)
+	if err != nil {
+		httpErr, ok := err.(*HttpError)
+		if !ok || httpErr.Code != 404 {
+			return false, err
+		}
+	}
+
+	resolver.exists[key] = err == nil
+	return err == nil, nil
+}
+
+func (resolver *ReferenceResolver) mappedId(objectType string, id string) string {
+	if mapped, ok := resolver.mappings[referenceKey(objectType, id)]; ok {
+		return mapped
+	}
+
+	return id
+}
+
+// rewritePanelsJson rewrites the ids of panels created before 7.0, later panels refer to their saved object
+// by a reference
+func (resolver *ReferenceResolver) rewritePanelsJson(panelsJson string) (string, error) {
+	if panelsJson == "" {
+		return panelsJson, nil
+	}
+
+	var panels []map[string]interface{}
+	if err := json.Unmarshal([]byte(panelsJson), &panels); err != nil {
+		return "", fmt.Errorf("could not parse dashboard panels, error: %v", err)
+	}
+
+	changed := false
+	for _, panel := range panels {
+		panelType, _ := panel["type"].(string)
+		id, ok := panel["id"].(string)
+		if ok && resolver.mappedId(panelType, id) != id {
+			panel["id"] = resolver.mappedId(panelType, id)
+			changed = true
+		}
+	}
+
+	if !changed {
+		return panelsJson, nil
+	}
+
+	data, err := json.Marshal(panels)
+	if err != nil {
+		return "", fmt.Errorf("could not encode dashboard panels, error: %v", err)
+	}
+
+	return string(data), nil
+}
+
+func (resolver *ReferenceResolver) rewriteSearchSourceJson(searchSourceJson string) (string, error) {
+	if searchSourceJson == "" {
+		return searchSourceJson, nil
+	}
+
+	var searchSource map[string]interface{}
+	if err := json.Unmarshal([]byte(searchSourceJson), &searchSource); err != nil {
+		return "", fmt.Errorf("could not parse search source, error: %v", err)
+	}
+
+	changed := resolver.rewriteIndex(searchSource)
+	filters, _ := searchSource["filter"].([]interface{})
+	for _, filter := range filters {
+		if filter, ok := filter.(map[string]interface{}); ok {
+			if meta, ok := filter["meta"].(map[string]interface{}); ok && resolver.rewriteIndex(meta) {
+				changed = true
+			}
+		}
+	}
+
+	if !changed {
+		return searchSourceJson, nil
+	}
+
+	data, err := json.Marshal(searchSource)
+	if err != nil {
+		return "", fmt.Errorf("could not encode search source, error: %v", err)
+	}
+
+	return string(data), nil
+}
+
+func (resolver *ReferenceResolver) rewriteIndex(values map[string]interface{}) bool {
+	index, ok := values["index"].(string)
+	if !ok || index == "" {
+		return false
+	}
+
+	mapped := resolver.mappedId(string(DashboardReferencesTypeIndexPattern), index)
+	values["index"] = mapped
+	return mapped != index
+}
+
+func referenceKey(objectType string, id string) string {
+	return objectType + "/" + id
+}
@@ -0,0 +1,132 @@
+package kibana
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_VisualizationRequestBuilder_saved_search_reference_for_7(t *testing.T) {
+	request, err := NewVisualizationRequestBuilder().WithTitle("No search").Build(DefaultKibanaVersion7)
+	require.NoError(t, err)
+	assert.Empty(t, request.References)
+	assert.Empty(t, request.Attributes.SavedSearchRefName)
+
+	request, err = NewVisualizationRequestBuilder().WithSavedSearchId("search-1").Build(DefaultKibanaVersion7)
+	require.NoError(t, err)
+	assert.Equal(t, "search_0", request.Attributes.SavedSearchRefName)
+	assert.Equal(t, []*VisualizationReferences{{Name: "search_0", Type: VisualizationReferencesTypeSearch, Id: "search-1"}}, request.References)
+}
+
+func Test_ReferenceResolver_ValidateDashboard(t *testing.T) {
+	client := DefaultTestKibanaClient()
+
+	searchRequest, _, err := createSearchRequest(client.Search(), client.Config.DefaultIndexId, t)
+	require.NoError(t, err)
+	search, err := client.Search().Create(searchRequest)
+	require.NoError(t, err)
+	defer client.Search().Delete(search.Id)
+
+	visualizationRequest, err := newTestVisualizationRequestBuilder().
+		WithSavedSearchId(search.Id).
+		Build(client.Config.KibanaVersion)
+	require.NoError(t, err)
+	visualization, err := client.Visualization().Create(visualizationRequest)
+	require.NoError(t, err)
+	defer client.Visualization().Delete(visualization.Id)
+
+	request, err := newTestPanelDashboardRequestBuilder(visualization.Id, "missing-search").
+		BuildForVersion(client.Config.KibanaVersion)
+	require.NoError(t, err)
+	created, err := client.Dashboard().Create(request)
+	require.NoError(t, err)
+	defer client.Dashboard().Delete(created.Id)
+
+	dashboard, err := client.Dashboard().GetById(created.Id)
+	require.NoError(t, err)
+
+	resolver := NewReferenceResolver(client.SavedObjects())
+	dangling, err := resolver.ValidateDashboard(dashboard)
+	require.NoError(t, err)
+	require.Len(t, dangling, 1)
+	assert.Equal(t, "missing-search", dangling[0].Id)
+	assert.Equal(t, DashboardReferencesTypeSearch.String(), dangling[0].Type)
+
+	readVisualization, err := client.Visualization().GetById(visualization.Id)
+	require.NoError(t, err)
+	dangling, err = resolver.ValidateVisualization(readVisualization)
+	require.NoError(t, err)
+	assert.Empty(t, dangling)
+}
+
+func Test_ReferenceResolver_reports_undefined_reference_names(t *testing.T) {
+	dashboard := &Dashboard{
+		Attributes: &DashboardAttributes{
+			PanelsJson: `[{"panelIndex":"1","panelRefName":"panel_0"},{"panelIndex":"2","panelRefName":"panel_1"}]`,
+		},
+		References: []*DashboardReferences{{Name: "panel_0", Type: DashboardReferencesTypeVisualization, Id: "vis-1"}},
+	}
+
+	references, err := dashboard.SavedObjectReferences()
+	require.NoError(t, err)
+	assert.Equal(t, []*SavedObjectReference{
+		{Name: "panel_0", Type: "visualization", Id: "vis-1"},
+		{Name: "panel_1"},
+	}, references)
+}
+
+func Test_ReferenceResolver_rewrites_legacy_ids(t *testing.T) {
+	resolver := NewReferenceResolver(nil).
+		WithIdMapping("visualization", "vis-1", "vis-2").
+		WithIdMapping("index-pattern", "logs-a", "logs-b").
+		WithIdMapping("search", "search-1", "search-2")
+
+	request, err := newTestPanelDashboardRequestBuilder("vis-1", "search-1").
+		WithFilter(&SearchFilter{Meta: &SearchFilterMetaData{Index: "logs-a", Key: "geo.src"}}).
+		BuildForVersion(DefaultKibanaVersion6)
+	require.NoError(t, err)
+
+	dashboard := &Dashboard{Id: "dashboard-1", Attributes: request.Attributes}
+	rewritten, err := resolver.RewriteDashboard(dashboard)
+	require.NoError(t, err)
+
+	references, err := rewritten.SavedObjectReferences()
+	require.NoError(t, err)
+	assert.Equal(t, []*SavedObjectReference{
+		{Name: "panel_0", Type: "visualization", Id: "vis-2"},
+		{Name: "panel_1", Type: "search", Id: "search-2"},
+		{Name: "kibanaSavedObjectMeta.searchSourceJSON.filter[0].meta.index", Type: "index-pattern", Id: "logs-b"},
+	}, references)
+	assert.Contains(t, request.Attributes.PanelsJson, "vis-1", "the original dashboard is not changed")
+
+	visualization := &Visualization{Attributes: &VisualizationAttributes{
+		SavedSearchId:         "search-1",
+		KibanaSavedObjectMeta: &SearchKibanaSavedObjectMeta{SearchSourceJSON: `{"index":"logs-a","filter":[]}`},
+	}}
+	rewrittenVisualization, err := resolver.RewriteVisualization(visualization)
+	require.NoError(t, err)
+	assert.Equal(t, "search-2", rewrittenVisualization.Attributes.SavedSearchId)
+
+	searchSource := map[string]interface{}{}
+	require.NoError(t, json.Unmarshal([]byte(rewrittenVisualization.Attributes.KibanaSavedObjectMeta.SearchSourceJSON), &searchSource))
+	assert.Equal(t, "logs-b", searchSource["index"])
+}
+
+func Test_ReferenceResolver_rewrites_references(t *testing.T) {
+	resolver := NewReferenceResolver(nil).WithIdMapping("search", "search-1", "search-2")
+
+	request, err := newTestPanelDashboardRequestBuilder("vis-1", "search-1").BuildForVersion(DefaultKibanaVersion7)
+	require.NoError(t, err)
+
+	rewritten, err := resolver.RewriteDashboard(&Dashboard{Attributes: request.Attributes, References: request.References})
+	require.NoError(t, err)
+	assert.Equal(t, request.Attributes.PanelsJson, rewritten.Attributes.PanelsJson)
+
+	panels, err := rewritten.Panels()
+	require.NoError(t, err)
+	assert.Equal(t, "vis-1", panels[0].Id)
+	assert.Equal(t, "search-2", panels[1].Id)
+	assert.Equal(t, "search-1", request.References[1].Id)
+}
@@ -0,0 +1,90 @@
+package kibana
+
+import (
+	"net/http"
+	"sync"
+	"time"
+)
+
+// RequestHook is notified before every http request the client sends and after its response is received,
+// it can be used to log or trace the calls made to kibana
+type RequestHook interface {
+	BeforeRequest(event *RequestEvent)
+	AfterResponse(event *ResponseEvent)
+}
+
+// RequestEvent describes a request about to be sent, the attempt starts at 1 and increases when the same
+// request is retried. Header contains the raw headers including credentials, use RedactHeaders before logging them.
+type RequestEvent struct {
+	Method  string
+	Url     string
+	Header  http.Header
+	Attempt int
+}
+
+// ResponseEvent describes the outcome of a request, Err is set when no response was received
+type ResponseEvent struct {
+	RequestEvent
+	StatusCode int
+	Duration   time.Duration
+	Err        error
+}
+
+type hookRoundTripper struct {
+	hooks    []RequestHook
+	next     http.RoundTripper
+	mutex    sync.Mutex
+	attempts int
+}
+
+// RedactHeaders returns a copy of the headers with the value of the named headers replaced
+func RedactHeaders(headers http.Header, names []string) http.Header {
+	result := http.Header{}
+	for name, values := range headers {
+		result[name] = append([]string{}, values...)
+	}
+
+	for _, name := range names {
+		if _, ok := result[http.CanonicalHeaderKey(name)]; ok {
+			result.Set(name, redactedValue)
+		}
+	}
+
+	return result
+}
+
+func newHookRoundTripper(hooks []RequestHook) func(next http.RoundTripper) http.RoundTripper {
+	return func(next http.RoundTripper) http.RoundTripper {
+		return &hookRoundTripper{hooks: hooks, next: next}
+	}
+}
+
+func (transport *hookRoundTripper) RoundTrip(request *http.Request) (*http.Response, error) {
+	transport.mutex.Lock()
+	transport.attempts++
+	requestEvent := RequestEvent{
+		Method:  request.Method,
+		Url:     request.URL.Redacted(),
+		Header:  request.Header.Clone(),
+		Attempt: transport.attempts,
+	}
+	transport.mutex.Unlock()
+
+	for _, hook := range transport.hooks {
+		hook.BeforeRequest(&requestEvent)
+	}
+
+	start := time.Now()
+	response, err := transport.next.RoundTrip(request)
+
+	responseEvent := &ResponseEvent{RequestEvent: requestEvent, Duration: time.Since(start), Err: err}
+	if response != nil {
+		responseEvent.StatusCode = response.StatusCode
+	}
+
+	for _, hook := range transport.hooks {
+		hook.AfterResponse(responseEvent)
+	}
+
+	return response, err
+}
@@ -0,0 +1,56 @@
+//go:build go1.21
+
+package kibana
+
+import (
+	"context"
+	"log/slog"
+)
+
+// SlogHook is a RequestHook which writes the requests sent to kibana to a structured slog logger. Requests are
+// logged at debug level, responses at info level or error level for failed calls and error status codes.
+// Headers are only logged when LogHeaders is set, with the values of RedactHeaders removed.
+type SlogHook struct {
+	Logger        *slog.Logger
+	LogHeaders    bool
+	RedactHeaders []string
+}
+
+// NewSlogHook creates a hook writing to logger which redacts the DefaultRedactedHeaders
+func NewSlogHook(logger *slog.Logger) *SlogHook {
+	return &SlogHook{Logger: logger, RedactHeaders: DefaultRedactedHeaders}
+}
+
+func (hook *SlogHook) BeforeRequest(event *RequestEvent) {
+	hook.Logger.LogAttrs(context.Background(), slog.LevelDebug, "kibana request", hook.requestAttributes(event)...)
+}
+
+func (hook *SlogHook) AfterResponse(event *ResponseEvent) {
+	level := slog.LevelInfo
+	attributes := append(hook.requestAttributes(&event.RequestEvent),
+		slog.Int("status", event.StatusCode),
+		slog.Duration("duration", event.Duration))
+
+	if event.Err != nil {
+		level = slog.LevelError
+		attributes = append(attributes, slog.String("error", event.Err.Error()))
+	} else if event.StatusCode >= 400 {
+		level = slog.LevelError
+	}
+
+	hook.Logger.LogAttrs(context.Background(), level, "kibana response", attributes...)
+}
+
+func (hook *SlogHook) requestAttributes(event *RequestEvent) []slog.Attr {
+	attributes := []slog.Attr{
+		slog.String("method", event.Method),
+		slog.String("url", event.Url),
+		slog.Int("attempt", event.Attempt),
+	}
+
+	if hook.LogHeaders {
+		attributes = append(attributes, slog.Any("headers", RedactHeaders(event.Header, hook.RedactHeaders)))
+	}
+
+	return attributes
+}
@@ -0,0 +1,37 @@
+//go:build go1.21
+
+package kibana
+
+import (
+	"bytes"
+	"log/slog"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_SlogHook_logs_redacted_requests(t *testing.T) {
+	fake := NewFakeKibana(DefaultKibanaVersion7)
+	defer fake.Close()
+
+	var output bytes.Buffer
+	hook := NewSlogHook(slog.New(slog.NewJSONHandler(&output, &slog.HandlerOptions{Level: slog.LevelDebug})))
+	hook.LogHeaders = true
+
+	client := NewClient(fake.Config()).
+		SetAuth(NewBasicAuthentication("elastic", "changeme")).
+		AddHook(hook)
+
+	_, err := client.Search().GetById("missing")
+	require.Error(t, err)
+
+	logs := output.String()
+	assert.Contains(t, logs, `"msg":"kibana request"`)
+	assert.Contains(t, logs, `"msg":"kibana response"`)
+	assert.Contains(t, logs, `"level":"ERROR"`)
+	assert.Contains(t, logs, `"status":404`)
+	assert.Contains(t, logs, `"attempt":1`)
+	assert.Contains(t, logs, redactedValue)
+	assert.NotContains(t, logs, "ZWxhc3RpYzpjaGFuZ2VtZQ==")
+}
@@ -48,6 +48,7 @@ func (api *DefaultRoleClient) CreateOrUpdate(request *Role) error {
 	request.Name = ""
 	response, body, err := api.client.
 		Put(api.config.KibanaBaseUri+"/api/security/role/"+id).
+		Operation("Role.CreateOrUpdate", "role", id).
 		Set("kbn-version", api.config.KibanaVersion).
 		Send(request).
 		End()
@@ -66,6 +67,7 @@ func (api *DefaultRoleClient) CreateOrUpdate(request *Role) error {
 func (api *DefaultRoleClient) GetByID(id string) (*Role, error) {
 	response, body, err := api.client.
 		Get(api.config.KibanaBaseUri+"/api/security/role/"+id).
+		Operation("Role.GetByID", "role", id).
 		Set("kbn-version", api.config.KibanaVersion).
 		End()
 	if err != nil {
@@ -91,6 +93,7 @@ func (api *DefaultRoleClient) GetByID(id string) (*Role, error) {
 func (api *DefaultRoleClient) Delete(id string) error {
 	response, body, err := api.client.
 		Delete(api.config.KibanaBaseUri+"/api/security/role/"+id).
+		Operation("Role.Delete", "role", id).
 		Set("kbn-version", api.config.KibanaVersion).
 		End()
 	if err != nil {
@@ -0,0 +1,322 @@
+package kibana
+
+import (
+	"encoding/json"
+	"fmt"
+
+	goversion "github.com/mcuadros/go-version"
+)
+
+// savedObjectGraphPerPage is the number of saved objects read per request when looking for dependents
+const savedObjectGraphPerPage = 1000
+
+// SavedObjectGraphTypes are the saved object types which refer to other saved objects
+var SavedObjectGraphTypes = []string{"dashboard", "visualization", "search", "lens"}
+
+// savedObjectGraphTypeVersions are the kibana versions introducing saved object types of SavedObjectGraphTypes,
+// saved objects of these types are only read from the saved objects clients of this package for those versions
+var savedObjectGraphTypeVersions = map[string]string{"lens": "7.10.0"}
+
+// SavedObjectGraph walks the references between saved objects, from a saved object to the saved objects it
+// depends on or to the saved objects depending on it. Saved objects are read once and cached by the graph.
+type SavedObjectGraph struct {
+	client     SavedObjectsClient
+	version    string
+	perPage    int
+	objects    map[string]*SavedObject
+	dependents map[string][]*SavedObjectNode
+}
+
+// SavedObjectNode is a saved object in a dependency tree. ReferenceName is the name of the reference to the
+// saved object from its parent, Missing is set when the referred saved object does not exist and Cycle when
+// the saved object is already one of its own ancestors, in both cases the node has no children.
+type SavedObjectNode struct {
+	Type          string
+	Id            string
+	Title         string
+	ReferenceName string
+	Missing       bool
+	Cycle         bool
+	Children      []*SavedObjectNode
+}
+
+// NewSavedObjectGraph creates a graph reading saved objects with the client from a kibana of the version
+func NewSavedObjectGraph(client SavedObjectsClient, version string) *SavedObjectGraph {
+	return &SavedObjectGraph{
+		client:  client,
+		version: version,
+		perPage: savedObjectGraphPerPage,
+		objects: map[string]*SavedObject{},
+	}
+}
+
+// Dependencies returns the tree of saved objects the saved object refers to, for example the visualizations
+// of a dashboard with their saved searches and index patterns
+func (graph *SavedObjectGraph) Dependencies(objectType string, id string) (*SavedObjectNode, error) {
+	return graph.dependencies(&SavedObjectReference{Type: objectType, Id: id}, map[string]bool{})
+}
+
+// Dependents returns the tree of saved objects referring to the saved object, for example the searches,
+// visualizations and dashboards using an index pattern
+func (graph *SavedObjectGraph) Dependents(objectType string, id string) (*SavedObjectNode, error) {
+	if graph.dependents == nil {
+		if err := graph.loadDependents(); err != nil {
+			return nil, err
+		}
+	}
+
+	object, err := graph.savedObject(objectType, id)
+	if err != nil {
+		return nil, err
+	}
+
+	root := &SavedObjectNode{Type: objectType, Id: id, Missing: object == nil}
+	if object != nil {
+		root.Title = savedObjectTitle(object)
+	}
+
+	graph.addDependents(root, map[string]bool{referenceKey(objectType, id): true})
+	return root, nil
+}
+
+// Flatten returns the saved objects below the node once each, every saved object comes after the saved
+// objects it depends on in a dependency tree. Missing saved objects and cycles are left out.
+func (node *SavedObjectNode) Flatten() []*SavedObjectNode {
+	var nodes []*SavedObjectNode
+	seen := map[string]bool{referenceKey(node.Type, node.Id): true}
+
+	var visit func(parent *SavedObjectNode)
+	visit = func(parent *SavedObjectNode) {
+		for _, child := range parent.Children {
+			key := referenceKey(child.Type, child.Id)
+			if seen[key] || child.Missing || child.Cycle {
+				continue
+			}
+
+			seen[key] = true
+			visit(child)
+			nodes = append(nodes, child)
+		}
+	}
+
+	visit(node)
+	return nodes
+}
+
+func (graph *SavedObjectGraph) dependencies(reference *SavedObjectReference, path map[string]bool) (*SavedObjectNode, error) {
+	node := &SavedObjectNode{Type: reference.Type, Id: reference.Id, ReferenceName: reference.Name}
+	key := referenceKey(reference.Type, reference.Id)
+	if path[key] {
+		node.Cycle = true
+		return node, nil
+	}
+
+	object, err := graph.savedObject(reference.Type, reference.Id)
+	if err != nil {
+		return nil, err
+	}
+
+	if object == nil {
+		node.Missing = true
+		return node, nil
+	}
+
+	node.Title = savedObjectTitle(object)
+	references, err := object.SavedObjectReferences()
+	if err != nil {
+		return nil, err
+	}
+
+	path[key] = true
+	defer delete(path, key)
+	for _, childReference := range references {
+		if childReference.Type == "" || childReference.Id == "" {
+			node.Children = append(node.Children, &SavedObjectNode{ReferenceName: childReference.Name, Missing: true})
+			continue
+		}
+
+		child, err := graph.dependencies(childReference, path)
+		if err != nil {
+			return nil, err
+		}
+
+		node.Children = append(node.Children, child)
+	}
+
+	return node, nil
+}
+
+func (graph *SavedObjectGraph) addDependents(node *SavedObjectNode, path map[string]bool) {
+	for _, dependent := range graph.dependents[referenceKey(node.Type, node.Id)] {
+		child := *dependent
+		key := referenceKey(child.Type, child.Id)
+		if path[key] {
+			child.Cycle = true
+		} else {
+			path[key] = true
+			graph.addDependents(&child, path)
+			delete(path, key)
+		}
+
+		node.Children = append(node.Children, &child)
+	}
+}
+
+// loadDependents reads the saved objects of the types referring to other saved objects and indexes them by
+// the saved objects they refer to
+func (graph *SavedObjectGraph) loadDependents() error {
+	dependents := map[string][]*SavedObjectNode{}
+	for _, objectType := range SavedObjectGraphTypes {
+		if !graph.supportsType(objectType) {
+			continue
+		}
+
+		objects, err := graph.savedObjectsOfType(objectType)
+		if err != nil {
+			return err
+		}
+
+		for _, object := range objects {
+			graph.objects[referenceKey(object.Type, object.Id)] = object
+			references, err := object.SavedObjectReferences()
+			if err != nil {
+				return fmt.Errorf("could not read references of %s %s, error: %v", object.Type, object.Id, err)
+			}
+
+			for _, reference := range references {
+				if reference.Type == "" || reference.Id == "" {
+					continue
+				}
+
+				key := referenceKey(reference.Type, reference.Id)
+				dependents[key] = append(dependents[key], &SavedObjectNode{
+					Type:          object.Type,
+					Id:            object.Id,
+					Title:         savedObjectTitle(object),
+					ReferenceName: reference.Name,
+				})
+			}
+		}
+	}
+
+	graph.dependents = dependents
+	return nil
+}
+
+// supportsType checks the kibana the graph reads from has saved objects of the type
+func (graph *SavedObjectGraph) supportsType(objectType string) bool {
+	minimumVersion, ok := savedObjectGraphTypeVersions[objectType]
+	if !ok {
+		return true
+	}
+
+	return graph.version != "" && goversion.Compare(graph.version, minimumVersion, ">=")
+}
+
+// savedObjectsOfType reads all the saved objects of the type a page at a time
+func (graph *SavedObjectGraph) savedObjectsOfType(objectType string) ([]*SavedObject, error) {
+	var objects []*SavedObject
+	for page := 1; ; page++ {
+		response, err := graph.client.GetByType(NewSavedObjectRequestBuilder().
+			WithType(objectType).
+			WithPerPage(graph.perPage).
+			WithPage(page).
+			Build())
+		if err != nil {
+			return nil, err
+		}
+
+		for _, object := range response.SavedObjects {
+			if object.Type == "" {
+				object.Type = objectType
+			}
+
+			objects = append(objects, object)
+		}
+
+		if len(response.SavedObjects) == 0 || len(objects) >= response.Total {
+			return objects, nil
+		}
+	}
+}
+
+// savedObject reads the saved object, it returns nil when the saved object does not exist
+func (graph *SavedObjectGraph) savedObject(objectType string, id string) (*SavedObject, error) {
+	key := referenceKey(objectType, id)
+	if object, ok := graph.objects[key]; ok {
+		return object, nil
+	}
+
+	object, err := graph.client.GetById(objectType, id)
+	if err != nil {
+		httpErr, ok := err.(*HttpError)
+		if !ok || httpErr.Code != 404 {
+			return nil, err
+		}
+
+		object = nil
+	}
+
+	if object != nil && object.Type == "" {
+		object.Type = objectType
+	}
+
+	graph.objects[key] = object
+	return object, nil
+}
+
+// SavedObjectReferences returns the saved objects the saved object refers to, for saved objects created
+// before 7.0 the references are read from the attributes of dashboards, visualizations and searches
+func (object *SavedObject) SavedObjectReferences() ([]*SavedObjectReference, error) {
+	data, err := json.Marshal(object.Attributes)
+	if err != nil {
+		return nil, fmt.Errorf("could not encode saved object attributes, error: %v", err)
+	}
+
+	switch object.Type {
+	case "dashboard":
+		dashboard := &Dashboard{Attributes: &DashboardAttributes{}}
+		if err := json.Unmarshal(data, dashboard.Attributes); err != nil {
+			return nil, fmt.Errorf("could not parse dashboard attributes, error: %v", err)
+		}
+
+		for _, reference := range object.References {
+			dashboard.References = append(dashboard.References, &DashboardReferences{Name: reference.Name, Type: dashboardReferencesType(reference.Type), Id: reference.Id})
+		}
+
+		return dashboard.SavedObjectReferences()
+	case "visualization":
+		visualization := &Visualization{Attributes: &VisualizationAttributes{}}
+		if err := json.Unmarshal(data, visualization.Attributes); err != nil {
+			return nil, fmt.Errorf("could not parse visualization attributes, error: %v", err)
+		}
+
+		for _, reference := range object.References {
+			visualization.References = append(visualization.References, &VisualizationReferences{Name: reference.Name, Type: visualizationReferencesType(reference.Type), Id: reference.Id})
+		}
+
+		return visualization.SavedObjectReferences()
+	}
+
+	var attributes struct {
+		KibanaSavedObjectMeta *SearchKibanaSavedObjectMeta `json:"kibanaSavedObjectMeta"`
+	}
+
+	if err := json.Unmarshal(data, &attributes); err != nil {
+		return nil, fmt.Errorf("could not parse %s attributes, error: %v", object.Type, err)
+	}
+
+	var references []*SavedObjectReference
+	names := map[string]bool{}
+	for _, reference := range object.References {
+		references = append(references, reference)
+		names[reference.Name] = true
+	}
+
+	return appendSearchSourceReferences(references, attributes.KibanaSavedObjectMeta, names)
+}
+
+func savedObjectTitle(object *SavedObject) string {
+	title, _ := object.Attributes["title"].(string)
+	return title
+}
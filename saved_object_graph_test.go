@@ -0,0 +1,203 @@
+package kibana
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type testSavedObjectsClient struct {
+	objects []*SavedObject
+}
+
+func (client *testSavedObjectsClient) GetByType(request *SavedObjectRequest) (*SavedObjectResponse, error) {
+	response := &SavedObjectResponse{Page: request.Page, PerPage: request.PerPage, SavedObjects: []*SavedObject{}}
+	for _, object := range client.objects {
+		if object.Type != request.Type {
+			continue
+		}
+
+		if response.Total >= (request.Page-1)*request.PerPage && response.Total < request.Page*request.PerPage {
+			response.SavedObjects = append(response.SavedObjects, object)
+		}
+
+		response.Total++
+	}
+
+	return response, nil
+}
+
+func (client *testSavedObjectsClient) GetById(objectType string, id string) (*SavedObject, error) {
+	for _, object := range client.objects {
+		if object.Type == objectType && object.Id == id {
+			return object, nil
+		}
+	}
+
+	return nil, &HttpError{Code: 404, Message: "Not Found"}
+}
+
+func (client *testSavedObjectsClient) Create(objectType string, id string, request *CreateSavedObjectRequest) (*SavedObject, error) {
+	object := &SavedObject{Type: objectType, Id: id, Attributes: request.Attributes, References: request.References}
+	client.objects = append(client.objects, object)
+	return object, nil
+}
+
+func (client *testSavedObjectsClient) Delete(objectType string, id string) error {
+	for index, object := range client.objects {
+		if object.Type == objectType && object.Id == id {
+			client.objects = append(client.objects[:index], client.objects[index+1:]...)
+			return nil
+		}
+	}
+
+	return &HttpError{Code: 404, Message: "Not Found"}
+}
+
+func Test_SavedObjectGraph_Dependencies(t *testing.T) {
+	client := DefaultTestKibanaClient()
+
+	searchRequest, _, err := createSearchRequest(client.Search(), client.Config.DefaultIndexId, t)
+	require.NoError(t, err)
+	search, err := client.Search().Create(searchRequest)
+	require.NoError(t, err)
+	defer client.Search().Delete(search.Id)
+
+	visualizationRequest, err := newTestVisualizationRequestBuilder().
+		WithSavedSearchId(search.Id).
+		Build(client.Config.KibanaVersion)
+	require.NoError(t, err)
+	visualization, err := client.Visualization().Create(visualizationRequest)
+	require.NoError(t, err)
+	defer client.Visualization().Delete(visualization.Id)
+
+	request, err := newTestPanelDashboardRequestBuilder(visualization.Id, search.Id).
+		BuildForVersion(client.Config.KibanaVersion)
+	require.NoError(t, err)
+	dashboard, err := client.Dashboard().Create(request)
+	require.NoError(t, err)
+	defer client.Dashboard().Delete(dashboard.Id)
+
+	graph := NewSavedObjectGraph(client.SavedObjects(), client.Config.KibanaVersion)
+	tree, err := graph.Dependencies("dashboard", dashboard.Id)
+	require.NoError(t, err)
+
+	assert.Equal(t, "China errors", tree.Title)
+	require.Len(t, tree.Children, 2)
+	visualizationNode := tree.Children[0]
+	assert.Equal(t, visualization.Id, visualizationNode.Id)
+	require.Len(t, visualizationNode.Children, 1)
+	assert.Equal(t, search.Id, visualizationNode.Children[0].Id)
+	assert.Equal(t, "Geography filter on china", visualizationNode.Children[0].Title)
+
+	indexPatternNode := visualizationNode.Children[0].Children[0]
+	assert.Equal(t, "index-pattern", indexPatternNode.Type)
+	assert.Equal(t, client.Config.DefaultIndexId, indexPatternNode.Id)
+	assert.False(t, indexPatternNode.Missing)
+
+	flattened := tree.Flatten()
+	require.Len(t, flattened, 3)
+	assert.Equal(t, client.Config.DefaultIndexId, flattened[0].Id)
+	assert.Equal(t, search.Id, flattened[1].Id)
+	assert.Equal(t, visualization.Id, flattened[2].Id)
+
+	dependents, err := NewSavedObjectGraph(client.SavedObjects(), client.Config.KibanaVersion).Dependents("search", search.Id)
+	require.NoError(t, err)
+	dependentIds := []string{}
+	for _, node := range dependents.Flatten() {
+		dependentIds = append(dependentIds, node.Type+"/"+node.Id)
+	}
+
+	assert.ElementsMatch(t, []string{"visualization/" + visualization.Id, "dashboard/" + dashboard.Id}, dependentIds)
+}
+
+func Test_SavedObjectGraph_detects_cycles(t *testing.T) {
+	client := &testSavedObjectsClient{objects: []*SavedObject{
+		{
+			Type:       "dashboard",
+			Id:         "dashboard-1",
+			Attributes: map[string]interface{}{"title": "Cyclic", "panelsJSON": `[{"panelIndex":"1","panelRefName":"panel_0"}]`},
+			References: []*SavedObjectReference{{Name: "panel_0", Type: "visualization", Id: "vis-1"}},
+		},
+		{
+			Type:       "visualization",
+			Id:         "vis-1",
+			Attributes: map[string]interface{}{"title": "Links back", "savedSearchRefName": "search_0"},
+			References: []*SavedObjectReference{{Name: "search_0", Type: "search", Id: "search-1"}},
+		},
+		{
+			Type: "search",
+			Id:   "search-1",
+			Attributes: map[string]interface{}{
+				"title":                 "Search",
+				"kibanaSavedObjectMeta": map[string]interface{}{"searchSourceJSON": `{"index":"logs"}`},
+			},
+			References: []*SavedObjectReference{{Name: "parent", Type: "dashboard", Id: "dashboard-1"}},
+		},
+	}}
+
+	tree, err := NewSavedObjectGraph(client, DefaultKibanaVersion7).Dependencies("dashboard", "dashboard-1")
+	require.NoError(t, err)
+
+	search := tree.Children[0].Children[0]
+	assert.Equal(t, "search-1", search.Id)
+	require.Len(t, search.Children, 2)
+	assert.Equal(t, &SavedObjectNode{Type: "dashboard", Id: "dashboard-1", ReferenceName: "parent", Cycle: true}, search.Children[0])
+	assert.Equal(t, &SavedObjectNode{Type: "index-pattern", Id: "logs", ReferenceName: searchSourceIndexRefName, Missing: true}, search.Children[1])
+
+	dependents, err := NewSavedObjectGraph(client, DefaultKibanaVersion7).Dependents("search", "search-1")
+	require.NoError(t, err)
+	require.Len(t, dependents.Children, 1)
+	assert.Equal(t, "vis-1", dependents.Children[0].Id)
+	assert.Equal(t, "dashboard-1", dependents.Children[0].Children[0].Id)
+	assert.True(t, dependents.Children[0].Children[0].Children[0].Cycle)
+}
+
+func Test_SavedObjectGraph_dependents_include_lens(t *testing.T) {
+	fake := NewFakeKibana("7.10.2")
+	defer fake.Close()
+
+	client := NewClient(fake.Config())
+	indexPattern, err := client.SavedObjects().Create("index-pattern", "logs", &CreateSavedObjectRequest{
+		Attributes: map[string]interface{}{"title": "logs-*"},
+	})
+	require.NoError(t, err)
+
+	request, err := newTestLensRequestBuilder(indexPattern.Id, NewLensMetricVisualization("layer1", "count")).Build()
+	require.NoError(t, err)
+	lens, err := client.Lens().Create(request)
+	require.NoError(t, err)
+
+	dependents, err := NewSavedObjectGraph(client.SavedObjects(), client.Config.KibanaVersion).Dependents("index-pattern", indexPattern.Id)
+	require.NoError(t, err)
+	require.Len(t, dependents.Children, 2, "the lens visualization refers to the index pattern of its layer and its current index pattern")
+	for _, dependent := range dependents.Children {
+		assert.Equal(t, "lens", dependent.Type)
+		assert.Equal(t, lens.Id, dependent.Id)
+		assert.Equal(t, "Requests by country", dependent.Title)
+	}
+
+	assert.False(t, NewSavedObjectGraph(client.SavedObjects(), DefaultKibanaVersion7).supportsType("lens"), "kibana 7.3.1 has no lens visualizations")
+}
+
+func Test_SavedObjectGraph_dependents_read_every_page(t *testing.T) {
+	client := &testSavedObjectsClient{}
+	for index := 0; index < 5; index++ {
+		client.objects = append(client.objects, &SavedObject{
+			Type:       "visualization",
+			Id:         fmt.Sprintf("vis-%d", index),
+			Attributes: map[string]interface{}{"title": fmt.Sprintf("Visualization %d", index), "savedSearchRefName": "search_0"},
+			References: []*SavedObjectReference{{Name: "search_0", Type: "search", Id: "search-1"}},
+		})
+	}
+
+	client.objects = append(client.objects, &SavedObject{Type: "search", Id: "search-1", Attributes: map[string]interface{}{"title": "Shared"}})
+
+	graph := NewSavedObjectGraph(client, DefaultKibanaVersion7)
+	graph.perPage = 2
+	dependents, err := graph.Dependents("search", "search-1")
+	require.NoError(t, err)
+	assert.Len(t, dependents.Children, 5, "the visualizations on every page depend on the search")
+}
@@ -6,16 +6,27 @@ type SavedObjectRequest struct {
 	Type    string   `json:"type" url:"type"`
 	Fields  []string `json:"fields" url:"fields"`
 	PerPage int      `json:"per_page" url:"per_page"`
+	Page    int      `json:"page,omitempty" url:"page,omitempty"`
 }
 
 type SavedObjectRequestBuilder struct {
 	objectType string
 	fields     []string
 	perPage    int
+	page       int
 }
 
 type SavedObjectsClient interface {
 	GetByType(request *SavedObjectRequest) (*SavedObjectResponse, error)
+	GetById(objectType string, id string) (*SavedObject, error)
+	Create(objectType string, id string, request *CreateSavedObjectRequest) (*SavedObject, error)
+	Delete(objectType string, id string) error
+}
+
+// CreateSavedObjectRequest creates a saved object of any type, references are only supported from kibana 7.0
+type CreateSavedObjectRequest struct {
+	Attributes map[string]interface{}  `json:"attributes"`
+	References []*SavedObjectReference `json:"references,omitempty"`
 }
 
 type SavedObjectResponse struct {
@@ -26,10 +37,19 @@ type SavedObjectResponse struct {
 }
 
 type SavedObject struct {
-	Id         string                 `json:"id"`
-	Type       string                 `json:"type"`
-	Version    version                `json:"version"`
-	Attributes map[string]interface{} `json:"attributes"`
+	Id         string                  `json:"id"`
+	Type       string                  `json:"type"`
+	Version    version                 `json:"version"`
+	Attributes map[string]interface{}  `json:"attributes"`
+	References []*SavedObjectReference `json:"references,omitempty"`
+}
+
+// SavedObjectReference is a reference from one saved object to another, kibana stores them with the saved
+// object from 7.0, for earlier versions they are read from the attributes of the saved object
+type SavedObjectReference struct {
+	Name string `json:"name"`
+	Type string `json:"type"`
+	Id   string `json:"id"`
 }
 
 func NewSavedObjectRequestBuilder() *SavedObjectRequestBuilder {
@@ -51,10 +71,16 @@ func (builder *SavedObjectRequestBuilder) WithPerPage(perPage int) *SavedObjectR
 	return builder
 }
 
+func (builder *SavedObjectRequestBuilder) WithPage(page int) *SavedObjectRequestBuilder {
+	builder.page = page
+	return builder
+}
+
 func (builder *SavedObjectRequestBuilder) Build() *SavedObjectRequest {
 	return &SavedObjectRequest{
 		Fields:  builder.fields,
 		Type:    builder.objectType,
 		PerPage: builder.perPage,
+		Page:    builder.page,
 	}
 }
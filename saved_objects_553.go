@@ -4,6 +4,8 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+
+	uuid "github.com/satori/go.uuid"
 )
 
 type savedObjectsClient553 struct {
@@ -27,10 +29,23 @@ type savedObjectSearchResponseHit553 struct {
 	Source map[string]interface{} `json:"_source"`
 }
 
+type savedObjectReadResult553 struct {
+	Id      string                 `json:"_id"`
+	Type    string                 `json:"_type"`
+	Version version                `json:"_version"`
+	Source  map[string]interface{} `json:"_source"`
+}
+
 func (api *savedObjectsClient553) GetByType(request *SavedObjectRequest) (*SavedObjectResponse, error) {
-	address := api.config.BuildFullPath("/%s/_search?size=%d", request.Type, request.PerPage)
+	page := request.Page
+	if page < 1 {
+		page = 1
+	}
+
+	address := api.config.BuildFullPath("/%s/_search?size=%d&from=%d", request.Type, request.PerPage, (page-1)*request.PerPage)
 	apiResponse, body, errs := api.client.
 		Post(address).
+		Operation("SavedObjects.GetByType", request.Type, "").
 		Set("kbn-version", api.config.KibanaVersion).
 		Send(`{"query":{"match_all":{}}}`).
 		End()
@@ -52,7 +67,7 @@ func (api *savedObjectsClient553) GetByType(request *SavedObjectRequest) (*Saved
 	for _, item := range response.Hits.Hits {
 		objectVersion := version("1")
 		if val, ok := item.Source["version"]; ok {
-			objectVersion = val.(version)
+			objectVersion = version(fmt.Sprint(val))
 		}
 
 		savedObjects = append(savedObjects, &SavedObject{
@@ -65,10 +80,80 @@ func (api *savedObjectsClient553) GetByType(request *SavedObjectRequest) (*Saved
 
 	savedObjectResponse := &SavedObjectResponse{
 		PerPage:      request.PerPage,
-		Page:         1,
+		Page:         page,
 		Total:        response.Hits.Total,
 		SavedObjects: savedObjects,
 	}
 
 	return savedObjectResponse, nil
 }
+
+func (api *savedObjectsClient553) GetById(objectType string, id string) (*SavedObject, error) {
+	response, body, err := api.client.
+		Get(api.config.BuildFullPath("/%s/%s", objectType, id)).
+		Operation("SavedObjects.GetById", objectType, id).
+		Set("kbn-version", api.config.KibanaVersion).
+		End()
+
+	if err != nil {
+		return nil, err[0]
+	}
+
+	if response.StatusCode >= 300 {
+		return nil, NewError(response, body, "Could not fetch saved object")
+	}
+
+	readResult := &savedObjectReadResult553{}
+	if err := json.Unmarshal([]byte(body), readResult); err != nil {
+		return nil, fmt.Errorf("could not parse fields from get saved object response, error: %v", err)
+	}
+
+	return &SavedObject{
+		Id:         readResult.Id,
+		Type:       readResult.Type,
+		Version:    readResult.Version,
+		Attributes: readResult.Source,
+	}, nil
+}
+
+// Create creates or overwrites the saved object, an id is generated when id is empty
+func (api *savedObjectsClient553) Create(objectType string, id string, request *CreateSavedObjectRequest) (*SavedObject, error) {
+	if id == "" {
+		id = uuid.NewV4().String()
+	}
+
+	response, body, err := api.client.
+		Post(api.config.BuildFullPath("/%s/%s", objectType, id)).
+		Operation("SavedObjects.Create", objectType, id).
+		Set("kbn-version", api.config.KibanaVersion).
+		Send(request.Attributes).
+		End()
+
+	if err != nil {
+		return nil, err[0]
+	}
+
+	if response.StatusCode >= 300 {
+		return nil, NewError(response, body, "Could not create saved object")
+	}
+
+	return api.GetById(objectType, id)
+}
+
+func (api *savedObjectsClient553) Delete(objectType string, id string) error {
+	response, body, err := api.client.
+		Delete(api.config.BuildFullPath("/%s/%s", objectType, id)).
+		Operation("SavedObjects.Delete", objectType, id).
+		Set("kbn-version", api.config.KibanaVersion).
+		End()
+
+	if err != nil {
+		return err[0]
+	}
+
+	if response.StatusCode >= 300 {
+		return NewError(response, body, "Could not delete saved object")
+	}
+
+	return nil
+}
@@ -21,7 +21,9 @@ func (api *savedObjectsClient600) GetByType(request *SavedObjectRequest) (*Saved
 		return nil, fmt.Errorf("could not build query string for get saved objects by type, error: %v", err)
 	}
 
-	apiResponse, body, errs := api.client.Get(address).End()
+	apiResponse, body, errs := api.client.Get(address).
+		Operation("SavedObjects.GetByType", request.Type, "").
+		End()
 	if errs != nil {
 		return nil, fmt.Errorf("could not get saved objects, error: %v", errs)
 	}
@@ -39,6 +41,77 @@ func (api *savedObjectsClient600) GetByType(request *SavedObjectRequest) (*Saved
 	return response, nil
 }
 
+func (api *savedObjectsClient600) GetById(objectType string, id string) (*SavedObject, error) {
+	response, body, err := api.client.
+		Get(api.config.KibanaBaseUri+savedObjectsPath+objectType+"/"+id).
+		Operation("SavedObjects.GetById", objectType, id).
+		Set("kbn-version", api.config.KibanaVersion).
+		End()
+
+	if err != nil {
+		return nil, err[0]
+	}
+
+	if response.StatusCode >= 300 {
+		return nil, NewError(response, body, "Could not fetch saved object")
+	}
+
+	savedObject := &SavedObject{}
+	if err := json.Unmarshal([]byte(body), savedObject); err != nil {
+		return nil, fmt.Errorf("could not parse fields from get saved object response, error: %v", err)
+	}
+
+	return savedObject, nil
+}
+
+// Create creates or overwrites the saved object, an id is generated when id is empty
+func (api *savedObjectsClient600) Create(objectType string, id string, request *CreateSavedObjectRequest) (*SavedObject, error) {
+	address := api.config.KibanaBaseUri + savedObjectsPath + objectType
+	if id != "" {
+		address += "/" + id
+	}
+
+	response, body, err := api.client.
+		Post(address+"?overwrite=true").
+		Operation("SavedObjects.Create", objectType, id).
+		Set("kbn-version", api.config.KibanaVersion).
+		Send(request).
+		End()
+
+	if err != nil {
+		return nil, err[0]
+	}
+
+	if response.StatusCode >= 300 {
+		return nil, NewError(response, body, "Could not create saved object")
+	}
+
+	savedObject := &SavedObject{}
+	if err := json.Unmarshal([]byte(body), savedObject); err != nil {
+		return nil, fmt.Errorf("could not parse fields from create saved object response, error: %v", err)
+	}
+
+	return savedObject, nil
+}
+
+func (api *savedObjectsClient600) Delete(objectType string, id string) error {
+	response, body, err := api.client.
+		Delete(api.config.KibanaBaseUri+savedObjectsPath+objectType+"/"+id).
+		Operation("SavedObjects.Delete", objectType, id).
+		Set("kbn-version", api.config.KibanaVersion).
+		End()
+
+	if err != nil {
+		return err[0]
+	}
+
+	if response.StatusCode >= 300 {
+		return NewError(response, body, "Could not delete saved object")
+	}
+
+	return nil
+}
+
 func (api *savedObjectsClient600) getSavedObjectsPath() string {
 	if goversion.Compare(api.config.KibanaVersion, "6.3.0", ">=") {
 		return api.config.KibanaBaseUri + savedObjectsPath + "_find"
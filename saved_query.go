@@ -0,0 +1,419 @@
+package kibana
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	goversion "github.com/mcuadros/go-version"
+)
+
+const savedQueryPath = "/api/saved_query"
+
+type SavedQueryClient interface {
+	Create(request *CreateSavedQueryRequest) (*SavedQuery, error)
+	GetById(id string) (*SavedQuery, error)
+	Find(search string) ([]*SavedQuery, error)
+	Update(id string, request *UpdateSavedQueryRequest) (*SavedQuery, error)
+	Delete(id string) error
+}
+
+// savedQueryClient700 manages saved queries as query saved objects, kibana 7.3 to 7.x have no saved query api
+type savedQueryClient700 struct {
+	config *Config
+	client *HttpAgent
+}
+
+// savedQueryClient800 uses the saved query api of kibana 8.0 and later
+type savedQueryClient800 struct {
+	config *Config
+	client *HttpAgent
+}
+
+// SavedQuery is a query, its filters and optionally a time range saved to be loaded again in discover, dashboards
+// and visualizations, supported from kibana 7.3
+type SavedQuery struct {
+	Id         string                `json:"id"`
+	Attributes *SavedQueryAttributes `json:"attributes"`
+}
+
+type SavedQueryAttributes struct {
+	Title       string                `json:"title"`
+	Description string                `json:"description"`
+	Query       *SearchQuery600       `json:"query"`
+	Filters     []*SearchFilter       `json:"filters,omitempty"`
+	Timefilter  *SavedQueryTimeFilter `json:"timefilter,omitempty"`
+}
+
+// SavedQueryTimeFilter is the time range the saved query restores, From and To are dates or date math such as
+// now-15m
+type SavedQueryTimeFilter struct {
+	From            string                    `json:"from"`
+	To              string                    `json:"to"`
+	RefreshInterval *DashboardRefreshInterval `json:"refreshInterval,omitempty"`
+}
+
+// CreateSavedQueryRequest creates a saved query, kibana 7.x uses the title as the id when Id is not set while
+// kibana 8.0 and later always generate the id
+type CreateSavedQueryRequest struct {
+	Id         string                `json:"-"`
+	Attributes *SavedQueryAttributes `json:"attributes"`
+}
+
+type UpdateSavedQueryRequest struct {
+	Attributes *SavedQueryAttributes `json:"attributes"`
+}
+
+type SavedQueryRequestBuilder struct {
+	id          string
+	title       string
+	description string
+	query       *SearchQuery600
+	filters     []*SearchFilter
+	timefilter  *SavedQueryTimeFilter
+}
+
+type savedQueryFindQuery struct {
+	Type         string `json:"type"`
+	Search       string `json:"search,omitempty"`
+	SearchFields string `json:"search_fields"`
+	PerPage      int    `json:"per_page"`
+}
+
+type savedQueryFindRequest struct {
+	Search  string `json:"search,omitempty"`
+	PerPage int    `json:"perPage"`
+	Page    int    `json:"page"`
+}
+
+type savedQueryFindResponse struct {
+	Total        int           `json:"total"`
+	SavedQueries []*SavedQuery `json:"savedQueries"`
+}
+
+type savedQueryFindResponse700 struct {
+	SavedObjects []*SavedQuery `json:"saved_objects"`
+}
+
+func NewSavedQueryRequestBuilder() *SavedQueryRequestBuilder {
+	return &SavedQueryRequestBuilder{}
+}
+
+// WithId sets the id of the saved query, only kibana 7.x uses it
+func (builder *SavedQueryRequestBuilder) WithId(id string) *SavedQueryRequestBuilder {
+	builder.id = id
+	return builder
+}
+
+func (builder *SavedQueryRequestBuilder) WithTitle(title string) *SavedQueryRequestBuilder {
+	builder.title = title
+	return builder
+}
+
+func (builder *SavedQueryRequestBuilder) WithDescription(description string) *SavedQueryRequestBuilder {
+	builder.description = description
+	return builder
+}
+
+// WithQuery sets the text of the query and its language, kuery or lucene
+func (builder *SavedQueryRequestBuilder) WithQuery(query string, language string) *SavedQueryRequestBuilder {
+	builder.query = &SearchQuery600{Query: query, Language: language}
+	return builder
+}
+
+func (builder *SavedQueryRequestBuilder) WithFilters(filters ...*SearchFilter) *SavedQueryRequestBuilder {
+	builder.filters = append(builder.filters, filters...)
+	return builder
+}
+
+// WithTimeFilter makes the saved query restore the time range when it is loaded
+func (builder *SavedQueryRequestBuilder) WithTimeFilter(from string, to string) *SavedQueryRequestBuilder {
+	builder.timefilter = &SavedQueryTimeFilter{From: from, To: to}
+	return builder
+}
+
+func (builder *SavedQueryRequestBuilder) Build() (*CreateSavedQueryRequest, error) {
+	if builder.title == "" {
+		return nil, errors.New("saved query title is required")
+	}
+
+	query := builder.query
+	if query == nil {
+		query = &SearchQuery600{Query: "", Language: QueryLanguageKuery}
+	}
+
+	switch query.Language {
+	case QueryLanguageKuery:
+		if err := ValidateKQL(query.Query); err != nil {
+			return nil, err
+		}
+	case QueryLanguageLucene:
+	default:
+		return nil, fmt.Errorf("unsupported query language %s", query.Language)
+	}
+
+	return &CreateSavedQueryRequest{
+		Id: builder.id,
+		Attributes: &SavedQueryAttributes{
+			Title:       builder.title,
+			Description: builder.description,
+			Query:       query,
+			Filters:     builder.filters,
+			Timefilter:  builder.timefilter,
+		},
+	}, nil
+}
+
+func (api *savedQueryClient700) Create(request *CreateSavedQueryRequest) (*SavedQuery, error) {
+	if err := api.checkVersion(); err != nil {
+		return nil, err
+	}
+
+	id := request.Id
+	if id == "" {
+		id = request.Attributes.Title
+	}
+
+	response, body, errs := api.client.
+		Post(api.config.KibanaBaseUri+savedObjectsPath+"query/"+id).
+		Operation("SavedQuery.Create", "query", id).
+		Set("kbn-version", api.config.KibanaVersion).
+		Send(&UpdateSavedQueryRequest{Attributes: request.Attributes}).
+		End()
+
+	if errs != nil {
+		return nil, errs[0]
+	}
+
+	if response.StatusCode >= 300 {
+		return nil, NewError(response, body, "Could not create saved query")
+	}
+
+	return parseSavedQueryResponse(body, "create saved query")
+}
+
+func (api *savedQueryClient700) GetById(id string) (*SavedQuery, error) {
+	if err := api.checkVersion(); err != nil {
+		return nil, err
+	}
+
+	response, body, errs := api.client.
+		Get(api.config.KibanaBaseUri+savedObjectsPath+"query/"+id).
+		Operation("SavedQuery.GetById", "query", id).
+		Set("kbn-version", api.config.KibanaVersion).
+		End()
+
+	if errs != nil {
+		return nil, errs[0]
+	}
+
+	if response.StatusCode >= 300 {
+		return nil, NewError(response, body, "Could not fetch saved query")
+	}
+
+	return parseSavedQueryResponse(body, "get saved query")
+}
+
+// Find returns the saved queries whose title contains a word starting with search, or all the saved queries when
+// search is empty
+func (api *savedQueryClient700) Find(search string) ([]*SavedQuery, error) {
+	if err := api.checkVersion(); err != nil {
+		return nil, err
+	}
+
+	query := savedQueryFindQuery{Type: "query", SearchFields: "title", PerPage: 9999}
+	if search != "" {
+		query.Search = search + "*"
+	}
+
+	response, body, errs := api.client.
+		Get(api.config.KibanaBaseUri+savedObjectsPath+"_find").
+		Query(query).
+		Operation("SavedQuery.Find", "query", "").
+		Set("kbn-version", api.config.KibanaVersion).
+		End()
+
+	if errs != nil {
+		return nil, errs[0]
+	}
+
+	if response.StatusCode >= 300 {
+		return nil, NewError(response, body, "Could not fetch saved queries")
+	}
+
+	result := &savedQueryFindResponse700{}
+	if err := json.Unmarshal([]byte(body), result); err != nil {
+		return nil, fmt.Errorf("could not parse fields from find saved queries response, error: %v", err)
+	}
+
+	return result.SavedObjects, nil
+}
+
+func (api *savedQueryClient700) Update(id string, request *UpdateSavedQueryRequest) (*SavedQuery, error) {
+	if err := api.checkVersion(); err != nil {
+		return nil, err
+	}
+
+	response, body, errs := api.client.
+		Put(api.config.KibanaBaseUri+savedObjectsPath+"query/"+id).
+		Operation("SavedQuery.Update", "query", id).
+		Set("kbn-version", api.config.KibanaVersion).
+		Send(request).
+		End()
+
+	if errs != nil {
+		return nil, errs[0]
+	}
+
+	if response.StatusCode >= 300 {
+		return nil, NewError(response, body, "Could not update saved query")
+	}
+
+	savedQuery, err := parseSavedQueryResponse(body, "update saved query")
+	if err != nil {
+		return nil, err
+	}
+
+	// kibana returns only the attributes which were sent
+	savedQuery.Attributes = request.Attributes
+	return savedQuery, nil
+}
+
+func (api *savedQueryClient700) Delete(id string) error {
+	if err := api.checkVersion(); err != nil {
+		return err
+	}
+
+	response, body, errs := api.client.
+		Delete(api.config.KibanaBaseUri+savedObjectsPath+"query/"+id).
+		Operation("SavedQuery.Delete", "query", id).
+		Set("kbn-version", api.config.KibanaVersion).
+		End()
+
+	if errs != nil {
+		return errs[0]
+	}
+
+	if response.StatusCode >= 300 {
+		return NewError(response, body, "Could not delete saved query")
+	}
+
+	return nil
+}
+
+func (api *savedQueryClient700) checkVersion() error {
+	if goversion.Compare(api.config.KibanaVersion, "7.3.0", "<") {
+		return fmt.Errorf("saved queries are not supported by kibana %s, they require kibana 7.3 or later", api.config.KibanaVersion)
+	}
+
+	return nil
+}
+
+func (api *savedQueryClient800) Create(request *CreateSavedQueryRequest) (*SavedQuery, error) {
+	response, body, errs := api.client.
+		Post(api.config.KibanaBaseUri+savedQueryPath+"/_create").
+		Operation("SavedQuery.Create", "query", "").
+		Set("kbn-version", api.config.KibanaVersion).
+		Send(request.Attributes).
+		End()
+
+	if errs != nil {
+		return nil, errs[0]
+	}
+
+	if response.StatusCode >= 300 {
+		return nil, NewError(response, body, "Could not create saved query")
+	}
+
+	return parseSavedQueryResponse(body, "create saved query")
+}
+
+func (api *savedQueryClient800) GetById(id string) (*SavedQuery, error) {
+	response, body, errs := api.client.
+		Get(api.config.KibanaBaseUri+savedQueryPath+"/"+id).
+		Operation("SavedQuery.GetById", "query", id).
+		Set("kbn-version", api.config.KibanaVersion).
+		End()
+
+	if errs != nil {
+		return nil, errs[0]
+	}
+
+	if response.StatusCode >= 300 {
+		return nil, NewError(response, body, "Could not fetch saved query")
+	}
+
+	return parseSavedQueryResponse(body, "get saved query")
+}
+
+// Find returns the saved queries whose title or description match search, or all the saved queries when search
+// is empty
+func (api *savedQueryClient800) Find(search string) ([]*SavedQuery, error) {
+	response, body, errs := api.client.
+		Post(api.config.KibanaBaseUri+savedQueryPath+"/_find").
+		Operation("SavedQuery.Find", "query", "").
+		Set("kbn-version", api.config.KibanaVersion).
+		Send(&savedQueryFindRequest{Search: search, PerPage: 9999, Page: 1}).
+		End()
+
+	if errs != nil {
+		return nil, errs[0]
+	}
+
+	if response.StatusCode >= 300 {
+		return nil, NewError(response, body, "Could not fetch saved queries")
+	}
+
+	result := &savedQueryFindResponse{}
+	if err := json.Unmarshal([]byte(body), result); err != nil {
+		return nil, fmt.Errorf("could not parse fields from find saved queries response, error: %v", err)
+	}
+
+	return result.SavedQueries, nil
+}
+
+func (api *savedQueryClient800) Update(id string, request *UpdateSavedQueryRequest) (*SavedQuery, error) {
+	response, body, errs := api.client.
+		Put(api.config.KibanaBaseUri+savedQueryPath+"/"+id).
+		Operation("SavedQuery.Update", "query", id).
+		Set("kbn-version", api.config.KibanaVersion).
+		Send(request.Attributes).
+		End()
+
+	if errs != nil {
+		return nil, errs[0]
+	}
+
+	if response.StatusCode >= 300 {
+		return nil, NewError(response, body, "Could not update saved query")
+	}
+
+	return parseSavedQueryResponse(body, "update saved query")
+}
+
+func (api *savedQueryClient800) Delete(id string) error {
+	response, body, errs := api.client.
+		Delete(api.config.KibanaBaseUri+savedQueryPath+"/"+id).
+		Operation("SavedQuery.Delete", "query", id).
+		Set("kbn-version", api.config.KibanaVersion).
+		End()
+
+	if errs != nil {
+		return errs[0]
+	}
+
+	if response.StatusCode >= 300 {
+		return NewError(response, body, "Could not delete saved query")
+	}
+
+	return nil
+}
+
+func parseSavedQueryResponse(body string, operation string) (*SavedQuery, error) {
+	savedQuery := &SavedQuery{}
+	if err := json.Unmarshal([]byte(body), savedQuery); err != nil {
+		return nil, fmt.Errorf("could not parse fields from %s response, error: %v", operation, err)
+	}
+
+	return savedQuery, nil
+}
@@ -0,0 +1,114 @@
+package kibana
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_SavedQueryLifecycle(t *testing.T) {
+	for _, version := range []string{DefaultKibanaVersion7, DefaultKibanaVersion8} {
+		t.Run(version, func(t *testing.T) {
+			fake := NewFakeKibana(version)
+			defer fake.Close()
+
+			client := NewClient(fake.Config())
+			savedQueryApi := client.SavedQuery()
+
+			filter, err := NewSearchFilter(version, "logs", &PhraseFilterParams{Field: "geo.src", Value: "CN"})
+			require.NoError(t, err)
+
+			request, err := NewSavedQueryRequestBuilder().
+				WithTitle("Chinese errors").
+				WithDescription("Errors from china").
+				WithQuery("response >= 500", QueryLanguageKuery).
+				WithFilters(filter).
+				WithTimeFilter("now-24h", "now").
+				Build()
+			require.NoError(t, err)
+
+			created, err := savedQueryApi.Create(request)
+			require.NoError(t, err)
+			assert.NotEmpty(t, created.Id)
+			assert.Equal(t, "Chinese errors", created.Attributes.Title)
+
+			savedQuery, err := savedQueryApi.GetById(created.Id)
+			require.NoError(t, err)
+			assert.Equal(t, "Errors from china", savedQuery.Attributes.Description)
+			assert.Equal(t, &SearchQuery600{Query: "response >= 500", Language: QueryLanguageKuery}, savedQuery.Attributes.Query)
+			assert.Equal(t, &SavedQueryTimeFilter{From: "now-24h", To: "now"}, savedQuery.Attributes.Timefilter)
+			require.Len(t, savedQuery.Attributes.Filters, 1)
+
+			params, err := savedQuery.Attributes.Filters[0].TypedParams()
+			require.NoError(t, err)
+			assert.Equal(t, &PhraseFilterParams{Field: "geo.src", Value: "CN"}, params)
+
+			other, err := NewSavedQueryRequestBuilder().WithTitle("Slow requests").WithQuery("duration:>1000", QueryLanguageLucene).Build()
+			require.NoError(t, err)
+			_, err = savedQueryApi.Create(other)
+			require.NoError(t, err)
+
+			found, err := savedQueryApi.Find("chinese")
+			require.NoError(t, err)
+			require.Len(t, found, 1)
+			assert.Equal(t, created.Id, found[0].Id)
+
+			found, err = savedQueryApi.Find("")
+			require.NoError(t, err)
+			assert.Len(t, found, 2)
+
+			savedQuery.Attributes.Query.Query = "response >= 400"
+			updated, err := savedQueryApi.Update(created.Id, &UpdateSavedQueryRequest{Attributes: savedQuery.Attributes})
+			require.NoError(t, err)
+			assert.Equal(t, "response >= 400", updated.Attributes.Query.Query)
+
+			savedQuery, err = savedQueryApi.GetById(created.Id)
+			require.NoError(t, err)
+			assert.Equal(t, "response >= 400", savedQuery.Attributes.Query.Query)
+			assert.Equal(t, "Chinese errors", savedQuery.Attributes.Title)
+
+			require.NoError(t, savedQueryApi.Delete(created.Id))
+			_, err = savedQueryApi.GetById(created.Id)
+			assertNotFound(t, err)
+		})
+	}
+}
+
+func Test_SavedQuery_not_supported_before_7_3(t *testing.T) {
+	fake := NewFakeKibana(DefaultKibanaVersion6)
+	defer fake.Close()
+
+	client := NewClient(fake.Config())
+	request, err := NewSavedQueryRequestBuilder().WithTitle("Errors").WithQuery("response:500", QueryLanguageLucene).Build()
+	require.NoError(t, err)
+
+	_, err = client.SavedQuery().Create(request)
+	assert.EqualError(t, err, "saved queries are not supported by kibana 6.0.0, they require kibana 7.3 or later")
+}
+
+func Test_SavedQueryRequestBuilder(t *testing.T) {
+	_, err := NewSavedQueryRequestBuilder().WithQuery("response:500", QueryLanguageKuery).Build()
+	assert.EqualError(t, err, "saved query title is required")
+
+	_, err = NewSavedQueryRequestBuilder().WithTitle("Errors").WithQuery("response:(500", QueryLanguageKuery).Build()
+	assert.Error(t, err, "invalid kql is rejected")
+
+	_, err = NewSavedQueryRequestBuilder().WithTitle("Errors").WithQuery("response:500", "sql").Build()
+	assert.EqualError(t, err, "unsupported query language sql")
+
+	request, err := NewSavedQueryRequestBuilder().WithId("errors").WithTitle("Errors").Build()
+	require.NoError(t, err)
+	assert.Equal(t, "errors", request.Id)
+	assert.Equal(t, &SearchQuery600{Query: "", Language: QueryLanguageKuery}, request.Attributes.Query)
+}
+
+func Test_SavedQueryClientFromVersion(t *testing.T) {
+	client := NewClient(NewDefaultConfig())
+
+	client.Config.KibanaVersion = "8.4.1"
+	assert.IsType(t, &savedQueryClient800{}, client.SavedQuery())
+
+	client.Config.KibanaVersion = "7.10.2"
+	assert.IsType(t, &savedQueryClient700{}, client.SavedQuery())
+}
@@ -2,6 +2,10 @@ package kibana
 
 import (
 	"encoding/json"
+	"fmt"
+	"strings"
+
+	goversion "github.com/mcuadros/go-version"
 )
 
 const (
@@ -32,6 +36,7 @@ type SearchClient interface {
 	GetById(id string) (*Search, error)
 	List() ([]*Search, error)
 	Delete(id string) error
+	Execute(id string, timeRange *SearchTimeRange, size int) (*SearchResult, error)
 	NewSearchSource() SearchSourceBuilder
 	Version() string
 }
@@ -46,6 +51,12 @@ type UpdateSearchRequest struct {
 	References []*SearchReferences `json:"references,omitempty"`
 }
 
+// searchRequest600 is a create or update request with the attributes encoded for the kibana version
+type searchRequest600 struct {
+	Attributes interface{}         `json:"attributes"`
+	References []*SearchReferences `json:"references,omitempty"`
+}
+
 type Search struct {
 	Id         string              `json:"id"`
 	Type       string              `json:"type"`
@@ -70,42 +81,126 @@ type SearchAttributes struct {
 	KibanaSavedObjectMeta *SearchKibanaSavedObjectMeta `json:"kibanaSavedObjectMeta"`
 }
 
-// Sort allows unmarshalling different json structure for the sort field. In
-// newer version of Kibana this can be a nested JSON array
-// (https://github.com/elastic/kibana/pull/41918/), while in the older versions
-// it is a flat JSON array.
-type Sort []string
+// SortField is a column a search is sorted on
+type SortField struct {
+	Field string
+	Order SortOrder
+}
+
+// Sort is the sort of a saved search. Kibana 7.4 and later store it as a nested array of field and direction
+// pairs (https://github.com/elastic/kibana/pull/41918/), older versions as a flat array of fields followed by
+// a single direction. Sort encodes the nested format, the search clients send the flat format to older
+// versions.
+type Sort []SortField
+
+// legacySort is the flat sort format of kibana versions before 7.4
+type legacySort Sort
 
-// UnmarshalJSON tries to unmarshal the json data first into a nested array and if that fails it will try to unmarshal into a slice of string
+// UnmarshalJSON reads both the nested and the flat sort format, fields of the flat format without a direction
+// are sorted ascending
 func (s *Sort) UnmarshalJSON(data []byte) error {
 	var nestedSlice [][]string
-
-	err := json.Unmarshal(data, &nestedSlice)
-	if err != nil {
-		var slice []string
-		err = json.Unmarshal(data, &slice)
-		if err != nil {
-			return err
+	if err := json.Unmarshal(data, &nestedSlice); err == nil {
+		sort := Sort{}
+		for _, pair := range nestedSlice {
+			if len(pair) == 0 {
+				continue
+			}
+
+			field := SortField{Field: pair[0]}
+			if len(pair) > 1 {
+				field.Order = sortOrderFromString(pair[1])
+			}
+
+			sort = append(sort, field)
 		}
 
-		*s = slice
+		*s = sort
+		return nil
 	}
 
-	// Successfully unmarshalled into a nested slice, which will now need to be
-	// flatten into a slice of string
-	if len(nestedSlice) > 0 && len(nestedSlice[0]) == 2 {
-		// The sort order for the old format will be
-		// taken from the first element of the new format
-		sortOrder := nestedSlice[0][1]
-		for _, sort := range nestedSlice {
-			*s = append(*s, sort[0])
-		}
-		*s = append(*s, sortOrder)
+	var slice []string
+	if err := json.Unmarshal(data, &slice); err != nil {
+		return err
+	}
+
+	order := Ascending
+	if len(slice) > 0 && isSortDirection(slice[len(slice)-1]) {
+		order = sortOrderFromString(slice[len(slice)-1])
+		slice = slice[:len(slice)-1]
+	}
+
+	sort := Sort{}
+	for _, name := range slice {
+		sort = append(sort, SortField{Field: name, Order: order})
 	}
 
+	*s = sort
 	return nil
 }
 
+func (s Sort) MarshalJSON() ([]byte, error) {
+	nested := make([][]string, 0, len(s))
+	for _, field := range s {
+		nested = append(nested, []string{field.Field, field.Order.String()})
+	}
+
+	return json.Marshal(nested)
+}
+
+func (s legacySort) MarshalJSON() ([]byte, error) {
+	flat := make([]string, 0, len(s)+1)
+	for _, field := range s {
+		flat = append(flat, field.Field)
+	}
+
+	if len(s) > 0 {
+		flat = append(flat, s[0].Order.String())
+	}
+
+	return json.Marshal(flat)
+}
+
+func (order SortOrder) String() string {
+	if order == Descending {
+		return "desc"
+	}
+
+	return "asc"
+}
+
+func sortOrderFromString(direction string) SortOrder {
+	if strings.ToLower(direction) == "desc" {
+		return Descending
+	}
+
+	return Ascending
+}
+
+func isSortDirection(value string) bool {
+	value = strings.ToLower(value)
+	return value == "asc" || value == "desc"
+}
+
+// forVersion returns the attributes to send to the kibana version, using the flat sort format before 7.4 which
+// holds a single direction for all the fields
+func (attributes *SearchAttributes) forVersion(version string) (interface{}, error) {
+	if attributes == nil || goversion.Compare(version, "7.4.0", ">=") {
+		return attributes, nil
+	}
+
+	for _, field := range attributes.Sort {
+		if field.Order != attributes.Sort[0].Order {
+			return nil, fmt.Errorf("sorting fields in different directions is not supported by kibana %s, it requires kibana 7.4 or later", version)
+		}
+	}
+
+	return &struct {
+		*SearchAttributes
+		Sort legacySort `json:"sort"`
+	}{SearchAttributes: attributes, Sort: legacySort(attributes.Sort)}, nil
+}
+
 type searchReadResult553 struct {
 	Id      string            `json:"_id"`
 	Type    string            `json:"_type"`
@@ -113,10 +208,17 @@ type searchReadResult553 struct {
 	Source  *SearchAttributes `json:"_source"`
 }
 
+// SearchKibanaSavedObjectMeta holds the search source of a search, visualization or dashboard. Reading a saved
+// object decodes SearchSourceJSON into SearchSource, changes to SearchSource are encoded back to
+// SearchSourceJSON when the object is saved unless SearchSourceJSON was changed as well.
 type SearchKibanaSavedObjectMeta struct {
-	SearchSourceJSON string `json:"searchSourceJSON"`
+	SearchSourceJSON string        `json:"searchSourceJSON"`
+	SearchSource     *SearchSource `json:"-"`
+	decodedJson      string
 }
 
+// SearchSource is the index, query and filters of a saved object. A decoded Query is a *SearchQuery600 or a
+// *SearchQuery553 when kibana wrote it in one of those forms, use TypedQuery to read it in either form.
 type SearchSource struct {
 	IndexId      string          `json:"index"`
 	IndexRefName string          `json:"indexRefName"`
@@ -124,6 +226,7 @@ type SearchSource struct {
 	Version      bool            `json:"version"`
 	Query        interface{}     `json:"query,omitempty"`
 	Filter       []*SearchFilter `json:"filter"`
+	raw          map[string]json.RawMessage
 }
 
 type SearchQuery600 struct {
@@ -140,42 +243,95 @@ type searchQueryString struct {
 	Analyze bool   `json:"analyze_wildcard"`
 }
 
+// SearchFilter is a filter of a search, visualization or dashboard. Use NewSearchFilter to create a filter of a
+// known kind and TypedParams to decode one, custom query dsl filters keep their query dsl in QueryDsl.
 type SearchFilter struct {
-	Query  *SearchFilterQuery    `json:"query"`
-	Exists *SearchFilterExists   `json:"exists"`
-	Meta   *SearchFilterMetaData `json:"meta,omitempty"`
+	Query          *SearchFilterQuery                     `json:"query,omitempty"`
+	Exists         *SearchFilterExists                    `json:"exists,omitempty"`
+	Range          map[string]*SearchFilterRange          `json:"range,omitempty"`
+	GeoBoundingBox map[string]*SearchFilterGeoBoundingBox `json:"geo_bounding_box,omitempty"`
+	Meta           *SearchFilterMetaData                  `json:"meta,omitempty"`
+	State          *SearchFilterState                     `json:"$state,omitempty"`
+	QueryDsl       map[string]json.RawMessage             `json:"-"`
 }
 
 type SearchFilterQuery struct {
-	Match map[string]*SearchFilterQueryAttributes `json:"match"`
+	Match       map[string]*SearchFilterQueryAttributes `json:"match,omitempty"`
+	MatchPhrase map[string]interface{}                  `json:"match_phrase,omitempty"`
+	Bool        *SearchFilterBool                       `json:"bool,omitempty"`
+}
+
+type SearchFilterBool struct {
+	Should             []*SearchFilterQuery `json:"should"`
+	MinimumShouldMatch int                  `json:"minimum_should_match"`
+}
+
+type SearchFilterRange struct {
+	Gte    interface{} `json:"gte,omitempty"`
+	Gt     interface{} `json:"gt,omitempty"`
+	Lte    interface{} `json:"lte,omitempty"`
+	Lt     interface{} `json:"lt,omitempty"`
+	Format string      `json:"format,omitempty"`
+}
+
+type SearchFilterGeoBoundingBox struct {
+	TopLeft     *GeoPoint `json:"top_left"`
+	BottomRight *GeoPoint `json:"bottom_right"`
+}
+
+type GeoPoint struct {
+	Lat float64 `json:"lat"`
+	Lon float64 `json:"lon"`
+}
+
+// SearchFilterState is where kibana keeps a filter, pinned filters are kept in the global state and apply
+// to every app
+type SearchFilterState struct {
+	Store string `json:"store"`
 }
 
 type SearchFilterExists struct {
 	Field string `json:"field"`
 }
 
+// SearchFilterMetaData is how kibana shows a filter. Keys kibana writes which are not decoded, such as the
+// controlledBy of input control filters, and values such as an alias of null are kept as they were read.
 type SearchFilterMetaData struct {
-	Index        string                       `json:"index"`
-	IndexRefName string                       `json:"indexRefName"`
+	Index        string                       `json:"index,omitempty"`
+	IndexRefName string                       `json:"indexRefName,omitempty"`
 	Negate       bool                         `json:"negate"`
 	Disabled     bool                         `json:"disabled"`
 	Alias        string                       `json:"alias"`
 	Type         string                       `json:"type"`
 	Key          string                       `json:"key"`
-	Value        string                       `json:"value"`
-	Params       *SearchFilterQueryAttributes `json:"params"`
+	Value        string                       `json:"value,omitempty"`
+	Params       *SearchFilterQueryAttributes `json:"params,omitempty"`
+	raw          map[string]json.RawMessage
 }
 
+// SearchFilterQueryAttributes are the attributes of a match query and the params of a filter meta, which
+// depend on the kind of filter: the phrase of phrase filters, the bounds of range filters, the corners of
+// geo bounding box filters and the list of phrases, held in Values, of phrases filters. A phrase which is not
+// a string, such as a number, and keys which are not decoded are kept as they were read.
 type SearchFilterQueryAttributes struct {
-	Query string `json:"query"`
-	Type  string `json:"type"`
+	Query       string        `json:"query,omitempty"`
+	Type        string        `json:"type,omitempty"`
+	Gte         interface{}   `json:"gte,omitempty"`
+	Gt          interface{}   `json:"gt,omitempty"`
+	Lte         interface{}   `json:"lte,omitempty"`
+	Lt          interface{}   `json:"lt,omitempty"`
+	Format      string        `json:"format,omitempty"`
+	TopLeft     *GeoPoint     `json:"top_left,omitempty"`
+	BottomRight *GeoPoint     `json:"bottom_right,omitempty"`
+	Values      []interface{} `json:"-"`
+	raw         map[string]json.RawMessage
 }
 
 type SearchRequestBuilder struct {
 	title          string
 	description    string
 	displayColumns []string
-	sortColumns    []string
+	sort           Sort
 	searchSource   *SearchSource
 	references     []*SearchReferences
 }
@@ -184,6 +340,8 @@ type SearchSourceBuilder interface {
 	WithIndexId(indexId string) SearchSourceBuilder
 	WithIndexRefName(indexRefName string) SearchSourceBuilder
 	WithQuery(query string) SearchSourceBuilder
+	WithQueryLanguage(language string) SearchSourceBuilder
+	WithKQL(query string) SearchSourceBuilder
 	WithFilter(filter *SearchFilter) SearchSourceBuilder
 	Build() (*SearchSource, error)
 }
@@ -207,15 +365,20 @@ func (builder *SearchRequestBuilder) WithDisplayColumns(columns []string) *Searc
 	return builder
 }
 
+// WithSortColumns sorts the search on the columns, all in the same direction
 func (builder *SearchRequestBuilder) WithSortColumns(columns []string, order SortOrder) *SearchRequestBuilder {
-	var sortOrder = ""
-	if order == Descending {
-		sortOrder = "desc"
-	} else {
-		sortOrder = "asc"
+	builder.sort = Sort{}
+	for _, column := range columns {
+		builder.sort = append(builder.sort, SortField{Field: column, Order: order})
 	}
 
-	builder.sortColumns = append(columns, sortOrder)
+	return builder
+}
+
+// WithSortFields sorts the search on the fields, each in its own direction, which requires kibana 7.4 or later
+// when the directions differ
+func (builder *SearchRequestBuilder) WithSortFields(fields ...SortField) *SearchRequestBuilder {
+	builder.sort = append(Sort{}, fields...)
 	return builder
 }
 
@@ -241,7 +404,7 @@ func (builder *SearchRequestBuilder) Build() (*CreateSearchRequest, error) {
 			Description: builder.description,
 			Hits:        0,
 			Columns:     builder.displayColumns,
-			Sort:        builder.sortColumns,
+			Sort:        builder.sort,
 			Version:     1,
 			KibanaSavedObjectMeta: &SearchKibanaSavedObjectMeta{
 				SearchSourceJSON: string(searchSourceBytes),
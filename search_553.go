@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"net/url"
 
 	uuid "github.com/satori/go.uuid"
 )
@@ -15,11 +16,12 @@ type searchClient553 struct {
 }
 
 type searchSourceBuilder553 struct {
-	indexId      string
-	indexRefName string
-	highlightAll bool
-	query        *SearchQuery553
-	filters      []*SearchFilter
+	indexId       string
+	indexRefName  string
+	highlightAll  bool
+	query         *SearchQuery553
+	queryLanguage string
+	filters       []*SearchFilter
 }
 
 func (api *searchClient553) Version() string {
@@ -31,11 +33,17 @@ func (api *searchClient553) NewSearchSource() SearchSourceBuilder {
 }
 
 func (api *searchClient553) Create(request *CreateSearchRequest) (*Search, error) {
+	attributes, err := request.Attributes.forVersion(api.config.KibanaVersion)
+	if err != nil {
+		return nil, err
+	}
+
 	id := uuid.NewV4().String()
 	response, body, errs := api.client.
 		Post(api.config.BuildFullPath("/%s/%s", "search", id)).
+		Operation("Search.Create", "search", id).
 		Set("kbn-version", api.config.KibanaVersion).
-		Send(request.Attributes).
+		Send(attributes).
 		End()
 
 	if errs != nil {
@@ -47,7 +55,7 @@ func (api *searchClient553) Create(request *CreateSearchRequest) (*Search, error
 	}
 
 	createResponse := &createResourceResult553{}
-	err := json.Unmarshal([]byte(body), createResponse)
+	err = json.Unmarshal([]byte(body), createResponse)
 	if err != nil {
 		return nil, fmt.Errorf("could not parse fields from create search response, error: %v", err)
 	}
@@ -61,10 +69,16 @@ func (api *searchClient553) Create(request *CreateSearchRequest) (*Search, error
 }
 
 func (api *searchClient553) Update(id string, request *UpdateSearchRequest) (*Search, error) {
+	attributes, attributesErr := request.Attributes.forVersion(api.config.KibanaVersion)
+	if attributesErr != nil {
+		return nil, attributesErr
+	}
+
 	response, body, err := api.client.
 		Post(api.config.BuildFullPath("/%s/%s", "search", id)).
+		Operation("Search.Update", "search", id).
 		Set("kbn-version", api.config.KibanaVersion).
-		Send(request.Attributes).
+		Send(attributes).
 		End()
 
 	if err != nil {
@@ -92,6 +106,7 @@ func (api *searchClient553) Update(id string, request *UpdateSearchRequest) (*Se
 func (api *searchClient553) GetById(id string) (*Search, error) {
 	response, body, err := api.client.
 		Get(api.config.BuildFullPath("/%s/%s", "search", id)).
+		Operation("Search.GetById", "search", id).
 		Set("kbn-version", api.config.KibanaVersion).
 		End()
 
@@ -125,9 +140,51 @@ func (api *searchClient553) List() ([]*Search, error) {
 	return nil, errors.New("not implemnted")
 }
 
+// Execute runs the saved search against the indices of its index pattern through the kibana server
+// proxy to elasticsearch, returning at most size hits
+func (api *searchClient553) Execute(id string, timeRange *SearchTimeRange, size int) (*SearchResult, error) {
+	search, err := api.GetById(id)
+	if err != nil {
+		return nil, err
+	}
+
+	indexPatternId := search.IndexPatternId()
+	if indexPatternId == "" {
+		return nil, fmt.Errorf("search %s has no index pattern", id)
+	}
+
+	indexPattern, err := (&IndexPatternClient553{config: api.config, client: api.client}).GetById(indexPatternId)
+	if err != nil {
+		return nil, err
+	}
+
+	query, err := buildSearchQuery(search, indexPattern, timeRange, size)
+	if err != nil {
+		return nil, err
+	}
+
+	response, body, errs := api.client.
+		Post(fmt.Sprintf("%s/elasticsearch/%s/_search", api.config.KibanaBaseUri, url.PathEscape(indexPattern.Attributes.Title))).
+		Operation("Search.Execute", "search", id).
+		Set("kbn-version", api.config.KibanaVersion).
+		Send(query).
+		End()
+
+	if errs != nil {
+		return nil, errs[0]
+	}
+
+	if response.StatusCode >= 300 {
+		return nil, NewError(response, body, "Could not execute search")
+	}
+
+	return parseSearchResponse(body)
+}
+
 func (api *searchClient553) Delete(id string) error {
 	response, body, err := api.client.
 		Delete(api.config.BuildFullPath("/%s/%s", "search", id)).
+		Operation("Search.Delete", "search", id).
 		Set("kbn-version", api.config.KibanaVersion).
 		End()
 
@@ -158,12 +215,30 @@ func (builder *searchSourceBuilder553) WithQuery(query string) SearchSourceBuild
 	return builder
 }
 
+// WithQueryLanguage sets the language of the query, kibana 5.x only supports lucene queries
+func (builder *searchSourceBuilder553) WithQueryLanguage(language string) SearchSourceBuilder {
+	builder.queryLanguage = language
+	return builder
+}
+
+func (builder *searchSourceBuilder553) WithKQL(query string) SearchSourceBuilder {
+	return builder.WithQuery(query).WithQueryLanguage(QueryLanguageKuery)
+}
+
 func (builder *searchSourceBuilder553) WithFilter(filter *SearchFilter) SearchSourceBuilder {
 	builder.filters = append(builder.filters, filter)
 	return builder
 }
 
 func (builder *searchSourceBuilder553) Build() (*SearchSource, error) {
+	if builder.queryLanguage == QueryLanguageKuery {
+		return nil, errors.New("kql queries are not supported by kibana 5.x, they require kibana 6.3 or later")
+	}
+
+	if builder.queryLanguage != "" && builder.queryLanguage != QueryLanguageLucene {
+		return nil, fmt.Errorf("unsupported query language %s", builder.queryLanguage)
+	}
+
 	return &SearchSource{
 		IndexId:      builder.indexId,
 		IndexRefName: builder.indexRefName,
@@ -3,6 +3,9 @@ package kibana
 import (
 	"encoding/json"
 	"fmt"
+	"net/url"
+
+	goversion "github.com/mcuadros/go-version"
 )
 
 type searchClient600 struct {
@@ -12,11 +15,12 @@ type searchClient600 struct {
 }
 
 type searchSourceBuilder600 struct {
-	indexId      string
-	indexRefName string
-	highlightAll bool
-	query        *SearchQuery600
-	filters      []*SearchFilter
+	kibanaVersion string
+	indexId       string
+	indexRefName  string
+	highlightAll  bool
+	query         *SearchQuery600
+	filters       []*SearchFilter
 }
 
 func (api *searchClient600) Version() string {
@@ -24,14 +28,20 @@ func (api *searchClient600) Version() string {
 }
 
 func (api *searchClient600) NewSearchSource() SearchSourceBuilder {
-	return &searchSourceBuilder600{filters: []*SearchFilter{}}
+	return &searchSourceBuilder600{kibanaVersion: api.config.KibanaVersion, filters: []*SearchFilter{}}
 }
 
 func (api *searchClient600) Create(request *CreateSearchRequest) (*Search, error) {
+	attributes, attributesErr := request.Attributes.forVersion(api.config.KibanaVersion)
+	if attributesErr != nil {
+		return nil, attributesErr
+	}
+
 	response, body, err := api.client.
 		Post(api.config.KibanaBaseUri+savedObjectsPath+"search?overwrite=true").
+		Operation("Search.Create", "search", "").
 		Set("kbn-version", api.config.KibanaVersion).
-		Send(request).
+		Send(&searchRequest600{Attributes: attributes, References: request.References}).
 		End()
 
 	if err != nil {
@@ -52,10 +62,16 @@ func (api *searchClient600) Create(request *CreateSearchRequest) (*Search, error
 }
 
 func (api *searchClient600) Update(id string, request *UpdateSearchRequest) (*Search, error) {
+	attributes, attributesErr := request.Attributes.forVersion(api.config.KibanaVersion)
+	if attributesErr != nil {
+		return nil, attributesErr
+	}
+
 	response, body, err := api.client.
 		Post(api.config.KibanaBaseUri+savedObjectsPath+"search/"+id+"?overwrite=true").
+		Operation("Search.Update", "search", id).
 		Set("kbn-version", api.config.KibanaVersion).
-		Send(request).
+		Send(&searchRequest600{Attributes: attributes, References: request.References}).
 		End()
 
 	if err != nil {
@@ -78,6 +94,7 @@ func (api *searchClient600) Update(id string, request *UpdateSearchRequest) (*Se
 func (api *searchClient600) GetById(id string) (*Search, error) {
 	response, body, err := api.client.
 		Get(api.config.KibanaBaseUri+savedObjectsPath+"search/"+id).
+		Operation("Search.GetById", "search", id).
 		Set("kbn-version", api.config.KibanaVersion).
 		End()
 
@@ -104,6 +121,7 @@ func (api *searchClient600) GetById(id string) (*Search, error) {
 func (api *searchClient600) List() ([]*Search, error) {
 	response, body, err := api.client.
 		Get(api.config.KibanaBaseUri+savedObjectsPath+"_find?type=search&per_page=9999").
+		Operation("Search.List", "search", "").
 		Set("kbn-version", api.config.KibanaVersion).
 		End()
 
@@ -129,9 +147,52 @@ func (api *searchClient600) List() ([]*Search, error) {
 	return listResp.SavedObjects, nil
 }
 
+// Execute runs the saved search against the indices of its index pattern through the kibana console
+// proxy to elasticsearch, returning at most size hits
+func (api *searchClient600) Execute(id string, timeRange *SearchTimeRange, size int) (*SearchResult, error) {
+	search, err := api.GetById(id)
+	if err != nil {
+		return nil, err
+	}
+
+	indexPatternId := search.IndexPatternId()
+	if indexPatternId == "" {
+		return nil, fmt.Errorf("search %s has no index pattern", id)
+	}
+
+	indexPattern, err := (&IndexPatternClient600{config: api.config, client: api.client}).GetById(indexPatternId)
+	if err != nil {
+		return nil, err
+	}
+
+	query, err := buildSearchQuery(search, indexPattern, timeRange, size)
+	if err != nil {
+		return nil, err
+	}
+
+	response, body, errs := api.client.
+		Post(api.config.KibanaBaseUri+"/api/console/proxy").
+		Operation("Search.Execute", "search", id).
+		Set("kbn-version", api.config.KibanaVersion).
+		Query(consoleProxyQuery{Path: url.PathEscape(indexPattern.Attributes.Title) + "/_search", Method: "POST"}).
+		Send(query).
+		End()
+
+	if errs != nil {
+		return nil, errs[0]
+	}
+
+	if response.StatusCode >= 300 {
+		return nil, NewError(response, body, "Could not execute search")
+	}
+
+	return parseSearchResponse(body)
+}
+
 func (api *searchClient600) Delete(id string) error {
 	response, body, err := api.client.
 		Delete(api.config.KibanaBaseUri+savedObjectsPath+"search/"+id).
+		Operation("Search.Delete", "search", id).
 		Set("kbn-version", api.config.KibanaVersion).
 		End()
 
@@ -153,7 +214,27 @@ func (builder *searchSourceBuilder600) WithIndexRefName(indexRefName string) Sea
 }
 
 func (builder *searchSourceBuilder600) WithQuery(query string) SearchSourceBuilder {
-	builder.query = &SearchQuery600{Query: query, Language: "lucene"}
+	language := QueryLanguageLucene
+	if builder.query != nil {
+		language = builder.query.Language
+	}
+
+	builder.query = &SearchQuery600{Query: query, Language: language}
+	return builder
+}
+
+// WithQueryLanguage sets the language of the query, use QueryLanguageLucene or QueryLanguageKuery
+func (builder *searchSourceBuilder600) WithQueryLanguage(language string) SearchSourceBuilder {
+	if builder.query == nil {
+		builder.query = &SearchQuery600{}
+	}
+
+	builder.query.Language = language
+	return builder
+}
+
+func (builder *searchSourceBuilder600) WithKQL(query string) SearchSourceBuilder {
+	builder.query = &SearchQuery600{Query: query, Language: QueryLanguageKuery}
 	return builder
 }
 
@@ -162,7 +243,24 @@ func (builder *searchSourceBuilder600) WithFilter(filter *SearchFilter) SearchSo
 	return builder
 }
 
+// Build creates the search source, kql queries are rejected before kibana 6.3 or when their syntax is invalid
 func (builder *searchSourceBuilder600) Build() (*SearchSource, error) {
+	if builder.query != nil {
+		switch builder.query.Language {
+		case QueryLanguageLucene:
+		case QueryLanguageKuery:
+			if builder.kibanaVersion != "" && goversion.Compare(builder.kibanaVersion, "6.3.0", "<") {
+				return nil, fmt.Errorf("kql queries are not supported by kibana %s, they require kibana 6.3 or later", builder.kibanaVersion)
+			}
+
+			if err := ValidateKQL(builder.query.Query); err != nil {
+				return nil, err
+			}
+		default:
+			return nil, fmt.Errorf("unsupported query language %s", builder.query.Language)
+		}
+	}
+
 	return &SearchSource{
 		IndexId:      builder.indexId,
 		IndexRefName: builder.indexRefName,
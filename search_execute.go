@@ -0,0 +1,179 @@
+package kibana
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+// SearchTimeRange restricts a search to the documents whose time field, as set on the index pattern, is in the
+// range. From and To are elasticsearch dates or date math, such as now-15m.
+type SearchTimeRange struct {
+	From string
+	To   string
+}
+
+// SearchResult is the documents matching a search, Total counts all the matching documents while Hits holds at
+// most the requested number of them
+type SearchResult struct {
+	Total int64
+	Hits  []*SearchHit
+}
+
+type SearchHit struct {
+	Index  string          `json:"_index"`
+	Id     string          `json:"_id"`
+	Score  *float64        `json:"_score"`
+	Source json.RawMessage `json:"_source"`
+	Sort   []interface{}   `json:"sort,omitempty"`
+}
+
+type searchResponse struct {
+	Hits *struct {
+		Total json.RawMessage `json:"total"`
+		Hits  []*SearchHit    `json:"hits"`
+	} `json:"hits"`
+}
+
+// buildSearchQuery translates the query, filters and sort of the saved search to an elasticsearch search request
+// body, restricted to the time range on the time field of the index pattern. A size of zero or less uses the
+// elasticsearch default of 10 hits.
+func buildSearchQuery(search *Search, indexPattern *IndexPattern, timeRange *SearchTimeRange, size int) (map[string]interface{}, error) {
+	filter := []interface{}{}
+	mustNot := []interface{}{}
+
+	meta := search.Attributes.KibanaSavedObjectMeta
+	if meta != nil && meta.SearchSourceJSON != "" && meta.SearchSource == nil {
+		return nil, fmt.Errorf("could not parse search source of search %s", search.Id)
+	}
+
+	if meta != nil && meta.SearchSource != nil {
+		query, err := meta.SearchSource.TypedQuery()
+		if err != nil {
+			return nil, err
+		}
+
+		if query != nil && query.Query != "" {
+			queryDsl, err := searchQueryDsl(query)
+			if err != nil {
+				return nil, err
+			}
+
+			filter = append(filter, queryDsl)
+		}
+
+		for _, searchFilter := range meta.SearchSource.Filter {
+			if searchFilter.Meta != nil && searchFilter.Meta.Disabled {
+				continue
+			}
+
+			queryDsl, err := searchFilterQueryDsl(searchFilter)
+			if err != nil {
+				return nil, err
+			}
+
+			if searchFilter.Meta != nil && searchFilter.Meta.Negate {
+				mustNot = append(mustNot, queryDsl)
+			} else {
+				filter = append(filter, queryDsl)
+			}
+		}
+	}
+
+	if timeRange != nil && indexPattern.Attributes.TimeFieldName != "" {
+		bounds := map[string]interface{}{"format": "strict_date_optional_time"}
+		if timeRange.From != "" {
+			bounds["gte"] = timeRange.From
+		}
+
+		if timeRange.To != "" {
+			bounds["lte"] = timeRange.To
+		}
+
+		filter = append(filter, map[string]interface{}{"range": map[string]interface{}{indexPattern.Attributes.TimeFieldName: bounds}})
+	}
+
+	sort := []interface{}{}
+	for _, sortField := range search.Attributes.Sort {
+		sort = append(sort, map[string]interface{}{sortField.Field: map[string]interface{}{"order": sortField.Order.String()}})
+	}
+
+	body := map[string]interface{}{
+		"query": map[string]interface{}{"bool": map[string]interface{}{"filter": filter, "must_not": mustNot}},
+		"sort":  sort,
+	}
+
+	if size > 0 {
+		body["size"] = size
+	}
+
+	return body, nil
+}
+
+func searchQueryDsl(query *SearchSourceQuery) (interface{}, error) {
+	switch query.Language {
+	case QueryLanguageLucene:
+		return map[string]interface{}{"query_string": map[string]interface{}{"query": query.Query, "analyze_wildcard": true}}, nil
+	case QueryLanguageKuery:
+		return kqlToQueryDsl(query.Query)
+	}
+
+	return nil, fmt.Errorf("unsupported query language %s", query.Language)
+}
+
+// searchFilterQueryDsl is the query of the filter without its meta and state, kibana wraps the query of phrase,
+// phrases and custom filters in a query key
+func searchFilterQueryDsl(filter *SearchFilter) (json.RawMessage, error) {
+	data, err := json.Marshal(filter)
+	if err != nil {
+		return nil, err
+	}
+
+	fields := map[string]json.RawMessage{}
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return nil, err
+	}
+
+	delete(fields, "meta")
+	delete(fields, "$state")
+	if query, ok := fields["query"]; ok && len(fields) == 1 {
+		return query, nil
+	}
+
+	if len(fields) == 0 {
+		return nil, errors.New("could not translate filter, the filter has no query")
+	}
+
+	return json.Marshal(fields)
+}
+
+func parseSearchResponse(body string) (*SearchResult, error) {
+	response := &searchResponse{}
+	if err := json.Unmarshal([]byte(body), response); err != nil {
+		return nil, fmt.Errorf("could not parse search response, error: %v", err)
+	}
+
+	if response.Hits == nil {
+		return nil, errors.New("could not parse search response, the response has no hits")
+	}
+
+	result := &SearchResult{Hits: response.Hits.Hits}
+	if result.Hits == nil {
+		result.Hits = []*SearchHit{}
+	}
+
+	// elasticsearch 7.0 and later report the total as an object with the count in value
+	var total struct {
+		Value int64 `json:"value"`
+	}
+
+	if err := json.Unmarshal(response.Hits.Total, &result.Total); err != nil {
+		if err := json.Unmarshal(response.Hits.Total, &total); err != nil {
+			return nil, fmt.Errorf("could not parse search response total, error: %v", err)
+		}
+
+		result.Total = total.Value
+	}
+
+	return result, nil
+}
@@ -0,0 +1,119 @@
+package kibana
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_buildSearchQuery(t *testing.T) {
+	phrase, err := NewSearchFilter(DefaultKibanaVersion7, "logstash", &PhraseFilterParams{Field: "geo.src", Value: "CN"})
+	require.NoError(t, err)
+	exists, err := NewSearchFilter(DefaultKibanaVersion7, "logstash", &ExistsFilterParams{Field: "machine.os"})
+	require.NoError(t, err)
+	disabled, err := NewSearchFilter(DefaultKibanaVersion7, "logstash", &RangeFilterParams{Field: "bytes", Gte: 100})
+	require.NoError(t, err)
+
+	search := &Search{Attributes: &SearchAttributes{
+		Sort: Sort{{Field: "@timestamp", Order: Descending}},
+		KibanaSavedObjectMeta: &SearchKibanaSavedObjectMeta{SearchSource: &SearchSource{
+			IndexId: "logstash",
+			Query:   &SearchQuery600{Query: "response:200", Language: QueryLanguageKuery},
+			Filter:  []*SearchFilter{phrase, exists.WithNegate(true), disabled.WithDisabled(true)},
+		}},
+	}}
+	indexPattern := &IndexPattern{Attributes: &IndexPatternAttributes{Title: "logstash-*", TimeFieldName: "@timestamp"}}
+
+	body, err := buildSearchQuery(search, indexPattern, &SearchTimeRange{From: "now-15m", To: "now"}, 5)
+	require.NoError(t, err)
+
+	data, err := json.Marshal(body)
+	require.NoError(t, err)
+	assert.JSONEq(t, `{
+		"size": 5,
+		"sort": [{"@timestamp": {"order": "desc"}}],
+		"query": {"bool": {
+			"filter": [
+				{"match": {"response": "200"}},
+				{"match_phrase": {"geo.src": "CN"}},
+				{"range": {"@timestamp": {"gte": "now-15m", "lte": "now", "format": "strict_date_optional_time"}}}
+			],
+			"must_not": [{"exists": {"field": "machine.os"}}]
+		}}
+	}`, string(data))
+}
+
+func Test_buildSearchQuery_lucene_without_time_field(t *testing.T) {
+	search := &Search{Attributes: &SearchAttributes{
+		KibanaSavedObjectMeta: &SearchKibanaSavedObjectMeta{SearchSource: &SearchSource{
+			Query: &SearchQuery553{QueryString: &searchQueryString{Query: "geo.src:CN", Analyze: true}},
+		}},
+	}}
+	indexPattern := &IndexPattern{Attributes: &IndexPatternAttributes{Title: "logstash-*"}}
+
+	body, err := buildSearchQuery(search, indexPattern, &SearchTimeRange{From: "now-15m", To: "now"}, 0)
+	require.NoError(t, err)
+
+	data, err := json.Marshal(body)
+	require.NoError(t, err)
+	assert.JSONEq(t, `{
+		"sort": [],
+		"query": {"bool": {"filter": [{"query_string": {"query": "geo.src:CN", "analyze_wildcard": true}}], "must_not": []}}
+	}`, string(data))
+}
+
+func Test_parseSearchResponse(t *testing.T) {
+	result, err := parseSearchResponse(`{"hits": {"total": 42, "hits": [{"_index": "logstash-1", "_id": "1", "_score": 1.5, "_source": {"bytes": 10}}]}}`)
+	require.NoError(t, err)
+	assert.Equal(t, int64(42), result.Total)
+	require.Len(t, result.Hits, 1)
+	assert.Equal(t, "logstash-1", result.Hits[0].Index)
+	assert.Equal(t, 1.5, *result.Hits[0].Score)
+	assert.JSONEq(t, `{"bytes": 10}`, string(result.Hits[0].Source))
+
+	result, err = parseSearchResponse(`{"hits": {"total": {"value": 7, "relation": "eq"}, "hits": []}}`)
+	require.NoError(t, err)
+	assert.Equal(t, int64(7), result.Total)
+	assert.Empty(t, result.Hits)
+
+	_, err = parseSearchResponse(`{"error": "index_not_found_exception"}`)
+	assert.Error(t, err)
+}
+
+func Test_SearchExecute(t *testing.T) {
+	for _, kibanaVersion := range []string{DefaultKibanaVersion553, DefaultKibanaVersion6, DefaultKibanaVersion7} {
+		t.Run(kibanaVersion, func(t *testing.T) {
+			fake := NewFakeKibana(kibanaVersion)
+			defer fake.Close()
+			fake.Documents = []*FakeKibanaDocument{
+				{Index: "logstash-2015.05.18", Id: "1", Source: map[string]interface{}{"geo.src": "CN", "bytes": 10}},
+				{Index: "logstash-2015.05.19", Id: "2", Source: map[string]interface{}{"geo.src": "CN", "bytes": 20}},
+				{Index: "metrics-2015.05.19", Id: "3", Source: map[string]interface{}{"cpu": 0.5}},
+			}
+
+			client := NewClient(fake.Config())
+			indexPatternId, err := createDefaultIndexPattern(client)
+			require.NoError(t, err)
+
+			request, _, err := createSearchRequest(client.Search(), indexPatternId, t)
+			require.NoError(t, err)
+			search, err := client.Search().Create(request)
+			require.NoError(t, err)
+
+			result, err := client.Search().Execute(search.Id, &SearchTimeRange{From: "now-15m", To: "now"}, 1)
+			require.NoError(t, err)
+			assert.Equal(t, int64(2), result.Total)
+			require.Len(t, result.Hits, 1)
+			assert.Equal(t, "1", result.Hits[0].Id)
+
+			require.Len(t, fake.SearchRequests, 1)
+			assert.Equal(t, float64(1), fake.SearchRequests[0]["size"])
+			assert.Contains(t, fake.SearchRequests[0]["sort"], map[string]interface{}{"@timestamp": map[string]interface{}{"order": "desc"}})
+
+			_, err = client.Search().Execute("missing", nil, 10)
+			assertNotFound(t, err)
+		})
+	}
+}
@@ -0,0 +1,625 @@
+package kibana
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	goversion "github.com/mcuadros/go-version"
+)
+
+const (
+	FilterTypePhrase         FilterType = "phrase"
+	FilterTypePhrases        FilterType = "phrases"
+	FilterTypeRange          FilterType = "range"
+	FilterTypeExists         FilterType = "exists"
+	FilterTypeGeoBoundingBox FilterType = "geo_bounding_box"
+	FilterTypeCustom         FilterType = "custom"
+)
+
+const (
+	FilterStoreApp    = "appState"
+	FilterStoreGlobal = "globalState"
+)
+
+type FilterType string
+
+func (t FilterType) String() string {
+	return string(t)
+}
+
+// FilterParams are the typed params of a search filter
+type FilterParams interface {
+	FilterType() FilterType
+}
+
+// PhraseFilterParams matches the documents whose field contains the phrase
+type PhraseFilterParams struct {
+	Field string
+	Value interface{}
+}
+
+// PhrasesFilterParams matches the documents whose field contains any of the phrases
+type PhrasesFilterParams struct {
+	Field  string
+	Values []interface{}
+}
+
+// RangeFilterParams matches the documents whose field is within the bounds, unset bounds are open
+type RangeFilterParams struct {
+	Field  string
+	Gte    interface{}
+	Gt     interface{}
+	Lte    interface{}
+	Lt     interface{}
+	Format string
+}
+
+// ExistsFilterParams matches the documents which have a value for the field
+type ExistsFilterParams struct {
+	Field string
+}
+
+// GeoBoundingBoxFilterParams matches the documents whose geo point field is within the box
+type GeoBoundingBoxFilterParams struct {
+	Field       string
+	TopLeft     *GeoPoint
+	BottomRight *GeoPoint
+}
+
+// CustomFilterParams is a filter written in the elasticsearch query dsl, such as {"query": {"term": {...}}}
+type CustomFilterParams struct {
+	QueryDsl map[string]interface{}
+}
+
+type searchFilterJson SearchFilter
+
+type searchFilterMetaDataJson SearchFilterMetaData
+
+// searchFilterMetaKeys are the keys SearchFilterMetaData decodes, other keys of a filter meta are kept as they
+// were read
+var searchFilterMetaKeys = map[string]bool{
+	"index":        true,
+	"indexRefName": true,
+	"negate":       true,
+	"disabled":     true,
+	"alias":        true,
+	"type":         true,
+	"key":          true,
+	"value":        true,
+	"params":       true,
+}
+
+type searchFilterQueryAttributesJson SearchFilterQueryAttributes
+
+// searchFilterQueryAttributesKeys are the keys SearchFilterQueryAttributes decodes, other keys are kept as they
+// were read
+var searchFilterQueryAttributesKeys = map[string]bool{
+	"query":        true,
+	"type":         true,
+	"gte":          true,
+	"gt":           true,
+	"lte":          true,
+	"lt":           true,
+	"format":       true,
+	"top_left":     true,
+	"bottom_right": true,
+}
+
+func (params *PhraseFilterParams) FilterType() FilterType {
+	return FilterTypePhrase
+}
+
+func (params *PhrasesFilterParams) FilterType() FilterType {
+	return FilterTypePhrases
+}
+
+func (params *RangeFilterParams) FilterType() FilterType {
+	return FilterTypeRange
+}
+
+func (params *ExistsFilterParams) FilterType() FilterType {
+	return FilterTypeExists
+}
+
+func (params *GeoBoundingBoxFilterParams) FilterType() FilterType {
+	return FilterTypeGeoBoundingBox
+}
+
+func (params *CustomFilterParams) FilterType() FilterType {
+	return FilterTypeCustom
+}
+
+// NewSearchFilter creates a filter on the index pattern with the query and meta kibana uses for the kind of
+// filter in the given version. Phrase filters are match queries before kibana 7.0 and match_phrase queries
+// from 7.0.
+func NewSearchFilter(version string, indexId string, params FilterParams) (*SearchFilter, error) {
+	meta := &SearchFilterMetaData{Index: indexId, Type: params.FilterType().String()}
+	filter := &SearchFilter{Meta: meta, State: &SearchFilterState{Store: FilterStoreApp}}
+
+	switch params := params.(type) {
+	case *PhraseFilterParams:
+		meta.Key = params.Field
+		meta.Value = fmt.Sprint(params.Value)
+		if goversion.Compare(version, "7.0.0", "<") {
+			meta.Params = newPhraseQueryAttributes(params.Value, "phrase")
+			filter.Query = &SearchFilterQuery{Match: map[string]*SearchFilterQueryAttributes{
+				params.Field: newPhraseQueryAttributes(params.Value, "phrase"),
+			}}
+		} else {
+			meta.Params = newPhraseQueryAttributes(params.Value, "")
+			filter.Query = &SearchFilterQuery{MatchPhrase: map[string]interface{}{params.Field: params.Value}}
+		}
+	case *PhrasesFilterParams:
+		if len(params.Values) == 0 {
+			return nil, fmt.Errorf("phrases filter on %s requires at least one phrase", params.Field)
+		}
+
+		values := make([]string, 0, len(params.Values))
+		should := make([]*SearchFilterQuery, 0, len(params.Values))
+		for _, value := range params.Values {
+			values = append(values, fmt.Sprint(value))
+			should = append(should, &SearchFilterQuery{MatchPhrase: map[string]interface{}{params.Field: value}})
+		}
+
+		meta.Key = params.Field
+		meta.Value = strings.Join(values, ", ")
+		meta.Params = &SearchFilterQueryAttributes{Values: params.Values}
+		filter.Query = &SearchFilterQuery{Bool: &SearchFilterBool{Should: should, MinimumShouldMatch: 1}}
+	case *RangeFilterParams:
+		if params.Gte == nil && params.Gt == nil && params.Lte == nil && params.Lt == nil {
+			return nil, fmt.Errorf("range filter on %s requires at least one bound", params.Field)
+		}
+
+		meta.Key = params.Field
+		meta.Value = rangeFilterDisplay(params)
+		meta.Params = &SearchFilterQueryAttributes{Gte: params.Gte, Gt: params.Gt, Lte: params.Lte, Lt: params.Lt, Format: params.Format}
+		filter.Range = map[string]*SearchFilterRange{params.Field: {
+			Gte:    params.Gte,
+			Gt:     params.Gt,
+			Lte:    params.Lte,
+			Lt:     params.Lt,
+			Format: params.Format,
+		}}
+	case *ExistsFilterParams:
+		meta.Key = params.Field
+		meta.Value = "exists"
+		filter.Exists = &SearchFilterExists{Field: params.Field}
+	case *GeoBoundingBoxFilterParams:
+		if params.TopLeft == nil || params.BottomRight == nil {
+			return nil, fmt.Errorf("geo bounding box filter on %s requires the top left and bottom right corners", params.Field)
+		}
+
+		meta.Key = params.Field
+		meta.Value = fmt.Sprintf("%v, %v to %v, %v", params.TopLeft.Lat, params.TopLeft.Lon, params.BottomRight.Lat, params.BottomRight.Lon)
+		meta.Params = &SearchFilterQueryAttributes{TopLeft: params.TopLeft, BottomRight: params.BottomRight}
+		filter.GeoBoundingBox = map[string]*SearchFilterGeoBoundingBox{params.Field: {
+			TopLeft:     params.TopLeft,
+			BottomRight: params.BottomRight,
+		}}
+	case *CustomFilterParams:
+		if len(params.QueryDsl) == 0 {
+			return nil, errors.New("custom filter requires a query dsl")
+		}
+
+		value, err := json.Marshal(params.QueryDsl)
+		if err != nil {
+			return nil, fmt.Errorf("could not encode custom filter query dsl, error: %v", err)
+		}
+
+		meta.Key = "query"
+		meta.Value = string(value)
+		filter.QueryDsl = map[string]json.RawMessage{}
+		for name, query := range params.QueryDsl {
+			data, err := json.Marshal(query)
+			if err != nil {
+				return nil, fmt.Errorf("could not encode custom filter query dsl, error: %v", err)
+			}
+
+			filter.QueryDsl[name] = data
+		}
+	default:
+		return nil, fmt.Errorf("unsupported filter type %s", params.FilterType())
+	}
+
+	return filter, nil
+}
+
+// WithNegate makes the filter exclude the documents it matches
+func (filter *SearchFilter) WithNegate(negate bool) *SearchFilter {
+	filter.metaData().Negate = negate
+	return filter
+}
+
+func (filter *SearchFilter) WithDisabled(disabled bool) *SearchFilter {
+	filter.metaData().Disabled = disabled
+	return filter
+}
+
+// WithAlias sets the label kibana shows for the filter instead of its value
+func (filter *SearchFilter) WithAlias(alias string) *SearchFilter {
+	filter.metaData().Alias = alias
+	return filter
+}
+
+// WithPinned keeps the filter in the global state, so it applies across dashboards, visualize and discover
+func (filter *SearchFilter) WithPinned(pinned bool) *SearchFilter {
+	filter.State = &SearchFilterState{Store: FilterStoreApp}
+	if pinned {
+		filter.State.Store = FilterStoreGlobal
+	}
+
+	return filter
+}
+
+func (filter *SearchFilter) IsPinned() bool {
+	return filter.State != nil && filter.State.Store == FilterStoreGlobal
+}
+
+func (filter *SearchFilter) metaData() *SearchFilterMetaData {
+	if filter.Meta == nil {
+		filter.Meta = &SearchFilterMetaData{}
+	}
+
+	return filter.Meta
+}
+
+// TypedParams decodes the filter into the params of its kind, using the query of the filter rather than its
+// meta which older kibana versions do not always fill in
+func (filter *SearchFilter) TypedParams() (FilterParams, error) {
+	switch {
+	case len(filter.QueryDsl) > 0:
+		queryDsl := map[string]interface{}{}
+		for name, data := range filter.QueryDsl {
+			var query interface{}
+			if err := json.Unmarshal(data, &query); err != nil {
+				return nil, fmt.Errorf("could not parse custom filter query dsl, error: %v", err)
+			}
+
+			queryDsl[name] = query
+		}
+
+		return &CustomFilterParams{QueryDsl: queryDsl}, nil
+	case filter.Exists != nil:
+		return &ExistsFilterParams{Field: filter.Exists.Field}, nil
+	case len(filter.Range) == 1:
+		for field, bounds := range filter.Range {
+			return &RangeFilterParams{Field: field, Gte: bounds.Gte, Gt: bounds.Gt, Lte: bounds.Lte, Lt: bounds.Lt, Format: bounds.Format}, nil
+		}
+	case len(filter.GeoBoundingBox) == 1:
+		for field, box := range filter.GeoBoundingBox {
+			return &GeoBoundingBoxFilterParams{Field: field, TopLeft: box.TopLeft, BottomRight: box.BottomRight}, nil
+		}
+	case filter.Query != nil && filter.Query.Bool != nil:
+		params := &PhrasesFilterParams{}
+		for _, query := range filter.Query.Bool.Should {
+			field, value, ok := phraseQuery(query)
+			if !ok {
+				return nil, errors.New("could not decode phrases filter, every clause must be a phrase query")
+			}
+
+			params.Field = field
+			params.Values = append(params.Values, value)
+		}
+
+		return params, nil
+	case filter.Query != nil:
+		if field, value, ok := phraseQuery(filter.Query); ok {
+			return &PhraseFilterParams{Field: field, Value: value}, nil
+		}
+	}
+
+	return nil, errors.New("could not decode filter, the filter kind is unknown")
+}
+
+// phraseQuery returns the field and phrase of a match query with the phrase type or a match_phrase query
+func phraseQuery(query *SearchFilterQuery) (string, interface{}, bool) {
+	if query == nil {
+		return "", nil, false
+	}
+
+	if len(query.MatchPhrase) == 1 && len(query.Match) == 0 {
+		for field, value := range query.MatchPhrase {
+			if attributes, ok := value.(map[string]interface{}); ok {
+				value = attributes["query"]
+			}
+
+			return field, value, true
+		}
+	}
+
+	if len(query.Match) == 1 && len(query.MatchPhrase) == 0 {
+		for field, attributes := range query.Match {
+			return field, attributes.phrase(), true
+		}
+	}
+
+	return "", nil, false
+}
+
+func rangeFilterDisplay(params *RangeFilterParams) string {
+	from, to := "-Infinity", "Infinity"
+	if params.Gte != nil {
+		from = fmt.Sprint(params.Gte)
+	} else if params.Gt != nil {
+		from = fmt.Sprint(params.Gt)
+	}
+
+	if params.Lt != nil {
+		to = fmt.Sprint(params.Lt)
+	} else if params.Lte != nil {
+		to = fmt.Sprint(params.Lte)
+	}
+
+	return from + " to " + to
+}
+
+// DecodeSearchFilters parses the filters of a searchSourceJSON, such as the kibana saved object meta of a
+// search, visualization or dashboard
+func DecodeSearchFilters(searchSourceJson string) ([]*SearchFilter, error) {
+	var searchSource struct {
+		Filter []*SearchFilter `json:"filter"`
+	}
+
+	if err := json.Unmarshal([]byte(searchSourceJson), &searchSource); err != nil {
+		return nil, fmt.Errorf("could not parse search source filters, error: %v", err)
+	}
+
+	if searchSource.Filter == nil {
+		return []*SearchFilter{}, nil
+	}
+
+	return searchSource.Filter, nil
+}
+
+func (filter *SearchFilter) MarshalJSON() ([]byte, error) {
+	data, err := json.Marshal((*searchFilterJson)(filter))
+	if err != nil || len(filter.QueryDsl) == 0 {
+		return data, err
+	}
+
+	fields := map[string]json.RawMessage{}
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return nil, err
+	}
+
+	for name, query := range filter.QueryDsl {
+		fields[name] = query
+	}
+
+	return json.Marshal(fields)
+}
+
+// UnmarshalJSON decodes the filter, filters whose query is not understood, such as custom filters, keep all
+// their query dsl in QueryDsl so they are encoded again unchanged
+func (filter *SearchFilter) UnmarshalJSON(data []byte) error {
+	fields := map[string]json.RawMessage{}
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return err
+	}
+
+	decoded := &searchFilterJson{}
+	if meta, ok := fields["meta"]; ok {
+		if err := json.Unmarshal(meta, &decoded.Meta); err != nil {
+			return err
+		}
+	}
+
+	if state, ok := fields["$state"]; ok {
+		if err := json.Unmarshal(state, &decoded.State); err != nil {
+			return err
+		}
+	}
+
+	queryDsl := map[string]json.RawMessage{}
+	for name, value := range fields {
+		if name != "meta" && name != "$state" {
+			queryDsl[name] = value
+		}
+	}
+
+	custom := decoded.Meta != nil && decoded.Meta.Type == FilterTypeCustom.String()
+	if !custom {
+		query := &searchFilterJson{}
+		if err := json.Unmarshal(data, query); err != nil || !sameQueryDsl(query, queryDsl) {
+			custom = true
+		} else {
+			decoded.Query = query.Query
+			decoded.Exists = query.Exists
+			decoded.Range = query.Range
+			decoded.GeoBoundingBox = query.GeoBoundingBox
+		}
+	}
+
+	if custom && len(queryDsl) > 0 {
+		decoded.QueryDsl = queryDsl
+	}
+
+	*filter = SearchFilter(*decoded)
+	return nil
+}
+
+// sameQueryDsl checks the typed query of a filter encodes back to the query dsl it was decoded from
+func sameQueryDsl(query *searchFilterJson, queryDsl map[string]json.RawMessage) bool {
+	typed := *query
+	typed.Meta = nil
+	typed.State = nil
+	original, err := json.Marshal(queryDsl)
+	return err == nil && jsonEqualValue(&typed, original)
+}
+
+// UnmarshalJSON decodes the filter meta, keeping what was read so that it is encoded again unchanged
+func (meta *SearchFilterMetaData) UnmarshalJSON(data []byte) error {
+	raw := map[string]json.RawMessage{}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+
+	decoded := &searchFilterMetaDataJson{}
+	if err := json.Unmarshal(data, decoded); err != nil {
+		return err
+	}
+
+	decoded.raw = raw
+	*meta = SearchFilterMetaData(*decoded)
+	return nil
+}
+
+// MarshalJSON encodes the filter meta, the keys which were read and not changed since are encoded as they were
+// read, such as an alias of null or params with keys SearchFilterQueryAttributes does not decode
+func (meta *SearchFilterMetaData) MarshalJSON() ([]byte, error) {
+	data, err := json.Marshal((*searchFilterMetaDataJson)(meta))
+	if err != nil || meta.raw == nil {
+		return data, err
+	}
+
+	fields := map[string]json.RawMessage{}
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return nil, err
+	}
+
+	read, err := meta.readFields()
+	if err != nil {
+		return nil, err
+	}
+
+	for key, value := range fields {
+		original, wasRead := meta.raw[key]
+		switch {
+		case wasRead && jsonEqual(value, read[key]):
+			fields[key] = original
+		case !wasRead && isEmptyJson(value):
+			delete(fields, key)
+		}
+	}
+
+	for key, value := range meta.raw {
+		if !searchFilterMetaKeys[key] {
+			fields[key] = value
+		}
+	}
+
+	return json.Marshal(fields)
+}
+
+// readFields encodes the meta as it was decoded, before any change
+func (meta *SearchFilterMetaData) readFields() (map[string]json.RawMessage, error) {
+	data, err := json.Marshal(meta.raw)
+	if err != nil {
+		return nil, err
+	}
+
+	decoded := &searchFilterMetaDataJson{}
+	if err := json.Unmarshal(data, decoded); err != nil {
+		return nil, err
+	}
+
+	if data, err = json.Marshal(decoded); err != nil {
+		return nil, err
+	}
+
+	fields := map[string]json.RawMessage{}
+	return fields, json.Unmarshal(data, &fields)
+}
+
+// newPhraseQueryAttributes creates the attributes of a phrase, a phrase which is not a string is kept as it is
+// encoded
+func newPhraseQueryAttributes(phrase interface{}, phraseType string) *SearchFilterQueryAttributes {
+	attributes := &SearchFilterQueryAttributes{Type: phraseType}
+	if query, ok := phrase.(string); ok {
+		attributes.Query = query
+		return attributes
+	}
+
+	if data, err := json.Marshal(phrase); err == nil {
+		attributes.raw = map[string]json.RawMessage{"query": data}
+	}
+
+	return attributes
+}
+
+// phrase returns the phrase of the attributes, decoding a phrase which is not a string
+func (attributes *SearchFilterQueryAttributes) phrase() interface{} {
+	if attributes.Query != "" {
+		return attributes.Query
+	}
+
+	var phrase interface{}
+	if data, ok := attributes.raw["query"]; ok && json.Unmarshal(data, &phrase) == nil {
+		return phrase
+	}
+
+	return attributes.Query
+}
+
+// MarshalJSON encodes the attributes, keys which were read and not decoded are encoded as they were read
+// unless the decoded attributes set them
+func (attributes *SearchFilterQueryAttributes) MarshalJSON() ([]byte, error) {
+	if attributes.Values != nil {
+		return json.Marshal(attributes.Values)
+	}
+
+	data, err := json.Marshal((*searchFilterQueryAttributesJson)(attributes))
+	if err != nil || len(attributes.raw) == 0 {
+		return data, err
+	}
+
+	fields := map[string]json.RawMessage{}
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return nil, err
+	}
+
+	for key, value := range attributes.raw {
+		if _, ok := fields[key]; !ok {
+			fields[key] = value
+		}
+	}
+
+	return json.Marshal(fields)
+}
+
+// UnmarshalJSON decodes the attributes, the params of phrases filters are a list of phrases. A query which is
+// not a string and the keys which are not decoded are kept as they were read.
+func (attributes *SearchFilterQueryAttributes) UnmarshalJSON(data []byte) error {
+	if trimmed := strings.TrimSpace(string(data)); strings.HasPrefix(trimmed, "[") {
+		*attributes = SearchFilterQueryAttributes{}
+		return json.Unmarshal(data, &attributes.Values)
+	}
+
+	fields := map[string]json.RawMessage{}
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return err
+	}
+
+	known := map[string]json.RawMessage{}
+	raw := map[string]json.RawMessage{}
+	for key, value := range fields {
+		var query string
+		switch {
+		case key == "query" && json.Unmarshal(value, &query) != nil:
+			raw[key] = value
+		case searchFilterQueryAttributesKeys[key]:
+			known[key] = value
+		default:
+			raw[key] = value
+		}
+	}
+
+	knownData, err := json.Marshal(known)
+	if err != nil {
+		return err
+	}
+
+	decoded := &searchFilterQueryAttributesJson{}
+	if err := json.Unmarshal(knownData, decoded); err != nil {
+		return err
+	}
+
+	if len(raw) > 0 {
+		decoded.raw = raw
+	}
+
+	*attributes = SearchFilterQueryAttributes(*decoded)
+	return nil
+}
@@ -0,0 +1,258 @@
+package kibana
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_NewSearchFilter(t *testing.T) {
+	var testCases = []struct {
+		name          string
+		version       string
+		params        FilterParams
+		expectedJson  string
+		expectedValue string
+	}{
+		{
+			name:          "phrase_6",
+			version:       "6.8.0",
+			params:        &PhraseFilterParams{Field: "geo.src", Value: "CN"},
+			expectedJson:  `{"match":{"geo.src":{"query":"CN","type":"phrase"}}}`,
+			expectedValue: "CN",
+		},
+		{
+			name:          "phrase_7",
+			version:       "7.3.1",
+			params:        &PhraseFilterParams{Field: "geo.src", Value: "CN"},
+			expectedJson:  `{"match_phrase":{"geo.src":"CN"}}`,
+			expectedValue: "CN",
+		},
+		{
+			name:          "phrases",
+			version:       "7.3.1",
+			params:        &PhrasesFilterParams{Field: "geo.src", Values: []interface{}{"CN", "US"}},
+			expectedJson:  `{"bool":{"should":[{"match_phrase":{"geo.src":"CN"}},{"match_phrase":{"geo.src":"US"}}],"minimum_should_match":1}}`,
+			expectedValue: "CN, US",
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			filter, err := NewSearchFilter(testCase.version, "logstash", testCase.params)
+			require.NoError(t, err)
+
+			query, err := json.Marshal(filter.Query)
+			require.NoError(t, err)
+			assert.JSONEq(t, testCase.expectedJson, string(query))
+
+			assert.Equal(t, testCase.params.FilterType().String(), filter.Meta.Type)
+			assert.Equal(t, "geo.src", filter.Meta.Key)
+			assert.Equal(t, testCase.expectedValue, filter.Meta.Value)
+			assert.Equal(t, "logstash", filter.Meta.Index)
+			assert.Equal(t, FilterStoreApp, filter.State.Store)
+
+			params, err := filter.TypedParams()
+			require.NoError(t, err)
+			assert.Equal(t, testCase.params, params)
+		})
+	}
+}
+
+func Test_NewSearchFilter_range_exists_and_geo(t *testing.T) {
+	rangeFilter, err := NewSearchFilter(DefaultKibanaVersion7, "logstash", &RangeFilterParams{Field: "bytes", Gte: 100, Lt: 200})
+	require.NoError(t, err)
+	data, err := json.Marshal(rangeFilter)
+	require.NoError(t, err)
+	assert.JSONEq(t, `{
+		"range": {"bytes": {"gte": 100, "lt": 200}},
+		"meta": {"index": "logstash", "negate": false, "disabled": false, "alias": "", "type": "range", "key": "bytes", "value": "100 to 200", "params": {"gte": 100, "lt": 200}},
+		"$state": {"store": "appState"}
+	}`, string(data))
+
+	existsFilter, err := NewSearchFilter(DefaultKibanaVersion7, "logstash", &ExistsFilterParams{Field: "machine.os"})
+	require.NoError(t, err)
+	assert.Equal(t, "machine.os", existsFilter.Exists.Field)
+	assert.Equal(t, "exists", existsFilter.Meta.Value)
+	assert.Nil(t, existsFilter.Meta.Params)
+
+	box := &GeoBoundingBoxFilterParams{Field: "geo.coordinates", TopLeft: &GeoPoint{Lat: 40, Lon: -74}, BottomRight: &GeoPoint{Lat: 35, Lon: -70}}
+	geoFilter, err := NewSearchFilter(DefaultKibanaVersion7, "logstash", box)
+	require.NoError(t, err)
+	assert.Equal(t, box.TopLeft, geoFilter.GeoBoundingBox["geo.coordinates"].TopLeft)
+	assert.Equal(t, box.BottomRight, geoFilter.Meta.Params.BottomRight)
+
+	_, err = NewSearchFilter(DefaultKibanaVersion7, "logstash", &RangeFilterParams{Field: "bytes"})
+	assert.Error(t, err)
+	_, err = NewSearchFilter(DefaultKibanaVersion7, "logstash", &PhrasesFilterParams{Field: "geo.src"})
+	assert.Error(t, err)
+}
+
+func Test_SearchFilterQueryAttributes_keeps_other_query_shapes(t *testing.T) {
+	filter := &SearchFilter{}
+	data := `{"query":{"match":{"bytes":{"query":1024,"type":"phrase","operator":"and"}}},"meta":{"alias":null,"negate":false,"disabled":false,"type":"phrase","key":"bytes","value":"1,024","params":{"query":1024}}}`
+	require.NoError(t, json.Unmarshal([]byte(data), filter))
+	require.Nil(t, filter.QueryDsl, "a match query with a number is decoded as a typed query")
+	assert.Equal(t, "phrase", filter.Query.Match["bytes"].Type)
+	assert.Empty(t, filter.Query.Match["bytes"].Query)
+
+	params, err := filter.TypedParams()
+	require.NoError(t, err)
+	assert.Equal(t, &PhraseFilterParams{Field: "bytes", Value: float64(1024)}, params)
+
+	encoded, err := json.Marshal(filter)
+	require.NoError(t, err)
+	assert.JSONEq(t, data, string(encoded))
+
+	numeric, err := NewSearchFilter(DefaultKibanaVersion6, "logstash", &PhraseFilterParams{Field: "bytes", Value: 1024})
+	require.NoError(t, err)
+	query, err := json.Marshal(numeric.Query)
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"match":{"bytes":{"query":1024,"type":"phrase"}}}`, string(query))
+}
+
+func Test_SearchFilter_negate_and_pinned(t *testing.T) {
+	filter, err := NewSearchFilter(DefaultKibanaVersion7, "logstash", &ExistsFilterParams{Field: "machine.os"})
+	require.NoError(t, err)
+
+	filter.WithNegate(true).WithAlias("no os").WithPinned(true)
+	assert.True(t, filter.Meta.Negate)
+	assert.Equal(t, "no os", filter.Meta.Alias)
+	assert.True(t, filter.IsPinned())
+	assert.Equal(t, FilterStoreGlobal, filter.State.Store)
+
+	filter.WithPinned(false)
+	assert.False(t, filter.IsPinned())
+}
+
+func Test_SearchFilter_custom_round_trip(t *testing.T) {
+	filter, err := NewSearchFilter(DefaultKibanaVersion7, "logstash", &CustomFilterParams{QueryDsl: map[string]interface{}{
+		"query": map[string]interface{}{"term": map[string]interface{}{"geo.src": "CN"}},
+	}})
+	require.NoError(t, err)
+	assert.Equal(t, "query", filter.Meta.Key)
+	assert.Equal(t, `{"query":{"term":{"geo.src":"CN"}}}`, filter.Meta.Value)
+
+	data, err := json.Marshal(filter)
+	require.NoError(t, err)
+
+	decoded := &SearchFilter{}
+	require.NoError(t, json.Unmarshal(data, decoded))
+	assert.Nil(t, decoded.Query, "custom query dsl is not decoded as a typed query")
+
+	encoded, err := json.Marshal(decoded)
+	require.NoError(t, err)
+	assert.JSONEq(t, string(data), string(encoded))
+}
+
+func Test_DecodeSearchFilters(t *testing.T) {
+	searchSourceJson := `{
+		"index": "logstash",
+		"filter": [
+			{
+				"meta": {"index": "logstash", "negate": true, "disabled": false, "alias": null, "type": "phrase", "key": "geo.src", "value": "CN", "params": {"query": "CN"}},
+				"query": {"match_phrase": {"geo.src": "CN"}},
+				"$state": {"store": "globalState"}
+			},
+			{
+				"meta": {"index": "logstash", "negate": false, "disabled": false, "alias": null, "type": "phrases", "key": "geo.dest", "value": "US, IN", "params": ["US", "IN"]},
+				"query": {"bool": {"should": [{"match_phrase": {"geo.dest": "US"}}, {"match_phrase": {"geo.dest": "IN"}}], "minimum_should_match": 1}},
+				"$state": {"store": "appState"}
+			},
+			{
+				"meta": {"index": "logstash", "negate": false, "disabled": false, "alias": null, "type": "range", "key": "bytes", "value": "0 to 1,000", "params": {"gte": 0, "lt": 1000}},
+				"range": {"bytes": {"gte": 0, "lt": 1000}},
+				"$state": {"store": "appState"}
+			},
+			{
+				"meta": {"index": "logstash", "negate": false, "disabled": false, "alias": null, "type": "phrase", "key": "machine.os", "value": "osx"},
+				"query": {"match_phrase": {"machine.os": {"query": "osx", "slop": 2}}},
+				"$state": {"store": "appState"}
+			},
+			{
+				"meta": {"index": "logstash", "negate": false, "disabled": false, "alias": null, "type": "phrase", "key": "machine.os", "value": "win*"},
+				"query": {"query_string": {"query": "machine.os:win*"}},
+				"$state": {"store": "appState"}
+			}
+		]
+	}`
+
+	filters, err := DecodeSearchFilters(searchSourceJson)
+	require.NoError(t, err)
+	require.Len(t, filters, 5)
+
+	params, err := filters[0].TypedParams()
+	require.NoError(t, err)
+	assert.Equal(t, &PhraseFilterParams{Field: "geo.src", Value: "CN"}, params)
+	assert.True(t, filters[0].Meta.Negate)
+	assert.True(t, filters[0].IsPinned())
+
+	params, err = filters[1].TypedParams()
+	require.NoError(t, err)
+	assert.Equal(t, &PhrasesFilterParams{Field: "geo.dest", Values: []interface{}{"US", "IN"}}, params)
+	assert.Equal(t, []interface{}{"US", "IN"}, filters[1].Meta.Params.Values)
+
+	params, err = filters[2].TypedParams()
+	require.NoError(t, err)
+	assert.Equal(t, &RangeFilterParams{Field: "bytes", Gte: float64(0), Lt: float64(1000)}, params)
+
+	encoded, err := json.Marshal(filters[3])
+	require.NoError(t, err)
+	assert.Contains(t, string(encoded), `"slop":2`)
+
+	assert.Nil(t, filters[4].Query)
+	assert.NotEmpty(t, filters[4].QueryDsl, "a query the typed model can not hold is kept as query dsl")
+	encoded, err = json.Marshal(filters[4])
+	require.NoError(t, err)
+	assert.Contains(t, string(encoded), `"query_string":{"query":"machine.os:win*"}`)
+
+	filters, err = DecodeSearchFilters(`{"index": "logstash"}`)
+	require.NoError(t, err)
+	assert.Empty(t, filters)
+}
+
+func Test_SearchCreate_with_typed_filters(t *testing.T) {
+	client := DefaultTestKibanaClient()
+	searchApi := client.Search()
+	indexId := client.Config.DefaultIndexId
+
+	phrase, err := NewSearchFilter(client.Config.KibanaVersion, indexId, &PhraseFilterParams{Field: "geo.src", Value: "CN"})
+	require.NoError(t, err)
+	bytesRange, err := NewSearchFilter(client.Config.KibanaVersion, indexId, &RangeFilterParams{Field: "bytes", Gte: 100})
+	require.NoError(t, err)
+
+	requestSearch, err := searchApi.NewSearchSource().
+		WithIndexId(indexId).
+		WithFilter(phrase.WithPinned(true)).
+		WithFilter(bytesRange.WithNegate(true)).
+		Build()
+	require.NoError(t, err)
+
+	request, err := NewSearchRequestBuilder().
+		WithTitle("Typed filters").
+		WithDisplayColumns([]string{"_source"}).
+		WithSortColumns([]string{"@timestamp"}, Descending).
+		WithSearchSource(requestSearch).
+		Build()
+	require.NoError(t, err)
+
+	response, err := searchApi.Create(request)
+	require.NoError(t, err)
+	defer searchApi.Delete(response.Id)
+
+	filters, err := DecodeSearchFilters(response.Attributes.KibanaSavedObjectMeta.SearchSourceJSON)
+	require.NoError(t, err)
+	require.Len(t, filters, 2)
+
+	params, err := filters[0].TypedParams()
+	require.NoError(t, err)
+	assert.Equal(t, &PhraseFilterParams{Field: "geo.src", Value: "CN"}, params)
+	assert.True(t, filters[0].IsPinned())
+
+	params, err = filters[1].TypedParams()
+	require.NoError(t, err)
+	assert.Equal(t, &RangeFilterParams{Field: "bytes", Gte: float64(100)}, params)
+	assert.True(t, filters[1].Meta.Negate)
+}
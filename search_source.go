@@ -0,0 +1,294 @@
+package kibana
+
+import (
+	"encoding/json"
+	"errors"
+	"reflect"
+)
+
+// searchSourceKeys are the keys SearchSource decodes, other keys of a search source are kept as they were read
+var searchSourceKeys = map[string]bool{
+	"index":        true,
+	"indexRefName": true,
+	"highlightAll": true,
+	"version":      true,
+	"query":        true,
+	"filter":       true,
+}
+
+// SearchSourceQuery is the text and language of a search source query, whichever form kibana stored it in
+type SearchSourceQuery struct {
+	Query    string
+	Language string
+}
+
+type searchSourceJson SearchSource
+
+type searchKibanaSavedObjectMetaJson struct {
+	SearchSourceJSON string `json:"searchSourceJSON"`
+}
+
+// UnmarshalJSON decodes the saved object meta, a search source which can not be decoded leaves SearchSource nil
+// and is still saved unchanged
+func (meta *SearchKibanaSavedObjectMeta) UnmarshalJSON(data []byte) error {
+	decoded := &searchKibanaSavedObjectMetaJson{}
+	if err := json.Unmarshal(data, decoded); err != nil {
+		return err
+	}
+
+	*meta = SearchKibanaSavedObjectMeta{SearchSourceJSON: decoded.SearchSourceJSON, decodedJson: decoded.SearchSourceJSON}
+	if decoded.SearchSourceJSON != "" {
+		searchSource := &SearchSource{}
+		if err := json.Unmarshal([]byte(decoded.SearchSourceJSON), searchSource); err == nil {
+			meta.SearchSource = searchSource
+		}
+	}
+
+	return nil
+}
+
+// searchSource returns the decoded search source, decoding SearchSourceJSON when it was set or changed after the
+// saved object was read. It returns nil when there is no search source.
+func (meta *SearchKibanaSavedObjectMeta) searchSource() (*SearchSource, error) {
+	if meta.SearchSource != nil && meta.SearchSourceJSON == meta.decodedJson {
+		return meta.SearchSource, nil
+	}
+
+	if meta.SearchSourceJSON == "" {
+		return nil, nil
+	}
+
+	searchSource := &SearchSource{}
+	if err := json.Unmarshal([]byte(meta.SearchSourceJSON), searchSource); err != nil {
+		return nil, err
+	}
+
+	return searchSource, nil
+}
+
+func (meta *SearchKibanaSavedObjectMeta) MarshalJSON() ([]byte, error) {
+	encoded := &searchKibanaSavedObjectMetaJson{SearchSourceJSON: meta.SearchSourceJSON}
+	if meta.SearchSource != nil && meta.SearchSourceJSON == meta.decodedJson {
+		data, err := json.Marshal(meta.SearchSource)
+		if err != nil {
+			return nil, err
+		}
+
+		if !jsonEqual(data, []byte(meta.decodedJson)) {
+			encoded.SearchSourceJSON = string(data)
+		}
+	}
+
+	return json.Marshal(encoded)
+}
+
+// UnmarshalJSON decodes the search source, keeping what was read so that it is encoded again without losing
+// the keys SearchSource does not decode
+func (source *SearchSource) UnmarshalJSON(data []byte) error {
+	raw := map[string]json.RawMessage{}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+
+	decoded := &searchSourceJson{}
+	if err := json.Unmarshal(data, decoded); err != nil {
+		return err
+	}
+
+	if query, ok := raw["query"]; ok {
+		decoded.Query = decodeSearchSourceQuery(query)
+	}
+
+	decoded.raw = raw
+	*source = SearchSource(*decoded)
+	return nil
+}
+
+func (source *SearchSource) MarshalJSON() ([]byte, error) {
+	data, err := json.Marshal((*searchSourceJson)(source))
+	if err != nil || source.raw == nil {
+		return data, err
+	}
+
+	fields := map[string]json.RawMessage{}
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return nil, err
+	}
+
+	for key, value := range fields {
+		if _, read := source.raw[key]; !read && isEmptyJson(value) {
+			delete(fields, key)
+		}
+	}
+
+	for key, value := range source.raw {
+		if !searchSourceKeys[key] {
+			fields[key] = value
+		}
+	}
+
+	return json.Marshal(fields)
+}
+
+// TypedQuery returns the text and language of the query, kibana 5.x queries are lucene query_string queries
+// which dashboards keep as their first filter. It returns nil when the search source has no query.
+func (source *SearchSource) TypedQuery() (*SearchSourceQuery, error) {
+	switch query := source.Query.(type) {
+	case nil:
+		for _, filter := range source.Filter {
+			if legacyQuery := legacyQueryFilter(filter); legacyQuery != nil {
+				return &SearchSourceQuery{Query: legacyQuery.QueryString.Query, Language: QueryLanguageLucene}, nil
+			}
+		}
+
+		return nil, nil
+	case *SearchQuery600:
+		return &SearchSourceQuery{Query: query.Query, Language: query.Language}, nil
+	case *SearchQuery553:
+		typed := &SearchSourceQuery{Language: QueryLanguageLucene}
+		if query.QueryString != nil {
+			typed.Query = query.QueryString.Query
+		}
+
+		return typed, nil
+	}
+
+	data, err := json.Marshal(source.Query)
+	if err != nil {
+		return nil, err
+	}
+
+	var query struct {
+		Query       json.RawMessage    `json:"query"`
+		Language    string             `json:"language"`
+		QueryString *searchQueryString `json:"query_string"`
+	}
+
+	if err := json.Unmarshal(data, &query); err != nil {
+		return nil, errors.New("could not decode search source query, the query is not an object")
+	}
+
+	if query.QueryString != nil {
+		return &SearchSourceQuery{Query: query.QueryString.Query, Language: QueryLanguageLucene}, nil
+	}
+
+	typed := &SearchSourceQuery{Language: query.Language}
+	if typed.Language == "" {
+		typed.Language = QueryLanguageLucene
+	}
+
+	// kibana 6.x migrated the lucene queries of 5.x searches to a query_string query inside the query
+	var text string
+	var nested SearchQuery553
+	if err := json.Unmarshal(query.Query, &text); err == nil {
+		typed.Query = text
+	} else if err := json.Unmarshal(query.Query, &nested); err == nil && nested.QueryString != nil {
+		typed.Query = nested.QueryString.Query
+	} else {
+		return nil, errors.New("could not decode search source query, the query is neither text nor a query_string query")
+	}
+
+	return typed, nil
+}
+
+// legacyQueryFilter returns the query of a filter holding the query of a kibana 5.x dashboard, a query_string
+// query without meta, or nil for other filters
+func legacyQueryFilter(filter *SearchFilter) *SearchQuery553 {
+	if filter == nil || filter.Meta != nil || len(filter.QueryDsl) != 1 {
+		return nil
+	}
+
+	query := &SearchQuery553{}
+	if data, ok := filter.QueryDsl["query"]; !ok || json.Unmarshal(data, query) != nil || query.QueryString == nil {
+		return nil
+	}
+
+	return query
+}
+
+// indexPatternId returns the index pattern of the search source, resolving the index reference name of 7.x
+func (source *SearchSource) indexPatternId(referenceId func(name string) string) string {
+	if source.IndexId != "" || source.IndexRefName == "" {
+		return source.IndexId
+	}
+
+	return referenceId(source.IndexRefName)
+}
+
+// IndexPatternId returns the id of the index pattern the search reads from
+func (search *Search) IndexPatternId() string {
+	if search.Attributes == nil || search.Attributes.KibanaSavedObjectMeta == nil || search.Attributes.KibanaSavedObjectMeta.SearchSource == nil {
+		return ""
+	}
+
+	return search.Attributes.KibanaSavedObjectMeta.SearchSource.indexPatternId(func(name string) string {
+		for _, reference := range search.References {
+			if reference.Name == name {
+				return reference.Id
+			}
+		}
+
+		return ""
+	})
+}
+
+// IndexPatternId returns the id of the index pattern the visualization reads from, which is empty for
+// visualizations of a saved search
+func (visualization *Visualization) IndexPatternId() string {
+	if visualization.Attributes == nil || visualization.Attributes.KibanaSavedObjectMeta == nil || visualization.Attributes.KibanaSavedObjectMeta.SearchSource == nil {
+		return ""
+	}
+
+	return visualization.Attributes.KibanaSavedObjectMeta.SearchSource.indexPatternId(func(name string) string {
+		for _, reference := range visualization.References {
+			if reference.Name == name {
+				return reference.Id
+			}
+		}
+
+		return ""
+	})
+}
+
+// decodeSearchSourceQuery decodes the query into the type kibana wrote it as, a query which would not be encoded
+// again unchanged is kept as generic json
+func decodeSearchSourceQuery(data json.RawMessage) interface{} {
+	query600 := &SearchQuery600{}
+	if err := json.Unmarshal(data, query600); err == nil && query600.Language != "" && jsonEqualValue(query600, data) {
+		return query600
+	}
+
+	query553 := &SearchQuery553{}
+	if err := json.Unmarshal(data, query553); err == nil && query553.QueryString != nil && jsonEqualValue(query553, data) {
+		return query553
+	}
+
+	var query interface{}
+	json.Unmarshal(data, &query)
+	return query
+}
+
+func isEmptyJson(value json.RawMessage) bool {
+	switch string(value) {
+	case `""`, "false", "null":
+		return true
+	}
+
+	return false
+}
+
+// jsonEqualValue checks the value encodes to json semantically equal to data
+func jsonEqualValue(value interface{}, data []byte) bool {
+	encoded, err := json.Marshal(value)
+	return err == nil && jsonEqual(encoded, data)
+}
+
+// jsonEqual checks two json documents are equal regardless of key order and formatting
+func jsonEqual(left []byte, right []byte) bool {
+	var leftValue, rightValue interface{}
+	if json.Unmarshal(left, &leftValue) != nil || json.Unmarshal(right, &rightValue) != nil {
+		return false
+	}
+
+	return reflect.DeepEqual(leftValue, rightValue)
+}
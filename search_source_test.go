@@ -0,0 +1,172 @@
+package kibana
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_SearchKibanaSavedObjectMeta_decodes_search_source(t *testing.T) {
+	searchSourceJson := `{"indexRefName":"kibanaSavedObjectMeta.searchSourceJSON.index","query":{"query":"geo.src:CN","language":"kuery"},"filter":[],"highlight":{"fields":{"*":{}}}}`
+	data, err := json.Marshal(map[string]string{"searchSourceJSON": searchSourceJson})
+	require.NoError(t, err)
+
+	meta := &SearchKibanaSavedObjectMeta{}
+	require.NoError(t, json.Unmarshal(data, meta))
+	require.NotNil(t, meta.SearchSource)
+	assert.Equal(t, "kibanaSavedObjectMeta.searchSourceJSON.index", meta.SearchSource.IndexRefName)
+	assert.Equal(t, &SearchQuery600{Query: "geo.src:CN", Language: QueryLanguageKuery}, meta.SearchSource.Query)
+
+	encoded, err := json.Marshal(meta)
+	require.NoError(t, err)
+	assert.Equal(t, string(data), string(encoded), "an unchanged search source is saved as it was read")
+
+	meta.SearchSource.Query = &SearchQuery600{Query: "geo.src:US", Language: QueryLanguageKuery}
+	encoded, err = json.Marshal(meta)
+	require.NoError(t, err)
+
+	reread := &SearchKibanaSavedObjectMeta{}
+	require.NoError(t, json.Unmarshal(encoded, reread))
+	assert.JSONEq(t, `{"indexRefName":"kibanaSavedObjectMeta.searchSourceJSON.index","query":{"query":"geo.src:US","language":"kuery"},"filter":[],"highlight":{"fields":{"*":{}}}}`, reread.SearchSourceJSON)
+
+	meta.SearchSourceJSON = `{"index":"logstash"}`
+	encoded, err = json.Marshal(meta)
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"searchSourceJSON":"{\"index\":\"logstash\"}"}`, string(encoded), "a changed search source json takes precedence")
+}
+
+func Test_SearchKibanaSavedObjectMeta_keeps_filter_meta(t *testing.T) {
+	searchSourceJson := `{"highlightAll":true,"version":true,"query":{"query":"","language":"kuery"},"filter":[{"meta":{"controlledBy":"1580211617193","index":"90943e30-9a47-11e8-b64d-95841ca0b247","type":"phrase","key":"geo.src","params":{"query":"CN"},"disabled":false,"negate":false,"alias":null},"query":{"match_phrase":{"geo.src":"CN"}},"$state":{"store":"appState"}},{"meta":{"alias":null,"negate":false,"disabled":false,"type":"exists","key":"machine.os","value":"exists","indexRefName":"kibanaSavedObjectMeta.searchSourceJSON.filter[1].meta.index"},"exists":{"field":"machine.os"},"$state":{"store":"appState"}}],"indexRefName":"kibanaSavedObjectMeta.searchSourceJSON.index"}`
+	data, err := json.Marshal(map[string]string{"searchSourceJSON": searchSourceJson})
+	require.NoError(t, err)
+
+	meta := &SearchKibanaSavedObjectMeta{}
+	require.NoError(t, json.Unmarshal(data, meta))
+	require.NotNil(t, meta.SearchSource)
+	require.Len(t, meta.SearchSource.Filter, 2)
+	assert.Empty(t, meta.SearchSource.Filter[0].Meta.Alias)
+
+	encoded, err := json.Marshal(meta)
+	require.NoError(t, err)
+	assert.Equal(t, string(data), string(encoded), "an unchanged search source is saved byte for byte")
+
+	meta.SearchSource.Filter[1].WithNegate(true)
+	encoded, err = json.Marshal(meta)
+	require.NoError(t, err)
+
+	reread := &SearchKibanaSavedObjectMeta{}
+	require.NoError(t, json.Unmarshal(encoded, reread))
+	var searchSource struct {
+		Filter []map[string]interface{} `json:"filter"`
+	}
+	require.NoError(t, json.Unmarshal([]byte(reread.SearchSourceJSON), &searchSource))
+	assert.Equal(t, map[string]interface{}{"controlledBy": "1580211617193", "index": "90943e30-9a47-11e8-b64d-95841ca0b247", "type": "phrase", "key": "geo.src", "params": map[string]interface{}{"query": "CN"}, "disabled": false, "negate": false, "alias": nil},
+		searchSource.Filter[0]["meta"], "the meta of an input control filter keeps its controlledBy")
+	assert.Equal(t, map[string]interface{}{"alias": nil, "negate": true, "disabled": false, "type": "exists", "key": "machine.os", "value": "exists", "indexRefName": "kibanaSavedObjectMeta.searchSourceJSON.filter[1].meta.index"},
+		searchSource.Filter[1]["meta"])
+}
+
+func Test_SearchKibanaSavedObjectMeta_keeps_invalid_search_source(t *testing.T) {
+	meta := &SearchKibanaSavedObjectMeta{}
+	require.NoError(t, json.Unmarshal([]byte(`{"searchSourceJSON":"not json"}`), meta))
+	assert.Nil(t, meta.SearchSource)
+
+	encoded, err := json.Marshal(meta)
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"searchSourceJSON":"not json"}`, string(encoded))
+}
+
+func Test_SearchSource_TypedQuery(t *testing.T) {
+	var testCases = []struct {
+		name             string
+		searchSourceJson string
+		expected         *SearchSourceQuery
+	}{
+		{
+			name:             "6.x",
+			searchSourceJson: `{"query":{"query":"geo.src:CN","language":"lucene"}}`,
+			expected:         &SearchSourceQuery{Query: "geo.src:CN", Language: QueryLanguageLucene},
+		},
+		{
+			name:             "5.x",
+			searchSourceJson: `{"query":{"query_string":{"query":"geo.src:CN","analyze_wildcard":true}}}`,
+			expected:         &SearchSourceQuery{Query: "geo.src:CN", Language: QueryLanguageLucene},
+		},
+		{
+			name:             "migrated_5.x",
+			searchSourceJson: `{"query":{"query":{"query_string":{"query":"geo.src:CN"}},"language":"lucene"}}`,
+			expected:         &SearchSourceQuery{Query: "geo.src:CN", Language: QueryLanguageLucene},
+		},
+		{
+			name:             "dashboard_5.x",
+			searchSourceJson: `{"filter":[{"query":{"query_string":{"query":"geo.src:CN","analyze_wildcard":true}}}],"highlightAll":true,"version":true}`,
+			expected:         &SearchSourceQuery{Query: "geo.src:CN", Language: QueryLanguageLucene},
+		},
+		{
+			name:             "no_query",
+			searchSourceJson: `{"index":"logstash"}`,
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			source := &SearchSource{}
+			require.NoError(t, json.Unmarshal([]byte(testCase.searchSourceJson), source))
+
+			query, err := source.TypedQuery()
+			require.NoError(t, err)
+			assert.Equal(t, testCase.expected, query)
+
+			encoded, err := json.Marshal(source)
+			require.NoError(t, err)
+			assert.JSONEq(t, testCase.searchSourceJson, string(encoded))
+		})
+	}
+}
+
+func Test_SearchGetById_decodes_search_source(t *testing.T) {
+	client := DefaultTestKibanaClient()
+	searchClient := client.Search()
+
+	requestSearch, err := searchClient.NewSearchSource().
+		WithIndexId(client.Config.DefaultIndexId).
+		WithQuery("geo.src:CN").
+		WithFilter(&SearchFilter{Exists: &SearchFilterExists{Field: "machine.os"}, Meta: &SearchFilterMetaData{Index: client.Config.DefaultIndexId, Type: "exists", Key: "machine.os", Value: "exists"}}).
+		Build()
+	require.NoError(t, err)
+
+	request, err := NewSearchRequestBuilder().
+		WithTitle("Typed search source").
+		WithDisplayColumns([]string{"_source"}).
+		WithSortColumns([]string{"@timestamp"}, Descending).
+		WithSearchSource(requestSearch).
+		Build()
+	require.NoError(t, err)
+
+	search, err := searchClient.Create(request)
+	require.NoError(t, err)
+	defer searchClient.Delete(search.Id)
+
+	search, err = searchClient.GetById(search.Id)
+	require.NoError(t, err)
+
+	searchSource := search.Attributes.KibanaSavedObjectMeta.SearchSource
+	require.NotNil(t, searchSource)
+	assert.Equal(t, client.Config.DefaultIndexId, search.IndexPatternId())
+	require.Len(t, searchSource.Filter, 1)
+	assert.Equal(t, "machine.os", searchSource.Filter[0].Exists.Field)
+
+	query, err := searchSource.TypedQuery()
+	require.NoError(t, err)
+	assert.Equal(t, &SearchSourceQuery{Query: "geo.src:CN", Language: QueryLanguageLucene}, query)
+
+	searchSource.Filter[0].WithNegate(true)
+	_, err = searchClient.Update(search.Id, &UpdateSearchRequest{Attributes: search.Attributes, References: search.References})
+	require.NoError(t, err)
+
+	search, err = searchClient.GetById(search.Id)
+	require.NoError(t, err)
+	assert.True(t, search.Attributes.KibanaSavedObjectMeta.SearchSource.Filter[0].Meta.Negate)
+}
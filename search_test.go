@@ -2,6 +2,7 @@ package kibana
 
 import (
 	"encoding/json"
+	"strings"
 	"testing"
 
 	goversion "github.com/mcuadros/go-version"
@@ -14,12 +15,43 @@ func Test_SortUnmarshalJSON(t *testing.T) {
 	s := &Sort{}
 	err := json.Unmarshal([]byte(`["@timestamp", "desc"]`), s)
 	assert.NoError(t, err)
-	assert.Equal(t, &Sort{"@timestamp", "desc"}, s)
+	assert.Equal(t, &Sort{{Field: "@timestamp", Order: Descending}}, s)
 
 	s = &Sort{}
 	err = json.Unmarshal([]byte(`[["@name", "desc"]]`), s)
 	assert.NoError(t, err)
-	assert.Equal(t, &Sort{"@name", "desc"}, s)
+	assert.Equal(t, &Sort{{Field: "@name", Order: Descending}}, s)
+
+	s = &Sort{}
+	err = json.Unmarshal([]byte(`[["@timestamp", "desc"], ["bytes", "asc"]]`), s)
+	assert.NoError(t, err)
+	assert.Equal(t, &Sort{{Field: "@timestamp", Order: Descending}, {Field: "bytes", Order: Ascending}}, s)
+
+	s = &Sort{}
+	err = json.Unmarshal([]byte(`["@timestamp", "bytes", "desc"]`), s)
+	assert.NoError(t, err)
+	assert.Equal(t, &Sort{{Field: "@timestamp", Order: Descending}, {Field: "bytes", Order: Descending}}, s)
+}
+
+func Test_SearchAttributes_sort_for_version(t *testing.T) {
+	attributes := &SearchAttributes{Sort: Sort{{Field: "@timestamp", Order: Descending}, {Field: "bytes", Order: Descending}}}
+
+	encoded, err := attributes.forVersion("7.4.0")
+	require.NoError(t, err)
+	data, err := json.Marshal(encoded)
+	require.NoError(t, err)
+	assert.Contains(t, string(data), `"sort":[["@timestamp","desc"],["bytes","desc"]]`)
+
+	encoded, err = attributes.forVersion("6.8.0")
+	require.NoError(t, err)
+	data, err = json.Marshal(encoded)
+	require.NoError(t, err)
+	assert.Contains(t, string(data), `"sort":["@timestamp","bytes","desc"]`)
+	assert.Equal(t, 1, strings.Count(string(data), `"sort"`))
+
+	attributes.Sort[1].Order = Ascending
+	_, err = attributes.forVersion("6.8.0")
+	assert.EqualError(t, err, "sorting fields in different directions is not supported by kibana 6.8.0, it requires kibana 7.4 or later")
 }
 
 func Test_SearchCreate(t *testing.T) {
@@ -461,3 +493,56 @@ func createSearchRequest(factory SearchSourceBuilderFactory, indexId string, t *
 
 	return request, requestSearch, err
 }
+
+func Test_SearchCreate_with_kql(t *testing.T) {
+	client := DefaultTestKibanaClient()
+	searchApi := client.Search()
+
+	requestSearch, err := searchApi.NewSearchSource().
+		WithIndexId(client.Config.DefaultIndexId).
+		WithKQL("geo.src:CN and not response:404").
+		Build()
+
+	if goversion.Compare(client.Config.KibanaVersion, "6.3.0", "<") {
+		assert.Error(t, err, "kql requires kibana 6.3")
+		return
+	}
+
+	require.NoError(t, err)
+	assert.Equal(t, &SearchQuery600{Query: "geo.src:CN and not response:404", Language: QueryLanguageKuery}, requestSearch.Query)
+
+	request, err := NewSearchRequestBuilder().
+		WithTitle("Geography kql search on china").
+		WithDisplayColumns([]string{"_source"}).
+		WithSortColumns([]string{"@timestamp"}, Descending).
+		WithSearchSource(requestSearch).
+		Build()
+	require.NoError(t, err)
+
+	response, err := searchApi.Create(request)
+	require.NoError(t, err)
+	defer searchApi.Delete(response.Id)
+
+	responseSearch := map[string]interface{}{}
+	require.NoError(t, json.Unmarshal([]byte(response.Attributes.KibanaSavedObjectMeta.SearchSourceJSON), &responseSearch))
+	assert.Equal(t, map[string]interface{}{"query": "geo.src:CN and not response:404", "language": "kuery"}, responseSearch["query"])
+}
+
+func Test_SearchSourceBuilder_rejects_invalid_kql(t *testing.T) {
+	builder := &searchSourceBuilder600{kibanaVersion: DefaultKibanaVersion7}
+	_, err := builder.WithKQL("geo.src:(CN or").Build()
+	assert.EqualError(t, err, "invalid kql query, unexpected end of query")
+
+	_, err = builder.WithQuery("geo.src:CN").WithQueryLanguage("sql").Build()
+	assert.EqualError(t, err, "unsupported query language sql")
+
+	_, err = (&searchSourceBuilder600{kibanaVersion: "6.2.4"}).WithKQL("geo.src:CN").Build()
+	assert.EqualError(t, err, "kql queries are not supported by kibana 6.2.4, they require kibana 6.3 or later")
+
+	_, err = (&searchSourceBuilder553{}).WithKQL("geo.src:CN").Build()
+	assert.EqualError(t, err, "kql queries are not supported by kibana 5.x, they require kibana 6.3 or later")
+
+	source, err := (&searchSourceBuilder600{}).WithQueryLanguage(QueryLanguageKuery).WithQuery("geo.src:CN").Build()
+	assert.NoError(t, err)
+	assert.Equal(t, &SearchQuery600{Query: "geo.src:CN", Language: QueryLanguageKuery}, source.Query)
+}
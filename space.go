@@ -37,6 +37,7 @@ type DefaultSpaceClient struct {
 func (api *DefaultSpaceClient) Create(request *Space) error {
 	response, body, err := api.client.
 		Post(api.config.KibanaBaseUri+"/api/spaces/space").
+		Operation("Space.Create", "space", request.Id).
 		Set("kbn-version", api.config.KibanaVersion).
 		Send(request).
 		End()
@@ -56,6 +57,7 @@ func (api *DefaultSpaceClient) Update(request *Space) error {
 	id := request.Id
 	response, body, err := api.client.
 		Put(api.config.KibanaBaseUri+"/api/spaces/space/"+id).
+		Operation("Space.Update", "space", id).
 		Set("kbn-version", api.config.KibanaVersion).
 		Send(request).
 		End()
@@ -74,6 +76,7 @@ func (api *DefaultSpaceClient) Update(request *Space) error {
 func (api *DefaultSpaceClient) GetByID(id string) (*Space, error) {
 	response, body, err := api.client.
 		Get(api.config.KibanaBaseUri+"/api/spaces/space/"+id).
+		Operation("Space.GetByID", "space", id).
 		Set("kbn-version", api.config.KibanaVersion).
 		End()
 	if err != nil {
@@ -99,6 +102,7 @@ func (api *DefaultSpaceClient) GetByID(id string) (*Space, error) {
 func (api *DefaultSpaceClient) Delete(id string) error {
 	response, body, err := api.client.
 		Delete(api.config.KibanaBaseUri+"/api/spaces/space/"+id).
+		Operation("Space.Delete", "space", id).
 		Set("kbn-version", api.config.KibanaVersion).
 		End()
 	if err != nil {
@@ -3,6 +3,7 @@ package kibana
 import (
 	"log"
 	"os"
+	"path/filepath"
 	"strings"
 	"testing"
 
@@ -49,8 +50,13 @@ func getAuthForContainerVersion(version string, kibanaType KibanaType) Authentic
 	return handler[kibanaType]
 }
 
+const envRecorderMode = "KIBANA_RECORDER_MODE"
+const envCassette = "KIBANA_CASSETTE"
+
+var testRecorder *Recorder
+
 func RunTestsWithoutContainers(m *testing.M) {
-	code := m.Run()
+	code := runTests(m)
 	os.Exit(code)
 }
 
@@ -60,28 +66,108 @@ func RunTestsWithContainers(m *testing.M, client *KibanaClient) {
 		log.Fatalf("Could not start kibana: %v", err)
 	}
 
-	err = os.Setenv(EnvKibanaUri, testContext.KibanaUri)
+	setTestEnvironment(testContext.KibanaUri, testContext.KibanaIndexId)
+
+	code := runTests(m)
+
+	if client.Config.KibanaType == KibanaTypeVanilla {
+		stopKibana(testContext)
+	}
+
+	os.Exit(code)
+}
+
+// RunTestsWithFakeKibana runs the tests against an in-memory FakeKibana instead of docker containers
+func RunTestsWithFakeKibana(m *testing.M, client *KibanaClient) {
+	fake := NewFakeKibana(client.Config.KibanaVersion)
+	client.Config.KibanaBaseUri = fake.URL()
+
+	indexId, err := createDefaultIndexPattern(client)
+	if err != nil {
+		log.Fatalf("Could not create index pattern in fake kibana: %v", err)
+	}
+
+	setTestEnvironment(fake.URL(), indexId)
+
+	code := runTests(m)
+	fake.Close()
+	os.Exit(code)
+}
+
+// RunTestsWithReplay runs the tests against the interactions recorded in the cassette named by
+// KIBANA_CASSETTE, without starting kibana
+func RunTestsWithReplay(m *testing.M) {
+	recorder, err := NewRecorder(testCassettePath(), RecorderModeReplay)
+	if err != nil {
+		log.Fatalf("Could not load cassette: %v", err)
+	}
+
+	setTestEnvironment(recorder.Cassette.Values[EnvKibanaUri], recorder.Cassette.Values[EnvKibanaIndexId])
+
+	testRecorder = recorder
+	code := m.Run()
+	os.Exit(code)
+}
+
+// UseFakeKibana reports whether USE_FAKE_KIBANA asks for the tests to run against a FakeKibana
+func UseFakeKibana() bool {
+	_, useFakeKibana := os.LookupEnv("USE_FAKE_KIBANA")
+	return useFakeKibana
+}
+
+// UseReplay reports whether KIBANA_RECORDER_MODE asks for the tests to be replayed from a cassette
+func UseReplay() bool {
+	return ParseRecorderMode(os.Getenv(envRecorderMode)) == RecorderModeReplay
+}
+
+func setTestEnvironment(kibanaUri string, kibanaIndexId string) {
+	err := os.Setenv(EnvKibanaUri, kibanaUri)
 	if err != nil {
 		log.Fatalf("Could not set kibana uri env variable: %v", err)
 	}
 
-	err = os.Setenv(EnvKibanaIndexId, testContext.KibanaIndexId)
+	err = os.Setenv(EnvKibanaIndexId, kibanaIndexId)
 	if err != nil {
 		log.Fatalf("Could not set kibana index id env variable: %v", err)
 	}
+}
 
-	code := m.Run()
+// runTests runs the tests, recording the interactions with kibana to a cassette when KIBANA_RECORDER_MODE is record
+func runTests(m *testing.M) int {
+	if ParseRecorderMode(os.Getenv(envRecorderMode)) != RecorderModeRecord {
+		return m.Run()
+	}
 
-	if client.Config.KibanaType == KibanaTypeVanilla {
-		stopKibana(testContext)
+	recorder, err := NewRecorder(testCassettePath(), RecorderModeRecord)
+	if err != nil {
+		log.Fatalf("Could not create recorder: %v", err)
 	}
 
-	os.Exit(code)
+	recorder.RedactedSecrets = []string{os.Getenv(EnvKibanaPassword), os.Getenv(EnvLogzMfaSecret)}
+	recorder.Cassette.Values[EnvKibanaUri] = os.Getenv(EnvKibanaUri)
+	recorder.Cassette.Values[EnvKibanaIndexId] = os.Getenv(EnvKibanaIndexId)
+
+	testRecorder = recorder
+	code := m.Run()
+	if err := recorder.Stop(); err != nil {
+		log.Printf("Could not save cassette: %v", err)
+		return 1
+	}
+
+	return code
+}
+
+func testCassettePath() string {
+	return GetEnvVarOrDefault(envCassette, filepath.Join("testdata", "cassettes", "kibana-"+GetEnvVarOrDefault("ELK_VERSION", DefaultKibanaVersion6)+".json"))
 }
 
 func DefaultTestKibanaClient() *KibanaClient {
 	kibanaClient := NewClient(NewDefaultConfig())
 	kibanaClient.SetAuth(getAuthForContainerVersion(kibanaClient.Config.KibanaVersion, kibanaClient.Config.KibanaType))
+	if testRecorder != nil {
+		kibanaClient.SetRecorder(testRecorder)
+	}
+
 	return kibanaClient
 }
 
@@ -90,8 +176,12 @@ func startKibana(elkVersion string, client *KibanaClient) (*testContext, error)
 
 	var err error
 	pool, err := dockertest.NewPool("")
+	if err == nil {
+		err = pool.Client.Ping()
+	}
+
 	if err != nil {
-		log.Fatalf("Could not connect to docker: %s", err)
+		log.Fatalf("Could not connect to docker, set USE_FAKE_KIBANA to run the tests against a fake kibana: %s", err)
 	}
 
 	elasticSearch, err := newElasticSearchContainer(pool, elkVersion)
@@ -61,7 +61,7 @@ func newKibanaContainer(pool *dockertest.Pool, elasticSearch *elasticSearchConta
 
 	kibanaUri := fmt.Sprintf("http://localhost:%v", resource.GetPort("5601/tcp"))
 
-	var indexPatternCreateResult *IndexPatternCreateResult
+	var indexPatternId string
 	pool.MaxWait = time.Minute * 5
 	if err := pool.Retry(func() error {
 
@@ -70,22 +70,8 @@ func newKibanaContainer(pool *dockertest.Pool, elasticSearch *elasticSearchConta
 			return err
 		}
 
-		indexPatternClient := client.IndexPattern()
-		indexPatternCreateResult, err = indexPatternClient.Create()
-		if err != nil {
-			log.Printf("Could not create index pattern:%s\n", err)
-			return err
-		}
-
-		if error := indexPatternClient.RefreshFields(indexPatternCreateResult.Id); error != nil {
-			return error
-		}
-
-		if error := indexPatternClient.SetDefault(indexPatternCreateResult.Id); error != nil {
-			return error
-		}
-
-		return nil
+		indexPatternId, err = createDefaultIndexPattern(client)
+		return err
 	}); err != nil {
 		log.Fatalf("Could not connect to kibana: %s", err)
 	}
@@ -102,7 +88,7 @@ func newKibanaContainer(pool *dockertest.Pool, elasticSearch *elasticSearchConta
 		pool:     pool,
 		resource: resource,
 		Uri:      kibanaUri,
-	}, indexPatternCreateResult.Id, nil
+	}, indexPatternId, nil
 }
 
 func checkKibanaServiceIsStarted(client *HttpAgent, kibanaUri string) error {
@@ -119,6 +105,33 @@ func checkKibanaServiceIsStarted(client *HttpAgent, kibanaUri string) error {
 	return nil
 }
 
+func createDefaultIndexPattern(client *KibanaClient) (string, error) {
+	indexPatternClient := client.IndexPattern()
+	request, err := NewIndexPatternRequestBuilder().
+		WithTitle(DefaultKibanaIndexId).
+		WithTimeFieldName("@timestamp").
+		Build()
+	if err != nil {
+		return "", err
+	}
+
+	indexPatternCreateResult, err := indexPatternClient.Create(request)
+	if err != nil {
+		log.Printf("Could not create index pattern:%s\n", err)
+		return "", err
+	}
+
+	if err := indexPatternClient.RefreshFields(indexPatternCreateResult.Id); err != nil {
+		return "", err
+	}
+
+	if err := indexPatternClient.SetDefault(indexPatternCreateResult.Id); err != nil {
+		return "", err
+	}
+
+	return indexPatternCreateResult.Id, nil
+}
+
 func (kibana *kibanaContainer) Stop() error {
 	return kibana.pool.Purge(kibana.resource)
 }
@@ -0,0 +1,926 @@
+package kibana
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	goversion "github.com/mcuadros/go-version"
+	uuid "github.com/satori/go.uuid"
+)
+
+const fakeKibanaDefaultSpace = "default"
+
+// FakeKibana is an in-memory kibana server which implements the saved objects, spaces, roles, index pattern,
+// data view, saved query and status apis. Objects are served using the 5.5.3 es_admin shape or the 6.x/7.x saved
+// objects shape depending on the version the fake was started with, so tests can run without docker containers.
+type FakeKibana struct {
+	Server  *httptest.Server
+	Version string
+	Fields  []*FakeKibanaField
+
+	// Documents are returned by searches sent through the kibana proxies to elasticsearch, queries are not
+	// evaluated, every document of an index matching the searched index pattern is a hit
+	Documents []*FakeKibanaDocument
+
+	// SearchRequests are the bodies of the searches sent through the kibana proxies to elasticsearch
+	SearchRequests []map[string]interface{}
+
+	mutex        sync.Mutex
+	sequence     int
+	objects      map[string]map[string]*fakeSavedObject
+	spaces       map[string]*Space
+	roles        map[string]*Role
+	settings     map[string]interface{}
+	spaceOrdinal []string
+}
+
+// FakeKibanaField is a field returned by the fake when kibana is asked for the fields of an index pattern
+type FakeKibanaField struct {
+	Name         string
+	Type         string
+	Searchable   bool
+	Aggregatable bool
+}
+
+// FakeKibanaDocument is an elasticsearch document searches of the fake return
+type FakeKibanaDocument struct {
+	Index  string
+	Id     string
+	Source map[string]interface{}
+}
+
+type fakeSavedObject struct {
+	Id         string
+	Type       string
+	Version    int
+	Sequence   int
+	UpdatedAt  time.Time
+	Attributes map[string]json.RawMessage
+	References json.RawMessage
+}
+
+type fakeSavedObjectBody struct {
+	Attributes map[string]json.RawMessage `json:"attributes"`
+	References json.RawMessage            `json:"references,omitempty"`
+}
+
+type fakeBulkGetRequest struct {
+	Type   string   `json:"type"`
+	Id     string   `json:"id"`
+	Fields []string `json:"fields"`
+}
+
+var fakeKibanaDefaultFields = []*FakeKibanaField{
+	{Name: "_id", Type: "string", Searchable: true, Aggregatable: true},
+	{Name: "_index", Type: "string", Searchable: true, Aggregatable: true},
+	{Name: "_score", Type: "number"},
+	{Name: "_source", Type: "_source"},
+	{Name: "_type", Type: "string", Searchable: true, Aggregatable: true},
+	{Name: "@timestamp", Type: "date", Searchable: true, Aggregatable: true},
+	{Name: "@tags", Type: "string", Searchable: true, Aggregatable: true},
+	{Name: "bytes", Type: "number", Searchable: true, Aggregatable: true},
+	{Name: "geo.ip", Type: "ip", Searchable: true, Aggregatable: true},
+	{Name: "geo.src", Type: "string", Searchable: true, Aggregatable: true},
+	{Name: "host", Type: "string", Searchable: true, Aggregatable: true},
+	{Name: "message", Type: "string", Searchable: true},
+	{Name: "response", Type: "string", Searchable: true, Aggregatable: true},
+}
+
+// NewFakeKibana starts a fake kibana server which behaves like the given kibana version
+func NewFakeKibana(kibanaVersion string) *FakeKibana {
+	fake := &FakeKibana{
+		Version: kibanaVersion,
+		Fields:  fakeKibanaDefaultFields,
+		objects: map[string]map[string]*fakeSavedObject{
+			fakeKibanaDefaultSpace: {},
+		},
+		spaces: map[string]*Space{
+			fakeKibanaDefaultSpace: {
+				Id:          fakeKibanaDefaultSpace,
+				Name:        "Default",
+				Description: "This is your default space!",
+				Color:       "#00bfb3",
+			},
+		},
+		roles: map[string]*Role{
+			"kibana_user": {
+				Name:     "kibana_user",
+				Metadata: map[string]interface{}{"_reserved": true},
+				ElasticSearch: &RoleElasticSearch{
+					Cluster: []string{},
+					Indices: []interface{}{},
+					RunAs:   []string{},
+				},
+				Kibana: []*RoleKibana{{Base: []string{"all"}, Feature: map[string][]string{}, Spaces: []string{"*"}}},
+			},
+		},
+		settings:     map[string]interface{}{},
+		spaceOrdinal: []string{fakeKibanaDefaultSpace},
+	}
+
+	fake.Server = httptest.NewServer(fake)
+	return fake
+}
+
+// URL is the base uri of the fake kibana server
+func (fake *FakeKibana) URL() string {
+	return fake.Server.URL
+}
+
+// Config returns a client configuration pointing at the fake kibana server
+func (fake *FakeKibana) Config() *Config {
+	return &Config{
+		DefaultIndexId:    DefaultKibanaIndexId,
+		ElasticSearchPath: DefaultElasticSearchPath,
+		KibanaBaseUri:     fake.URL(),
+		KibanaVersion:     fake.Version,
+		KibanaType:        KibanaTypeVanilla,
+	}
+}
+
+// Close shuts down the fake kibana server
+func (fake *FakeKibana) Close() {
+	fake.Server.Close()
+}
+
+func (fake *FakeKibana) ServeHTTP(writer http.ResponseWriter, request *http.Request) {
+	fake.mutex.Lock()
+	defer fake.mutex.Unlock()
+
+	path := request.URL.Path
+	space := fakeKibanaDefaultSpace
+	if strings.HasPrefix(path, "/s/") {
+		parts := strings.SplitN(strings.TrimPrefix(path, "/s/"), "/", 2)
+		space = parts[0]
+		path = "/"
+		if len(parts) > 1 {
+			path += parts[1]
+		}
+
+		if _, ok := fake.spaces[space]; !ok || fake.isVersion553() {
+			fake.writeError(writer, http.StatusNotFound, "Not Found")
+			return
+		}
+	}
+
+	if request.Method != http.MethodGet && request.Header.Get("kbn-version") == "" && request.Header.Get("kbn-xsrf") == "" {
+		fake.writeError(writer, http.StatusBadRequest, "Request must contain a kbn-xsrf header.")
+		return
+	}
+
+	switch {
+	case path == "/app/kibana":
+		writer.Header().Set("Content-Type", "text/html")
+		writer.WriteHeader(http.StatusOK)
+		fmt.Fprint(writer, "<html><body>kibana</body></html>")
+	case path == "/api/status":
+		fake.handleStatus(writer)
+	case strings.HasPrefix(path, DefaultElasticSearchPath+"/") && fake.isVersion553():
+		fake.handleElasticSearch553(writer, request, strings.TrimPrefix(path, DefaultElasticSearchPath+"/"))
+	case strings.HasPrefix(path, savedObjectsPath) && !fake.isVersion553():
+		fake.handleSavedObjects(writer, request, space, strings.TrimPrefix(path, savedObjectsPath))
+	case strings.HasPrefix(path, dataViewsPath) && fake.isVersion8():
+		fake.handleDataViews(writer, request, space, strings.TrimPrefix(path, dataViewsPath))
+	case strings.HasPrefix(path, savedQueryPath) && fake.isVersion8():
+		fake.handleSavedQueries(writer, request, space, strings.Trim(strings.TrimPrefix(path, savedQueryPath), "/"))
+	case strings.HasPrefix(path, "/elasticsearch/") && fake.isVersion553():
+		fake.handleElasticSearchProxy(writer, request, strings.TrimPrefix(path, "/elasticsearch/"))
+	case path == "/api/console/proxy" && request.Method == http.MethodPost && !fake.isVersion553():
+		fake.handleConsoleProxy(writer, request)
+	case path == "/api/index_patterns/_fields_for_wildcard":
+		fake.handleFieldsForWildcard(writer, request, space)
+	case strings.HasPrefix(path, "/api/kibana/settings"):
+		fake.handleSettings(writer, request, strings.TrimPrefix(path, "/api/kibana/settings"))
+	case strings.HasPrefix(path, "/api/spaces/space") && !fake.isVersion553():
+		fake.handleSpaces(writer, request, strings.Trim(strings.TrimPrefix(path, "/api/spaces/space"), "/"))
+	case strings.HasPrefix(path, "/api/security/role") && !fake.isVersion553():
+		fake.handleRoles(writer, request, strings.Trim(strings.TrimPrefix(path, "/api/security/role"), "/"))
+	default:
+		fake.writeError(writer, http.StatusNotFound, "Not Found")
+	}
+}
+
+func (fake *FakeKibana) isVersion553() bool {
+	return goversion.Compare(fake.Version, "6.0.0", "<")
+}
+
+func (fake *FakeKibana) isVersion7() bool {
+	return goversion.Compare(fake.Version, "7.0.0", ">=")
+}
+
+func (fake *FakeKibana) isVersion8() bool {
+	return goversion.Compare(fake.Version, "8.0.0", ">=")
+}
+
+func (fake *FakeKibana) handleStatus(writer http.ResponseWriter) {
+	fake.writeJson(writer, http.StatusOK, map[string]interface{}{
+		"name": "kibana",
+		"uuid": "5b2de169-2785-441b-ae8c-186a1936b17d",
+		"version": map[string]interface{}{
+			"number":         fake.Version,
+			"build_hash":     "",
+			"build_number":   0,
+			"build_snapshot": false,
+		},
+		"status": map[string]interface{}{
+			"overall": map[string]interface{}{
+				"state":    "green",
+				"title":    "Green",
+				"nickname": "Looking good",
+				"icon":     "success",
+				"since":    time.Now().UTC().Format(time.RFC3339),
+			},
+			"statuses": []interface{}{},
+		},
+	})
+}
+
+func (fake *FakeKibana) handleSavedObjects(writer http.ResponseWriter, request *http.Request, space string, path string) {
+	parts := strings.Split(path, "/")
+	switch {
+	case (path == "" || path == "_find") && request.Method == http.MethodGet:
+		fake.findSavedObjects(writer, request, space)
+	case path == "_bulk_get" && request.Method == http.MethodPost:
+		fake.bulkGetSavedObjects(writer, request, space)
+	case len(parts) == 1 && request.Method == http.MethodPost:
+		fake.createSavedObject(writer, request, space, parts[0], uuid.NewV4().String())
+	case len(parts) == 2 && request.Method == http.MethodPost:
+		fake.createSavedObject(writer, request, space, parts[0], parts[1])
+	case len(parts) == 2 && request.Method == http.MethodPut:
+		fake.updateSavedObject(writer, request, space, parts[0], parts[1])
+	case len(parts) == 2 && request.Method == http.MethodGet:
+		object, ok := fake.objects[space][fakeObjectKey(parts[0], parts[1])]
+		if !ok {
+			fake.writeSavedObjectNotFound(writer, parts[0], parts[1])
+			return
+		}
+
+		fake.writeJson(writer, http.StatusOK, fake.renderSavedObject(object, nil))
+	case len(parts) == 2 && request.Method == http.MethodDelete:
+		key := fakeObjectKey(parts[0], parts[1])
+		if _, ok := fake.objects[space][key]; !ok {
+			fake.writeSavedObjectNotFound(writer, parts[0], parts[1])
+			return
+		}
+
+		delete(fake.objects[space], key)
+		fake.writeJson(writer, http.StatusOK, map[string]interface{}{})
+	default:
+		fake.writeError(writer, http.StatusNotFound, "Not Found")
+	}
+}
+
+func (fake *FakeKibana) createSavedObject(writer http.ResponseWriter, request *http.Request, space string, objectType string, id string) {
+	body := &fakeSavedObjectBody{}
+	if !fake.readJson(writer, request, body) {
+		return
+	}
+
+	if body.References != nil && !fake.isVersion7() {
+		fake.writeError(writer, http.StatusBadRequest, "child \"references\" fails because [\"references\" is not allowed]")
+		return
+	}
+
+	key := fakeObjectKey(objectType, id)
+	existing, exists := fake.objects[space][key]
+	if exists && request.URL.Query().Get("overwrite") != "true" {
+		fake.writeError(writer, http.StatusConflict, fmt.Sprintf("Saved object [%s/%s] conflict", objectType, id))
+		return
+	}
+
+	object := &fakeSavedObject{
+		Id:         id,
+		Type:       objectType,
+		Version:    1,
+		Attributes: body.Attributes,
+		References: body.References,
+	}
+	if exists {
+		object.Version = existing.Version + 1
+	}
+
+	fake.storeObject(space, object)
+	fake.writeJson(writer, http.StatusOK, fake.renderSavedObject(object, nil))
+}
+
+func (fake *FakeKibana) updateSavedObject(writer http.ResponseWriter, request *http.Request, space string, objectType string, id string) {
+	body := &fakeSavedObjectBody{}
+	if !fake.readJson(writer, request, body) {
+		return
+	}
+
+	object, ok := fake.objects[space][fakeObjectKey(objectType, id)]
+	if !ok {
+		fake.writeSavedObjectNotFound(writer, objectType, id)
+		return
+	}
+
+	if object.Attributes == nil {
+		object.Attributes = map[string]json.RawMessage{}
+	}
+
+	for name, value := range body.Attributes {
+		object.Attributes[name] = value
+	}
+
+	if body.References != nil {
+		object.References = body.References
+	}
+
+	object.Version++
+	fake.storeObject(space, object)
+
+	response := fake.renderSavedObject(object, nil)
+	response["attributes"] = body.Attributes
+	fake.writeJson(writer, http.StatusOK, response)
+}
+
+func (fake *FakeKibana) findSavedObjects(writer http.ResponseWriter, request *http.Request, space string) {
+	query := request.URL.Query()
+	perPage := fakeQueryInt(query.Get("per_page"), 20)
+	page := fakeQueryInt(query.Get("page"), 1)
+	types := query["type"]
+	search := strings.ToLower(strings.TrimSuffix(query.Get("search"), "*"))
+
+	var matches []*fakeSavedObject
+	for _, object := range fake.sortedObjects(space) {
+		if len(types) > 0 && !fakeContains(types, object.Type) {
+			continue
+		}
+
+		if search != "" && !strings.Contains(strings.ToLower(fakeAttributeString(object, "title")), search) {
+			continue
+		}
+
+		matches = append(matches, object)
+	}
+
+	savedObjects := []map[string]interface{}{}
+	for index, object := range matches {
+		if index >= (page-1)*perPage && index < page*perPage {
+			savedObjects = append(savedObjects, fake.renderSavedObject(object, query["fields"]))
+		}
+	}
+
+	fake.writeJson(writer, http.StatusOK, map[string]interface{}{
+		"page":          page,
+		"per_page":      perPage,
+		"total":         len(matches),
+		"saved_objects": savedObjects,
+	})
+}
+
+func (fake *FakeKibana) bulkGetSavedObjects(writer http.ResponseWriter, request *http.Request, space string) {
+	var body []*fakeBulkGetRequest
+	if !fake.readJson(writer, request, &body) {
+		return
+	}
+
+	savedObjects := []map[string]interface{}{}
+	for _, item := range body {
+		object, ok := fake.objects[space][fakeObjectKey(item.Type, item.Id)]
+		if !ok {
+			savedObjects = append(savedObjects, map[string]interface{}{
+				"id":   item.Id,
+				"type": item.Type,
+				"error": map[string]interface{}{
+					"statusCode": http.StatusNotFound,
+					"message":    "Not found",
+				},
+			})
+			continue
+		}
+
+		savedObjects = append(savedObjects, fake.renderSavedObject(object, item.Fields))
+	}
+
+	fake.writeJson(writer, http.StatusOK, map[string]interface{}{"saved_objects": savedObjects})
+}
+
+func (fake *FakeKibana) renderSavedObject(object *fakeSavedObject, fields []string) map[string]interface{} {
+	attributes := map[string]json.RawMessage{}
+	for name, value := range object.Attributes {
+		if len(fields) == 0 || fakeContains(fields, name) {
+			attributes[name] = value
+		}
+	}
+
+	result := map[string]interface{}{
+		"id":         object.Id,
+		"type":       object.Type,
+		"updated_at": object.UpdatedAt.Format(time.RFC3339Nano),
+		"version":    object.Version,
+		"attributes": attributes,
+	}
+
+	if fake.isVersion7() {
+		result["version"] = base64.StdEncoding.EncodeToString([]byte(fmt.Sprintf("[%d,1]", object.Sequence)))
+		result["references"] = object.References
+		if object.References == nil {
+			result["references"] = []interface{}{}
+		}
+	}
+
+	return result
+}
+
+func (fake *FakeKibana) handleElasticSearch553(writer http.ResponseWriter, request *http.Request, path string) {
+	parts := strings.Split(path, "/")
+	switch {
+	case len(parts) == 2 && parts[1] == "_search":
+		fake.searchDocuments553(writer, request, parts[0])
+	case len(parts) == 3 && parts[2] == "_create" && (request.Method == http.MethodPost || request.Method == http.MethodPut):
+		if _, ok := fake.objects[fakeKibanaDefaultSpace][fakeObjectKey(parts[0], parts[1])]; ok {
+			fake.writeJson(writer, http.StatusConflict, map[string]interface{}{
+				"error": map[string]interface{}{
+					"type":   "version_conflict_engine_exception",
+					"reason": fmt.Sprintf("[%s][%s]: version conflict, document already exists", parts[0], parts[1]),
+				},
+				"status": http.StatusConflict,
+			})
+			return
+		}
+
+		fake.indexDocument553(writer, request, parts[0], parts[1])
+	case len(parts) == 2 && (request.Method == http.MethodPost || request.Method == http.MethodPut):
+		fake.indexDocument553(writer, request, parts[0], parts[1])
+	case len(parts) == 2 && request.Method == http.MethodGet:
+		object, ok := fake.objects[fakeKibanaDefaultSpace][fakeObjectKey(parts[0], parts[1])]
+		if !ok {
+			fake.writeJson(writer, http.StatusNotFound, fakeDocument553(parts[0], parts[1], map[string]interface{}{"found": false}))
+			return
+		}
+
+		fake.writeJson(writer, http.StatusOK, fakeDocument553(object.Type, object.Id, map[string]interface{}{
+			"_version": object.Version,
+			"found":    true,
+			"_source":  object.Attributes,
+		}))
+	case len(parts) == 2 && request.Method == http.MethodDelete:
+		key := fakeObjectKey(parts[0], parts[1])
+		object, ok := fake.objects[fakeKibanaDefaultSpace][key]
+		if !ok {
+			fake.writeJson(writer, http.StatusNotFound, fakeDocument553(parts[0], parts[1], map[string]interface{}{"found": false, "result": "not_found"}))
+			return
+		}
+
+		delete(fake.objects[fakeKibanaDefaultSpace], key)
+		fake.writeJson(writer, http.StatusOK, fakeDocument553(object.Type, object.Id, map[string]interface{}{
+			"_version": object.Version + 1,
+			"found":    true,
+			"result":   "deleted",
+		}))
+	default:
+		fake.writeError(writer, http.StatusNotFound, "Not Found")
+	}
+}
+
+func (fake *FakeKibana) indexDocument553(writer http.ResponseWriter, request *http.Request, objectType string, id string) {
+	source := map[string]json.RawMessage{}
+	if !fake.readJson(writer, request, &source) {
+		return
+	}
+
+	object := &fakeSavedObject{Id: id, Type: objectType, Version: 1, Attributes: source}
+	existing, exists := fake.objects[fakeKibanaDefaultSpace][fakeObjectKey(objectType, id)]
+	if exists {
+		object.Version = existing.Version + 1
+	}
+
+	fake.storeObject(fakeKibanaDefaultSpace, object)
+
+	status, result := http.StatusCreated, "created"
+	if exists {
+		status, result = http.StatusOK, "updated"
+	}
+
+	fake.writeJson(writer, status, fakeDocument553(objectType, id, map[string]interface{}{
+		"_version": object.Version,
+		"result":   result,
+		"created":  !exists,
+	}))
+}
+
+func (fake *FakeKibana) searchDocuments553(writer http.ResponseWriter, request *http.Request, objectType string) {
+	size := fakeQueryInt(request.URL.Query().Get("size"), 10)
+	from := fakeQueryInt(request.URL.Query().Get("from"), 0)
+
+	var hits []map[string]interface{}
+	total := 0
+	for _, object := range fake.sortedObjects(fakeKibanaDefaultSpace) {
+		if object.Type != objectType {
+			continue
+		}
+
+		total++
+		if total > from && len(hits) < size {
+			hits = append(hits, fakeDocument553(object.Type, object.Id, map[string]interface{}{
+				"_score":  1,
+				"_source": object.Attributes,
+			}))
+		}
+	}
+
+	fake.writeJson(writer, http.StatusOK, map[string]interface{}{
+		"took":      1,
+		"timed_out": false,
+		"hits": map[string]interface{}{
+			"total":     total,
+			"max_score": 1,
+			"hits":      hits,
+		},
+	})
+}
+
+// handleFieldsForWildcard returns the fields of the index pattern whose title is the pattern, a pattern which is
+// not the title of an index pattern matches no indices
+func (fake *FakeKibana) handleFieldsForWildcard(writer http.ResponseWriter, request *http.Request, space string) {
+	pattern := request.URL.Query().Get("pattern")
+	if pattern == "" {
+		fake.writeError(writer, http.StatusBadRequest, "[request query.pattern]: expected value of type [string] but got [undefined]")
+		return
+	}
+
+	matched := false
+	for _, object := range fake.objects[space] {
+		var title string
+		if object.Type == "index-pattern" && json.Unmarshal(object.Attributes["title"], &title) == nil && title == pattern {
+			matched = true
+		}
+	}
+
+	if !matched {
+		fake.writeError(writer, http.StatusNotFound, fmt.Sprintf("No indices match pattern \"%s\"", pattern))
+		return
+	}
+
+	fields := []*IndexPatternField{}
+	for _, field := range fake.Fields {
+		fields = append(fields, &IndexPatternField{
+			Name:              field.Name,
+			Type:              field.Type,
+			Searchable:        field.Searchable,
+			Aggregatable:      field.Aggregatable,
+			ReadFromDocValues: field.Aggregatable && field.Type != "string",
+		})
+	}
+
+	fake.writeJson(writer, http.StatusOK, &metaFieldsResult{Fields: fields})
+}
+
+// handleConsoleProxy serves the requests sent to elasticsearch through the kibana 6.0 and later console proxy
+func (fake *FakeKibana) handleConsoleProxy(writer http.ResponseWriter, request *http.Request) {
+	path := strings.SplitN(request.URL.Query().Get("path"), "?", 2)[0]
+	if unescaped, err := url.PathUnescape(path); err == nil {
+		path = unescaped
+	}
+
+	fake.handleElasticSearchProxy(writer, request, strings.TrimPrefix(path, "/"))
+}
+
+func (fake *FakeKibana) handleElasticSearchProxy(writer http.ResponseWriter, request *http.Request, path string) {
+	parts := strings.Split(path, "/")
+	switch {
+	case len(parts) == 2 && parts[1] == "_field_caps":
+		fake.handleFieldCaps(writer)
+	case len(parts) == 2 && parts[1] == "_search" && request.Method == http.MethodPost:
+		fake.handleSearch(writer, request, parts[0])
+	default:
+		fake.writeError(writer, http.StatusNotFound, "Not Found")
+	}
+}
+
+func (fake *FakeKibana) handleFieldCaps(writer http.ResponseWriter) {
+	fields := map[string]interface{}{}
+	for _, field := range fake.Fields {
+		esType := fakeEsFieldType(field)
+		if esType == "" {
+			continue
+		}
+
+		fields[field.Name] = map[string]interface{}{
+			esType: map[string]interface{}{
+				"type":         esType,
+				"searchable":   field.Searchable,
+				"aggregatable": field.Aggregatable,
+			},
+		}
+	}
+
+	fake.writeJson(writer, http.StatusOK, map[string]interface{}{"fields": fields})
+}
+
+func (fake *FakeKibana) handleSearch(writer http.ResponseWriter, request *http.Request, indexPattern string) {
+	body := map[string]interface{}{}
+	if !fake.readJson(writer, request, &body) {
+		return
+	}
+
+	fake.SearchRequests = append(fake.SearchRequests, body)
+
+	size := 10
+	if value, ok := body["size"].(float64); ok {
+		size = int(value)
+	}
+
+	hits := []interface{}{}
+	total := 0
+	for _, document := range fake.Documents {
+		if matched, _ := filepath.Match(indexPattern, document.Index); !matched {
+			continue
+		}
+
+		total++
+		if len(hits) < size {
+			hits = append(hits, map[string]interface{}{
+				"_index":  document.Index,
+				"_id":     document.Id,
+				"_score":  nil,
+				"_source": document.Source,
+			})
+		}
+	}
+
+	var hitsTotal interface{} = total
+	if fake.isVersion7() {
+		hitsTotal = map[string]interface{}{"value": total, "relation": "eq"}
+	}
+
+	fake.writeJson(writer, http.StatusOK, map[string]interface{}{
+		"took":      1,
+		"timed_out": false,
+		"hits":      map[string]interface{}{"total": hitsTotal, "max_score": nil, "hits": hits},
+	})
+}
+
+func (fake *FakeKibana) handleSettings(writer http.ResponseWriter, request *http.Request, path string) {
+	switch {
+	case path == "/defaultIndex" && request.Method == http.MethodPost:
+		value := &valuePair{}
+		if !fake.readJson(writer, request, value) {
+			return
+		}
+
+		fake.settings["defaultIndex"] = value.Value
+	case path == "" && request.Method == http.MethodPost:
+		changes := &struct {
+			Changes map[string]interface{} `json:"changes"`
+		}{}
+		if !fake.readJson(writer, request, changes) {
+			return
+		}
+
+		for name, value := range changes.Changes {
+			if value == nil {
+				delete(fake.settings, name)
+				continue
+			}
+
+			fake.settings[name] = value
+		}
+	case path == "" && request.Method == http.MethodGet:
+	default:
+		fake.writeError(writer, http.StatusNotFound, "Not Found")
+		return
+	}
+
+	settings := map[string]interface{}{}
+	for name, value := range fake.settings {
+		settings[name] = map[string]interface{}{"userValue": value}
+	}
+
+	fake.writeJson(writer, http.StatusOK, map[string]interface{}{"settings": settings})
+}
+
+func (fake *FakeKibana) handleSpaces(writer http.ResponseWriter, request *http.Request, id string) {
+	switch {
+	case id == "" && request.Method == http.MethodGet:
+		spaces := []*Space{}
+		for _, spaceId := range fake.spaceOrdinal {
+			spaces = append(spaces, fake.spaces[spaceId])
+		}
+
+		fake.writeJson(writer, http.StatusOK, spaces)
+	case id == "" && request.Method == http.MethodPost:
+		space := &Space{}
+		if !fake.readJson(writer, request, space) {
+			return
+		}
+
+		if _, ok := fake.spaces[space.Id]; ok {
+			fake.writeError(writer, http.StatusConflict, fmt.Sprintf("A space with the identifier %s already exists.", space.Id))
+			return
+		}
+
+		fake.spaces[space.Id] = space
+		fake.spaceOrdinal = append(fake.spaceOrdinal, space.Id)
+		fake.objects[space.Id] = map[string]*fakeSavedObject{}
+		fake.writeJson(writer, http.StatusOK, space)
+	case id != "" && request.Method == http.MethodGet:
+		space, ok := fake.spaces[id]
+		if !ok {
+			fake.writeError(writer, http.StatusNotFound, "Not Found")
+			return
+		}
+
+		fake.writeJson(writer, http.StatusOK, space)
+	case id != "" && request.Method == http.MethodPut:
+		space := &Space{}
+		if !fake.readJson(writer, request, space) {
+			return
+		}
+
+		if _, ok := fake.spaces[id]; !ok {
+			fake.writeError(writer, http.StatusNotFound, "Not Found")
+			return
+		}
+
+		space.Id = id
+		fake.spaces[id] = space
+		fake.writeJson(writer, http.StatusOK, space)
+	case id != "" && request.Method == http.MethodDelete:
+		if _, ok := fake.spaces[id]; !ok || id == fakeKibanaDefaultSpace {
+			fake.writeError(writer, http.StatusNotFound, "Not Found")
+			return
+		}
+
+		delete(fake.spaces, id)
+		delete(fake.objects, id)
+		for index, spaceId := range fake.spaceOrdinal {
+			if spaceId == id {
+				fake.spaceOrdinal = append(fake.spaceOrdinal[:index], fake.spaceOrdinal[index+1:]...)
+				break
+			}
+		}
+
+		writer.WriteHeader(http.StatusNoContent)
+	default:
+		fake.writeError(writer, http.StatusNotFound, "Not Found")
+	}
+}
+
+func (fake *FakeKibana) handleRoles(writer http.ResponseWriter, request *http.Request, name string) {
+	switch {
+	case name == "" && request.Method == http.MethodGet:
+		roles := []*Role{}
+		for _, role := range fake.roles {
+			roles = append(roles, role)
+		}
+
+		sort.Slice(roles, func(i, j int) bool { return roles[i].Name < roles[j].Name })
+		fake.writeJson(writer, http.StatusOK, roles)
+	case name != "" && request.Method == http.MethodPut:
+		role := &Role{}
+		if !fake.readJson(writer, request, role) {
+			return
+		}
+
+		role.Name = name
+		fake.roles[name] = role
+		writer.WriteHeader(http.StatusNoContent)
+	case name != "" && request.Method == http.MethodGet:
+		role, ok := fake.roles[name]
+		if !ok {
+			fake.writeError(writer, http.StatusNotFound, "Not Found")
+			return
+		}
+
+		fake.writeJson(writer, http.StatusOK, role)
+	case name != "" && request.Method == http.MethodDelete:
+		if _, ok := fake.roles[name]; !ok {
+			fake.writeError(writer, http.StatusNotFound, "Not Found")
+			return
+		}
+
+		delete(fake.roles, name)
+		writer.WriteHeader(http.StatusNoContent)
+	default:
+		fake.writeError(writer, http.StatusNotFound, "Not Found")
+	}
+}
+
+func (fake *FakeKibana) storeObject(space string, object *fakeSavedObject) {
+	fake.sequence++
+	if object.Sequence == 0 {
+		object.Sequence = fake.sequence
+	}
+
+	object.UpdatedAt = time.Now().UTC()
+	fake.objects[space][fakeObjectKey(object.Type, object.Id)] = object
+}
+
+func (fake *FakeKibana) sortedObjects(space string) []*fakeSavedObject {
+	var objects []*fakeSavedObject
+	for _, object := range fake.objects[space] {
+		objects = append(objects, object)
+	}
+
+	sort.Slice(objects, func(i, j int) bool { return objects[i].Sequence < objects[j].Sequence })
+	return objects
+}
+
+func (fake *FakeKibana) readJson(writer http.ResponseWriter, request *http.Request, value interface{}) bool {
+	body, err := ioutil.ReadAll(request.Body)
+	if err != nil {
+		fake.writeError(writer, http.StatusBadRequest, err.Error())
+		return false
+	}
+
+	if err := json.Unmarshal(body, value); err != nil {
+		fake.writeError(writer, http.StatusBadRequest, fmt.Sprintf("Invalid request payload JSON format: %v", err))
+		return false
+	}
+
+	return true
+}
+
+func (fake *FakeKibana) writeSavedObjectNotFound(writer http.ResponseWriter, objectType string, id string) {
+	fake.writeError(writer, http.StatusNotFound, fmt.Sprintf("Saved object [%s/%s] not found", objectType, id))
+}
+
+func (fake *FakeKibana) writeError(writer http.ResponseWriter, status int, message string) {
+	fake.writeJson(writer, status, map[string]interface{}{
+		"statusCode": status,
+		"error":      http.StatusText(status),
+		"message":    message,
+	})
+}
+
+func (fake *FakeKibana) writeJson(writer http.ResponseWriter, status int, value interface{}) {
+	writer.Header().Set("Content-Type", "application/json; charset=utf-8")
+	writer.WriteHeader(status)
+	json.NewEncoder(writer).Encode(value)
+}
+
+func fakeDocument553(objectType string, id string, values map[string]interface{}) map[string]interface{} {
+	document := map[string]interface{}{
+		"_index": ".kibana",
+		"_type":  objectType,
+		"_id":    id,
+	}
+
+	for name, value := range values {
+		document[name] = value
+	}
+
+	return document
+}
+
+// fakeEsFieldType returns the elasticsearch mapping type of the field, or an empty string for fields which are
+// not part of the elasticsearch mapping
+func fakeEsFieldType(field *FakeKibanaField) string {
+	switch {
+	case field.Name == "_score":
+		return ""
+	case field.Type == "string" && field.Aggregatable:
+		return "keyword"
+	case field.Type == "string":
+		return "text"
+	case field.Type == "number":
+		return "long"
+	default:
+		return field.Type
+	}
+}
+
+func fakeObjectKey(objectType string, id string) string {
+	return objectType + "/" + id
+}
+
+func fakeAttributeString(object *fakeSavedObject, name string) string {
+	var value string
+	json.Unmarshal(object.Attributes[name], &value)
+	return value
+}
+
+func fakeQueryInt(value string, defaultValue int) int {
+	result, err := strconv.Atoi(value)
+	if err != nil || result <= 0 {
+		return defaultValue
+	}
+
+	return result
+}
+
+func fakeContains(values []string, value string) bool {
+	for _, item := range values {
+		for _, part := range strings.Split(item, ",") {
+			if part == value {
+				return true
+			}
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,353 @@
+package kibana
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	uuid "github.com/satori/go.uuid"
+)
+
+type fakeDataViewCreateBody struct {
+	DataView *DataView `json:"data_view"`
+	Override bool      `json:"override"`
+}
+
+type fakeDataViewUpdateBody struct {
+	DataView *DataViewUpdate `json:"data_view"`
+}
+
+// handleDataViews serves the kibana 8.x data views api, data views are stored as index-pattern saved objects so
+// they are visible to the saved objects and index pattern apis as well
+func (fake *FakeKibana) handleDataViews(writer http.ResponseWriter, request *http.Request, space string, path string) {
+	parts := strings.Split(strings.Trim(path, "/"), "/")
+	switch {
+	case path == "" && request.Method == http.MethodGet:
+		fake.listDataViews(writer, space)
+	case path == "/default":
+		fake.handleDefaultDataView(writer, request)
+	case path == "/data_view" && request.Method == http.MethodPost:
+		fake.createDataView(writer, request, space)
+	case len(parts) == 2 && parts[0] == "data_view":
+		fake.handleDataView(writer, request, space, parts[1])
+	case len(parts) == 3 && parts[0] == "data_view" && parts[2] == "fields" && request.Method == http.MethodPost:
+		fake.updateDataViewFields(writer, request, space, parts[1])
+	case len(parts) >= 3 && parts[0] == "data_view" && parts[2] == "runtime_field":
+		fake.handleDataViewRuntimeField(writer, request, space, parts[1], strings.Join(parts[3:], "/"))
+	default:
+		fake.writeError(writer, http.StatusNotFound, "Not Found")
+	}
+}
+
+func (fake *FakeKibana) listDataViews(writer http.ResponseWriter, space string) {
+	dataViews := []*DataViewListItem{}
+	for _, object := range fake.sortedObjects(space) {
+		if object.Type != "index-pattern" {
+			continue
+		}
+
+		dataViews = append(dataViews, &DataViewListItem{
+			Id:         object.Id,
+			Title:      fakeAttributeString(object, "title"),
+			Name:       fakeAttributeString(object, "name"),
+			Namespaces: []string{space},
+		})
+	}
+
+	fake.writeJson(writer, http.StatusOK, &dataViewListResponse{DataView: dataViews})
+}
+
+func (fake *FakeKibana) handleDefaultDataView(writer http.ResponseWriter, request *http.Request) {
+	switch request.Method {
+	case http.MethodGet:
+		id, _ := fake.settings["defaultIndex"].(string)
+		fake.writeJson(writer, http.StatusOK, &dataViewDefault{DataViewId: id})
+	case http.MethodPost:
+		body := &dataViewDefault{}
+		if !fake.readJson(writer, request, body) {
+			return
+		}
+
+		if _, ok := fake.settings["defaultIndex"]; ok && !body.Force {
+			fake.writeJson(writer, http.StatusOK, map[string]interface{}{"acknowledged": true})
+			return
+		}
+
+		fake.settings["defaultIndex"] = body.DataViewId
+		fake.writeJson(writer, http.StatusOK, map[string]interface{}{"acknowledged": true})
+	default:
+		fake.writeError(writer, http.StatusNotFound, "Not Found")
+	}
+}
+
+func (fake *FakeKibana) createDataView(writer http.ResponseWriter, request *http.Request, space string) {
+	body := &fakeDataViewCreateBody{}
+	if !fake.readJson(writer, request, body) {
+		return
+	}
+
+	if body.DataView == nil || body.DataView.Title == "" {
+		fake.writeError(writer, http.StatusBadRequest, "[request body.data_view.title]: expected value of type [string] but got [undefined]")
+		return
+	}
+
+	for _, object := range fake.sortedObjects(space) {
+		if object.Type != "index-pattern" || fakeAttributeString(object, "title") != body.DataView.Title {
+			continue
+		}
+
+		if !body.Override {
+			fake.writeError(writer, http.StatusBadRequest, fmt.Sprintf("Duplicate data view: %s", body.DataView.Title))
+			return
+		}
+
+		delete(fake.objects[space], fakeObjectKey(object.Type, object.Id))
+	}
+
+	id := body.DataView.Id
+	if id == "" {
+		id = uuid.NewV4().String()
+	}
+
+	if _, exists := fake.objects[space][fakeObjectKey("index-pattern", id)]; exists {
+		fake.writeError(writer, http.StatusConflict, fmt.Sprintf("Saved object [index-pattern/%s] conflict", id))
+		return
+	}
+
+	object := &fakeSavedObject{Id: id, Type: "index-pattern", Version: 1}
+	fake.writeDataViewAttributes(object, body.DataView)
+	fake.storeObject(space, object)
+	fake.writeJson(writer, http.StatusOK, &dataViewResponse{DataView: fake.renderDataView(object, space)})
+}
+
+func (fake *FakeKibana) handleDataView(writer http.ResponseWriter, request *http.Request, space string, id string) {
+	key := fakeObjectKey("index-pattern", id)
+	object, ok := fake.objects[space][key]
+	if !ok {
+		fake.writeSavedObjectNotFound(writer, "index-pattern", id)
+		return
+	}
+
+	switch request.Method {
+	case http.MethodGet:
+		fake.writeJson(writer, http.StatusOK, &dataViewResponse{DataView: fake.renderDataView(object, space)})
+	case http.MethodPost:
+		body := &fakeDataViewUpdateBody{}
+		if !fake.readJson(writer, request, body) {
+			return
+		}
+
+		dataView := fake.renderDataView(object, space)
+		if update := body.DataView; update != nil {
+			if update.Title != "" {
+				dataView.Title = update.Title
+			}
+			if update.Name != "" {
+				dataView.Name = update.Name
+			}
+			if update.TimeFieldName != "" {
+				dataView.TimeFieldName = update.TimeFieldName
+			}
+			if update.SourceFilters != nil {
+				dataView.SourceFilters = update.SourceFilters
+			}
+			if update.FieldFormats != nil {
+				dataView.FieldFormats = update.FieldFormats
+			}
+			if update.RuntimeFieldMap != nil {
+				dataView.RuntimeFieldMap = update.RuntimeFieldMap
+			}
+			if update.AllowNoIndex != nil {
+				dataView.AllowNoIndex = *update.AllowNoIndex
+			}
+		}
+
+		fake.saveDataView(writer, space, object, dataView)
+	case http.MethodDelete:
+		delete(fake.objects[space], key)
+		writer.WriteHeader(http.StatusOK)
+	default:
+		fake.writeError(writer, http.StatusNotFound, "Not Found")
+	}
+}
+
+func (fake *FakeKibana) updateDataViewFields(writer http.ResponseWriter, request *http.Request, space string, id string) {
+	object, ok := fake.objects[space][fakeObjectKey("index-pattern", id)]
+	if !ok {
+		fake.writeSavedObjectNotFound(writer, "index-pattern", id)
+		return
+	}
+
+	body := &dataViewFieldsRequest{}
+	if !fake.readJson(writer, request, body) {
+		return
+	}
+
+	dataView := fake.renderDataView(object, space)
+	for name, update := range body.Fields {
+		if _, ok := dataView.Fields[name]; !ok {
+			fake.writeError(writer, http.StatusNotFound, fmt.Sprintf("Field %s not found", name))
+			return
+		}
+
+		attributes := dataView.FieldAttrs[name]
+		if attributes == nil {
+			attributes = &DataViewFieldAttributes{}
+		}
+		if update.CustomLabel != "" {
+			attributes.CustomLabel = update.CustomLabel
+		}
+		if update.Count != 0 {
+			attributes.Count = update.Count
+		}
+		if update.Format != nil {
+			if dataView.FieldFormats == nil {
+				dataView.FieldFormats = map[string]*FieldFormat{}
+			}
+
+			dataView.FieldFormats[name] = update.Format
+		}
+
+		if dataView.FieldAttrs == nil {
+			dataView.FieldAttrs = map[string]*DataViewFieldAttributes{}
+		}
+		dataView.FieldAttrs[name] = attributes
+	}
+
+	fake.saveDataView(writer, space, object, dataView)
+}
+
+func (fake *FakeKibana) handleDataViewRuntimeField(writer http.ResponseWriter, request *http.Request, space string, id string, name string) {
+	object, ok := fake.objects[space][fakeObjectKey("index-pattern", id)]
+	if !ok {
+		fake.writeSavedObjectNotFound(writer, "index-pattern", id)
+		return
+	}
+
+	dataView := fake.renderDataView(object, space)
+	switch {
+	case name == "" && request.Method == http.MethodPut:
+		body := &dataViewRuntimeFieldRequest{}
+		if !fake.readJson(writer, request, body) {
+			return
+		}
+
+		if dataView.RuntimeFieldMap == nil {
+			dataView.RuntimeFieldMap = map[string]*RuntimeField{}
+		}
+
+		dataView.RuntimeFieldMap[body.Name] = body.RuntimeField
+		fake.saveDataView(writer, space, object, dataView)
+	case name != "" && (request.Method == http.MethodGet || request.Method == http.MethodDelete):
+		if _, ok := dataView.RuntimeFieldMap[name]; !ok {
+			fake.writeError(writer, http.StatusNotFound, fmt.Sprintf("Runtime field %s not found in data view %s", name, id))
+			return
+		}
+
+		if request.Method == http.MethodGet {
+			fake.writeJson(writer, http.StatusOK, map[string]interface{}{
+				"data_view": dataView,
+				"fields":    []*DataViewField{dataView.Fields[name]},
+			})
+			return
+		}
+
+		delete(dataView.RuntimeFieldMap, name)
+		fake.writeDataViewAttributes(object, dataView)
+		object.Version++
+		fake.storeObject(space, object)
+		writer.WriteHeader(http.StatusOK)
+	default:
+		fake.writeError(writer, http.StatusNotFound, "Not Found")
+	}
+}
+
+func (fake *FakeKibana) saveDataView(writer http.ResponseWriter, space string, object *fakeSavedObject, dataView *DataView) {
+	fake.writeDataViewAttributes(object, dataView)
+	object.Version++
+	fake.storeObject(space, object)
+	fake.writeJson(writer, http.StatusOK, &dataViewResponse{DataView: fake.renderDataView(object, space)})
+}
+
+// writeDataViewAttributes stores the data view the way kibana does, with the maps encoded as json strings
+func (fake *FakeKibana) writeDataViewAttributes(object *fakeSavedObject, dataView *DataView) {
+	attributes := map[string]json.RawMessage{}
+	if fields, ok := object.Attributes["fields"]; ok {
+		attributes["fields"] = fields
+	}
+
+	attributes["title"], _ = json.Marshal(dataView.Title)
+	attributes["name"], _ = json.Marshal(dataView.Name)
+	attributes["timeFieldName"], _ = json.Marshal(dataView.TimeFieldName)
+	attributes["allowNoIndex"], _ = json.Marshal(dataView.AllowNoIndex)
+	if len(dataView.FieldFormats) > 0 {
+		attributes["fieldFormatMap"] = fakeJsonString(dataView.FieldFormats)
+	}
+	if len(dataView.RuntimeFieldMap) > 0 {
+		attributes["runtimeFieldMap"] = fakeJsonString(dataView.RuntimeFieldMap)
+	}
+	if len(dataView.FieldAttrs) > 0 {
+		attributes["fieldAttrs"] = fakeJsonString(dataView.FieldAttrs)
+	}
+	if len(dataView.SourceFilters) > 0 {
+		attributes["sourceFilters"] = fakeJsonString(dataView.SourceFilters)
+	}
+
+	object.Attributes = attributes
+}
+
+func (fake *FakeKibana) renderDataView(object *fakeSavedObject, space string) *DataView {
+	dataView := &DataView{
+		Id:            object.Id,
+		Version:       strconv.Itoa(object.Version),
+		Title:         fakeAttributeString(object, "title"),
+		Name:          fakeAttributeString(object, "name"),
+		TimeFieldName: fakeAttributeString(object, "timeFieldName"),
+		Namespaces:    []string{space},
+		Fields:        map[string]*DataViewField{},
+	}
+
+	json.Unmarshal(object.Attributes["allowNoIndex"], &dataView.AllowNoIndex)
+	json.Unmarshal([]byte(fakeAttributeString(object, "fieldFormatMap")), &dataView.FieldFormats)
+	json.Unmarshal([]byte(fakeAttributeString(object, "runtimeFieldMap")), &dataView.RuntimeFieldMap)
+	json.Unmarshal([]byte(fakeAttributeString(object, "fieldAttrs")), &dataView.FieldAttrs)
+	json.Unmarshal([]byte(fakeAttributeString(object, "sourceFilters")), &dataView.SourceFilters)
+
+	for _, field := range fake.Fields {
+		dataView.Fields[field.Name] = &DataViewField{
+			Name:              field.Name,
+			Type:              field.Type,
+			Searchable:        field.Searchable,
+			Aggregatable:      field.Aggregatable,
+			ReadFromDocValues: field.Aggregatable && field.Type != "string",
+		}
+	}
+
+	for name, runtimeField := range dataView.RuntimeFieldMap {
+		dataView.Fields[name] = &DataViewField{
+			Name:         name,
+			Type:         runtimeField.Type,
+			Searchable:   true,
+			Aggregatable: true,
+			RuntimeField: runtimeField,
+		}
+	}
+
+	for name, field := range dataView.Fields {
+		field.Format = dataView.FieldFormats[name]
+		if attributes, ok := dataView.FieldAttrs[name]; ok {
+			field.CustomLabel = attributes.CustomLabel
+			field.Count = attributes.Count
+		}
+	}
+
+	return dataView
+}
+
+func fakeJsonString(value interface{}) json.RawMessage {
+	data, _ := json.Marshal(value)
+	encoded, _ := json.Marshal(string(data))
+	return encoded
+}
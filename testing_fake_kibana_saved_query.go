@@ -0,0 +1,93 @@
+package kibana
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	uuid "github.com/satori/go.uuid"
+)
+
+// handleSavedQueries serves the kibana 8.x saved query api, saved queries are stored as query saved objects so
+// they are visible to the saved objects api as well
+func (fake *FakeKibana) handleSavedQueries(writer http.ResponseWriter, request *http.Request, space string, path string) {
+	switch {
+	case path == "_create" && request.Method == http.MethodPost:
+		fake.saveSavedQuery(writer, request, space, &fakeSavedObject{Id: uuid.NewV4().String(), Type: "query"})
+	case path == "_find" && request.Method == http.MethodPost:
+		fake.findSavedQueries(writer, request, space)
+	case path != "" && !strings.Contains(path, "/"):
+		object, ok := fake.objects[space][fakeObjectKey("query", path)]
+		if !ok {
+			fake.writeSavedObjectNotFound(writer, "query", path)
+			return
+		}
+
+		switch request.Method {
+		case http.MethodGet:
+			fake.writeJson(writer, http.StatusOK, fake.renderSavedQuery(object))
+		case http.MethodPut:
+			fake.saveSavedQuery(writer, request, space, object)
+		case http.MethodDelete:
+			delete(fake.objects[space], fakeObjectKey("query", path))
+			writer.WriteHeader(http.StatusOK)
+		default:
+			fake.writeError(writer, http.StatusNotFound, "Not Found")
+		}
+	default:
+		fake.writeError(writer, http.StatusNotFound, "Not Found")
+	}
+}
+
+// saveSavedQuery replaces the attributes of the saved query with the body, the saved query api has no partial
+// updates
+func (fake *FakeKibana) saveSavedQuery(writer http.ResponseWriter, request *http.Request, space string, object *fakeSavedObject) {
+	attributes := map[string]json.RawMessage{}
+	if !fake.readJson(writer, request, &attributes) {
+		return
+	}
+
+	if fakeAttributeString(&fakeSavedObject{Attributes: attributes}, "title") == "" {
+		fake.writeError(writer, http.StatusBadRequest, "[request body.title]: expected value of type [string] but got [undefined]")
+		return
+	}
+
+	object.Attributes = attributes
+	object.Version++
+	fake.storeObject(space, object)
+	fake.writeJson(writer, http.StatusOK, fake.renderSavedQuery(object))
+}
+
+func (fake *FakeKibana) findSavedQueries(writer http.ResponseWriter, request *http.Request, space string) {
+	body := &savedQueryFindRequest{}
+	if !fake.readJson(writer, request, body) {
+		return
+	}
+
+	search := strings.ToLower(body.Search)
+	savedQueries := []map[string]interface{}{}
+	for _, object := range fake.sortedObjects(space) {
+		if object.Type != "query" {
+			continue
+		}
+
+		if search != "" && !strings.Contains(strings.ToLower(fakeAttributeString(object, "title")), search) &&
+			!strings.Contains(strings.ToLower(fakeAttributeString(object, "description")), search) {
+			continue
+		}
+
+		savedQueries = append(savedQueries, fake.renderSavedQuery(object))
+	}
+
+	fake.writeJson(writer, http.StatusOK, map[string]interface{}{
+		"total":        len(savedQueries),
+		"savedQueries": savedQueries,
+	})
+}
+
+func (fake *FakeKibana) renderSavedQuery(object *fakeSavedObject) map[string]interface{} {
+	return map[string]interface{}{
+		"id":         object.Id,
+		"attributes": object.Attributes,
+	}
+}
@@ -0,0 +1,120 @@
+package kibana
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/parnurzeal/gorequest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_FakeKibana_status(t *testing.T) {
+	fake := NewFakeKibana(DefaultKibanaVersion7)
+	defer fake.Close()
+
+	response, body, errs := gorequest.New().Get(fake.URL() + "/api/status").End()
+	require.Nil(t, errs)
+	assert.Equal(t, 200, response.StatusCode)
+
+	status := &struct {
+		Version struct {
+			Number string `json:"number"`
+		} `json:"version"`
+	}{}
+	require.NoError(t, json.Unmarshal([]byte(body), status))
+	assert.Equal(t, DefaultKibanaVersion7, status.Version.Number)
+}
+
+func Test_FakeKibana_requires_xsrf_header(t *testing.T) {
+	fake := NewFakeKibana(DefaultKibanaVersion7)
+	defer fake.Close()
+
+	response, _, errs := gorequest.New().Post(fake.URL() + savedObjectsPath + "search").Send(`{"attributes":{}}`).End()
+	require.Nil(t, errs)
+	assert.Equal(t, 400, response.StatusCode)
+}
+
+func Test_FakeKibana_553_search_round_trip(t *testing.T) {
+	fake := NewFakeKibana(DefaultKibanaVersion553)
+	defer fake.Close()
+
+	client := NewClient(fake.Config())
+	request, _, err := createSearchRequest(client.Search(), client.Config.DefaultIndexId, t)
+	require.NoError(t, err)
+
+	created, err := client.Search().Create(request)
+	require.NoError(t, err)
+
+	read, err := client.Search().GetById(created.Id)
+	require.NoError(t, err)
+	assert.Equal(t, request.Attributes.Title, read.Attributes.Title)
+	assert.Equal(t, request.Attributes.KibanaSavedObjectMeta.SearchSourceJSON, read.Attributes.KibanaSavedObjectMeta.SearchSourceJSON)
+
+	result, err := client.SavedObjects().GetByType(NewSavedObjectRequestBuilder().WithType("search").Build())
+	require.NoError(t, err)
+	assert.Equal(t, 1, result.Total)
+
+	require.NoError(t, client.Search().Delete(created.Id))
+	_, err = client.Search().GetById(created.Id)
+	httpErr, ok := err.(*HttpError)
+	require.True(t, ok, "Expected http error")
+	assert.Equal(t, 404, httpErr.Code)
+}
+
+func Test_FakeKibana_7_references_round_trip(t *testing.T) {
+	fake := NewFakeKibana(DefaultKibanaVersion7)
+	defer fake.Close()
+
+	client := NewClient(fake.Config())
+	request, err := newTestVisualizationRequestBuilder().Build(client.Config.KibanaVersion)
+	require.NoError(t, err)
+
+	created, err := client.Visualization().Create(request)
+	require.NoError(t, err)
+	require.Len(t, created.References, 1)
+	assert.Equal(t, "123", created.References[0].Id)
+
+	list, err := client.Visualization().List()
+	require.NoError(t, err)
+	assert.Len(t, list, 1)
+}
+
+func Test_FakeKibana_6_rejects_references(t *testing.T) {
+	fake := NewFakeKibana(DefaultKibanaVersion6)
+	defer fake.Close()
+
+	client := NewClient(fake.Config())
+	request, err := newTestVisualizationRequestBuilder().Build(DefaultKibanaVersion7)
+	require.NoError(t, err)
+
+	_, err = client.Visualization().Create(request)
+	httpErr, ok := err.(*HttpError)
+	require.True(t, ok, "Expected http error")
+	assert.Equal(t, 400, httpErr.Code)
+}
+
+func Test_FakeKibana_space_scoped_saved_objects(t *testing.T) {
+	fake := NewFakeKibana(DefaultKibanaVersion7)
+	defer fake.Close()
+
+	client := NewClient(fake.Config())
+	require.NoError(t, client.Space().Create(&Space{Id: "blue", Name: "Blue team"}))
+
+	spaceConfig := fake.Config()
+	spaceConfig.KibanaBaseUri = fake.URL() + "/s/blue"
+	spaceClient := NewClient(spaceConfig)
+
+	request, err := newTestDashboardRequestBuilder("vis", "search").Build()
+	require.NoError(t, err)
+	created, err := spaceClient.Dashboard().Create(request)
+	require.NoError(t, err)
+
+	_, err = spaceClient.Dashboard().GetById(created.Id)
+	assert.NoError(t, err)
+
+	_, err = client.Dashboard().GetById(created.Id)
+	httpErr, ok := err.(*HttpError)
+	require.True(t, ok, "Expected http error")
+	assert.Equal(t, 404, httpErr.Code)
+}
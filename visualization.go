@@ -66,6 +66,7 @@ type VisualizationRequestBuilder struct {
 	title                 string
 	description           string
 	visualizationState    string
+	state                 *VisualizationState
 	savedSearchId         string
 	savedSearchRefName    string
 	kibanaSavedObjectMeta *SearchKibanaSavedObjectMeta
@@ -108,6 +109,13 @@ func (builder *VisualizationRequestBuilder) WithVisualizationState(visualization
 	return builder
 }
 
+// WithState sets a typed visualization state, it is encoded for the kibana version passed to Build
+// and its title defaults to the title of the visualization
+func (builder *VisualizationRequestBuilder) WithState(state *VisualizationState) *VisualizationRequestBuilder {
+	builder.state = state
+	return builder
+}
+
 func (builder *VisualizationRequestBuilder) WithSavedSearchId(savedSearchId string) *VisualizationRequestBuilder {
 	builder.savedSearchId = savedSearchId
 	return builder
@@ -128,7 +136,23 @@ func (builder *VisualizationRequestBuilder) WithReferences(refs []*Visualization
 	return builder
 }
 
+// Build creates the request for the kibana version, for 7.x and later the saved search is added as a reference
+// unless the references are set explicitly
 func (builder *VisualizationRequestBuilder) Build(version string) (*CreateVisualizationRequest, error) {
+	if builder.state != nil {
+		state := *builder.state
+		if state.Title == "" {
+			state.Title = builder.title
+		}
+
+		visualizationState, err := state.Encode(version)
+		if err != nil {
+			return nil, err
+		}
+
+		builder.visualizationState = visualizationState
+	}
+
 	if goversion.Compare(version, "7.0.0", "<") {
 		return &CreateVisualizationRequest{
 			Attributes: &VisualizationAttributes{
@@ -148,20 +172,23 @@ func (builder *VisualizationRequestBuilder) Build(version string) (*CreateVisual
 				Version:               1,
 				VisualizationState:    builder.visualizationState,
 				KibanaSavedObjectMeta: builder.kibanaSavedObjectMeta,
-				SavedSearchRefName:    "search_1",
+				SavedSearchRefName:    builder.savedSearchRefName,
 			},
-			References: []*VisualizationReferences{
+			References: builder.references,
+		}
+
+		if len(builder.references) == 0 && builder.savedSearchId != "" {
+			if req.Attributes.SavedSearchRefName == "" {
+				req.Attributes.SavedSearchRefName = "search_0"
+			}
+
+			req.References = []*VisualizationReferences{
 				{
-					Name: "search_1",
-					Type: "search",
+					Name: req.Attributes.SavedSearchRefName,
+					Type: VisualizationReferencesTypeSearch,
 					Id:   builder.savedSearchId,
 				},
-			},
-		}
-
-		if len(builder.references) > 0 {
-			req.References = builder.references
-			req.Attributes.SavedSearchRefName = ""
+			}
 		}
 
 		return req, nil
@@ -171,6 +198,7 @@ func (builder *VisualizationRequestBuilder) Build(version string) (*CreateVisual
 func (api *visualizationClient600) Create(request *CreateVisualizationRequest) (*Visualization, error) {
 	response, body, err := api.client.
 		Post(api.config.KibanaBaseUri+savedObjectsPath+"visualization?overwrite=true").
+		Operation("Visualization.Create", "visualization", "").
 		Set("kbn-version", api.config.KibanaVersion).
 		Send(request).
 		End()
@@ -195,6 +223,7 @@ func (api *visualizationClient600) Create(request *CreateVisualizationRequest) (
 func (api *visualizationClient600) GetById(id string) (*Visualization, error) {
 	response, body, err := api.client.
 		Get(api.config.KibanaBaseUri+savedObjectsPath+"visualization/"+id).
+		Operation("Visualization.GetById", "visualization", id).
 		Set("kbn-version", api.config.KibanaVersion).
 		End()
 
@@ -221,6 +250,7 @@ func (api *visualizationClient600) GetById(id string) (*Visualization, error) {
 func (api *visualizationClient600) List() ([]*Visualization, error) {
 	response, body, err := api.client.
 		Get(api.config.KibanaBaseUri+savedObjectsPath+"_find?type=visualization&per_page=9999").
+		Operation("Visualization.List", "visualization", "").
 		Set("kbn-version", api.config.KibanaVersion).
 		End()
 
@@ -250,6 +280,7 @@ func (api *visualizationClient600) List() ([]*Visualization, error) {
 func (api *visualizationClient600) Update(id string, request *UpdateVisualizationRequest) (*Visualization, error) {
 	response, body, err := api.client.
 		Post(api.config.KibanaBaseUri+savedObjectsPath+"visualization/"+id+"?overwrite=true").
+		Operation("Visualization.Update", "visualization", id).
 		Set("kbn-version", api.config.KibanaVersion).
 		Send(request).
 		End()
@@ -274,6 +305,7 @@ func (api *visualizationClient600) Update(id string, request *UpdateVisualizatio
 func (api *visualizationClient600) Delete(id string) error {
 	response, body, err := api.client.
 		Delete(api.config.KibanaBaseUri+savedObjectsPath+"visualization/"+id).
+		Operation("Visualization.Delete", "visualization", id).
 		Set("kbn-version", api.config.KibanaVersion).
 		End()
 
@@ -288,6 +320,7 @@ func (api *visualizationClient553) Create(request *CreateVisualizationRequest) (
 	id := uuid.NewV4().String()
 	response, body, errs := api.client.
 		Post(api.config.BuildFullPath("/%s/%s", "visualization", id)).
+		Operation("Visualization.Create", "visualization", id).
 		Set("kbn-version", api.config.KibanaVersion).
 		Send(request.Attributes).
 		End()
@@ -312,6 +345,7 @@ func (api *visualizationClient553) Create(request *CreateVisualizationRequest) (
 func (api *visualizationClient553) GetById(id string) (*Visualization, error) {
 	response, body, err := api.client.
 		Get(api.config.BuildFullPath("/%s/%s", "visualization", id)).
+		Operation("Visualization.GetById", "visualization", id).
 		Set("kbn-version", api.config.KibanaVersion).
 		End()
 
@@ -348,6 +382,7 @@ func (api *visualizationClient553) List() ([]*Visualization, error) {
 func (api *visualizationClient553) Update(id string, request *UpdateVisualizationRequest) (*Visualization, error) {
 	response, body, err := api.client.
 		Post(api.config.BuildFullPath("/%s/%s", "visualization", id)).
+		Operation("Visualization.Update", "visualization", id).
 		Set("kbn-version", api.config.KibanaVersion).
 		Send(request.Attributes).
 		End()
@@ -372,6 +407,7 @@ func (api *visualizationClient553) Update(id string, request *UpdateVisualizatio
 func (api *visualizationClient553) Delete(id string) error {
 	response, body, err := api.client.
 		Delete(api.config.BuildFullPath("/%s/%s", "visualization", id)).
+		Operation("Visualization.Delete", "visualization", id).
 		Set("kbn-version", api.config.KibanaVersion).
 		End()
 
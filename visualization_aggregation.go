@@ -0,0 +1,390 @@
+package kibana
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	goversion "github.com/mcuadros/go-version"
+)
+
+const (
+	AggregationSchemaMetric  AggregationSchema = "metric"
+	AggregationSchemaSegment AggregationSchema = "segment"
+	AggregationSchemaGroup   AggregationSchema = "group"
+	AggregationSchemaSplit   AggregationSchema = "split"
+)
+
+const (
+	AggregationTypeCount            AggregationType = "count"
+	AggregationTypeAvg              AggregationType = "avg"
+	AggregationTypeSum              AggregationType = "sum"
+	AggregationTypeMin              AggregationType = "min"
+	AggregationTypeMax              AggregationType = "max"
+	AggregationTypeCardinality      AggregationType = "cardinality"
+	AggregationTypePercentiles      AggregationType = "percentiles"
+	AggregationTypeTerms            AggregationType = "terms"
+	AggregationTypeDateHistogram    AggregationType = "date_histogram"
+	AggregationTypeHistogram        AggregationType = "histogram"
+	AggregationTypeRange            AggregationType = "range"
+	AggregationTypeFilters          AggregationType = "filters"
+	AggregationTypeSignificantTerms AggregationType = "significant_terms"
+)
+
+// AggregationSchema is the role of an aggregation in a visualization, metrics are the values drawn and
+// the bucket aggregations split them into segments, groups or separate charts
+type AggregationSchema string
+
+type AggregationType string
+
+func (s AggregationSchema) String() string {
+	return string(s)
+}
+
+func (t AggregationType) String() string {
+	return string(t)
+}
+
+// AggregationParams are the typed params of an aggregation
+type AggregationParams interface {
+	AggregationType() AggregationType
+}
+
+type CountParams struct {
+}
+
+// FieldMetricParams are the params of the avg, sum, min, max and cardinality aggregations
+type FieldMetricParams struct {
+	Type  AggregationType `json:"-"`
+	Field string          `json:"field"`
+}
+
+type PercentilesParams struct {
+	Field    string    `json:"field"`
+	Percents []float64 `json:"percents,omitempty"`
+}
+
+type TermsParams struct {
+	Field              string `json:"field"`
+	Size               int    `json:"size"`
+	Order              string `json:"order"`
+	OrderBy            string `json:"orderBy"`
+	OtherBucket        bool   `json:"otherBucket,omitempty"`
+	OtherBucketLabel   string `json:"otherBucketLabel,omitempty"`
+	MissingBucket      bool   `json:"missingBucket,omitempty"`
+	MissingBucketLabel string `json:"missingBucketLabel,omitempty"`
+}
+
+type DateHistogramParams struct {
+	Field          string                 `json:"field"`
+	Interval       string                 `json:"interval"`
+	CustomInterval string                 `json:"customInterval,omitempty"`
+	MinDocCount    int                    `json:"min_doc_count"`
+	ExtendedBounds map[string]interface{} `json:"extended_bounds,omitempty"`
+}
+
+type HistogramParams struct {
+	Field          string                 `json:"field"`
+	Interval       float64                `json:"interval"`
+	MinDocCount    bool                   `json:"min_doc_count"`
+	ExtendedBounds map[string]interface{} `json:"extended_bounds,omitempty"`
+}
+
+type RangeParams struct {
+	Field  string              `json:"field"`
+	Ranges []*AggregationRange `json:"ranges"`
+}
+
+// AggregationRange is a bucket of a range aggregation, a nil From or To leaves that side of the range open
+type AggregationRange struct {
+	From *float64 `json:"from,omitempty"`
+	To   *float64 `json:"to,omitempty"`
+}
+
+type FiltersParams struct {
+	Filters []*AggregationFilter `json:"filters"`
+}
+
+// AggregationFilter is a bucket of a filters aggregation, kibana versions before 6.0 only support lucene
+// queries and store them as a query_string query
+type AggregationFilter struct {
+	Input *AggregationFilterInput `json:"input"`
+	Label string                  `json:"label"`
+}
+
+type AggregationFilterInput struct {
+	Query    string `json:"query"`
+	Language string `json:"language,omitempty"`
+}
+
+type SignificantTermsParams struct {
+	Field string `json:"field"`
+	Size  int    `json:"size"`
+}
+
+type aggregationFilter553 struct {
+	Input *aggregationFilterInput553 `json:"input"`
+	Label string                     `json:"label"`
+}
+
+type aggregationFilterInput553 struct {
+	Query *SearchQuery553 `json:"query"`
+}
+
+var aggregationParamsFromType = map[AggregationType]func() AggregationParams{
+	AggregationTypeCount:            func() AggregationParams { return &CountParams{} },
+	AggregationTypeAvg:              func() AggregationParams { return &FieldMetricParams{Type: AggregationTypeAvg} },
+	AggregationTypeSum:              func() AggregationParams { return &FieldMetricParams{Type: AggregationTypeSum} },
+	AggregationTypeMin:              func() AggregationParams { return &FieldMetricParams{Type: AggregationTypeMin} },
+	AggregationTypeMax:              func() AggregationParams { return &FieldMetricParams{Type: AggregationTypeMax} },
+	AggregationTypeCardinality:      func() AggregationParams { return &FieldMetricParams{Type: AggregationTypeCardinality} },
+	AggregationTypePercentiles:      func() AggregationParams { return &PercentilesParams{} },
+	AggregationTypeTerms:            func() AggregationParams { return &TermsParams{} },
+	AggregationTypeDateHistogram:    func() AggregationParams { return &DateHistogramParams{} },
+	AggregationTypeHistogram:        func() AggregationParams { return &HistogramParams{} },
+	AggregationTypeRange:            func() AggregationParams { return &RangeParams{} },
+	AggregationTypeFilters:          func() AggregationParams { return &FiltersParams{} },
+	AggregationTypeSignificantTerms: func() AggregationParams { return &SignificantTermsParams{} },
+}
+
+var bucketFieldTypes = map[AggregationType][]string{
+	AggregationTypeTerms:            nil,
+	AggregationTypeSignificantTerms: {"string"},
+	AggregationTypeDateHistogram:    {"date"},
+	AggregationTypeHistogram:        {"number"},
+	AggregationTypeRange:            {"number"},
+	AggregationTypeFilters:          nil,
+}
+
+func (params *CountParams) AggregationType() AggregationType {
+	return AggregationTypeCount
+}
+
+func (params *FieldMetricParams) AggregationType() AggregationType {
+	return params.Type
+}
+
+func (params *PercentilesParams) AggregationType() AggregationType {
+	return AggregationTypePercentiles
+}
+
+func (params *TermsParams) AggregationType() AggregationType {
+	return AggregationTypeTerms
+}
+
+func (params *DateHistogramParams) AggregationType() AggregationType {
+	return AggregationTypeDateHistogram
+}
+
+func (params *HistogramParams) AggregationType() AggregationType {
+	return AggregationTypeHistogram
+}
+
+func (params *RangeParams) AggregationType() AggregationType {
+	return AggregationTypeRange
+}
+
+func (params *FiltersParams) AggregationType() AggregationType {
+	return AggregationTypeFilters
+}
+
+func (params *SignificantTermsParams) AggregationType() AggregationType {
+	return AggregationTypeSignificantTerms
+}
+
+// IsBucket returns true for the aggregations which split the documents into buckets
+func (t AggregationType) IsBucket() bool {
+	_, ok := bucketFieldTypes[t]
+	return ok
+}
+
+// NewAggregation creates an aggregation of the type of the params with the given id and schema
+func NewAggregation(id string, schema AggregationSchema, params AggregationParams) (*VisualizationAggregation, error) {
+	data, err := json.Marshal(params)
+	if err != nil {
+		return nil, fmt.Errorf("could not encode %s aggregation params, error: %v", params.AggregationType(), err)
+	}
+
+	aggregationParams := map[string]interface{}{}
+	if err := json.Unmarshal(data, &aggregationParams); err != nil {
+		return nil, fmt.Errorf("could not encode %s aggregation params, error: %v", params.AggregationType(), err)
+	}
+
+	return &VisualizationAggregation{
+		Id:      id,
+		Enabled: true,
+		Type:    params.AggregationType().String(),
+		Schema:  schema.String(),
+		Params:  aggregationParams,
+	}, nil
+}
+
+// TypedParams decodes the params of an aggregation of one of the AggregationType types
+func (aggregation *VisualizationAggregation) TypedParams() (AggregationParams, error) {
+	newParams, ok := aggregationParamsFromType[AggregationType(aggregation.Type)]
+	if !ok {
+		return nil, fmt.Errorf("aggregation type %s has no typed params", aggregation.Type)
+	}
+
+	data, err := json.Marshal(aggregation.Params)
+	if err != nil {
+		return nil, fmt.Errorf("could not parse %s aggregation params, error: %v", aggregation.Type, err)
+	}
+
+	params := newParams()
+	if err := json.Unmarshal(data, params); err != nil {
+		return nil, fmt.Errorf("could not parse %s aggregation params, error: %v", aggregation.Type, err)
+	}
+
+	return params, nil
+}
+
+func (input *AggregationFilterInput) UnmarshalJSON(data []byte) error {
+	var raw struct {
+		Query    json.RawMessage `json:"query"`
+		Language string          `json:"language"`
+	}
+
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+
+	input.Language = raw.Language
+	if len(raw.Query) == 0 {
+		input.Query = ""
+		return nil
+	}
+
+	if err := json.Unmarshal(raw.Query, &input.Query); err == nil {
+		return nil
+	}
+
+	query := &SearchQuery553{}
+	if err := json.Unmarshal(raw.Query, query); err != nil {
+		return err
+	}
+
+	if query.QueryString != nil {
+		input.Query = query.QueryString.Query
+	}
+
+	input.Language = "lucene"
+	return nil
+}
+
+// Validate checks that every aggregation has a schema role matching its type and that the aggregations
+// referencing a field use an existing, aggregatable field of the index pattern with a supported type
+func (state *VisualizationState) Validate(fields []*IndexPatternField) error {
+	return ValidateAggregations(state.Aggs, fields)
+}
+
+func ValidateAggregations(aggregations []*VisualizationAggregation, fields []*IndexPatternField) error {
+	fieldsByName := map[string]*IndexPatternField{}
+	for _, field := range fields {
+		fieldsByName[field.Name] = field
+	}
+
+	var problems []string
+	for _, aggregation := range aggregations {
+		aggregationType := AggregationType(aggregation.Type)
+		schema := AggregationSchema(aggregation.Schema)
+		if problem := schemaProblem(aggregationType, schema); problem != "" {
+			problems = append(problems, fmt.Sprintf("aggregation %s: %s", aggregation.Id, problem))
+		}
+
+		fieldName, ok := aggregation.Params["field"].(string)
+		if !ok || fieldName == "" {
+			continue
+		}
+
+		field, ok := fieldsByName[fieldName]
+		if !ok {
+			problems = append(problems, fmt.Sprintf("aggregation %s: field %s does not exist in the index pattern", aggregation.Id, fieldName))
+			continue
+		}
+
+		if !field.Aggregatable {
+			problems = append(problems, fmt.Sprintf("aggregation %s: field %s is not aggregatable", aggregation.Id, fieldName))
+			continue
+		}
+
+		if types := bucketFieldTypes[aggregationType]; len(types) > 0 && !containsFieldType(types, field.Type) {
+			problems = append(problems, fmt.Sprintf("aggregation %s: %s aggregation requires a field of type %s, %s is a %s field",
+				aggregation.Id, aggregationType, strings.Join(types, " or "), fieldName, field.Type))
+		}
+	}
+
+	if len(problems) > 0 {
+		return fmt.Errorf("invalid aggregations, %s", strings.Join(problems, "; "))
+	}
+
+	return nil
+}
+
+// schemaProblem describes why the schema role can not be used for the aggregation type, the roles of
+// aggregation types without a typed model are unknown so they are not checked
+func schemaProblem(aggregationType AggregationType, schema AggregationSchema) string {
+	if _, ok := aggregationParamsFromType[aggregationType]; !ok {
+		return ""
+	}
+
+	if aggregationType.IsBucket() && schema == AggregationSchemaMetric {
+		return fmt.Sprintf("bucket aggregation %s can not be used as a metric", aggregationType)
+	}
+
+	if !aggregationType.IsBucket() && schema != AggregationSchemaMetric {
+		return fmt.Sprintf("metric aggregation %s can not be used as a %s", aggregationType, schema)
+	}
+
+	return ""
+}
+
+func containsFieldType(types []string, fieldType string) bool {
+	for _, item := range types {
+		if item == fieldType {
+			return true
+		}
+	}
+
+	return false
+}
+
+// aggregationsForVersion converts the params which kibana versions before 6.0 store differently
+func aggregationsForVersion(aggregations []*VisualizationAggregation, version string) []*VisualizationAggregation {
+	if goversion.Compare(version, "6.0.0", ">=") {
+		return aggregations
+	}
+
+	result := make([]*VisualizationAggregation, 0, len(aggregations))
+	for _, aggregation := range aggregations {
+		if AggregationType(aggregation.Type) != AggregationTypeFilters {
+			result = append(result, aggregation)
+			continue
+		}
+
+		params, err := aggregation.TypedParams()
+		if err != nil {
+			result = append(result, aggregation)
+			continue
+		}
+
+		filters := []*aggregationFilter553{}
+		for _, filter := range params.(*FiltersParams).Filters {
+			query := ""
+			if filter.Input != nil {
+				query = filter.Input.Query
+			}
+
+			filters = append(filters, &aggregationFilter553{
+				Input: &aggregationFilterInput553{Query: &SearchQuery553{QueryString: &searchQueryString{Query: query, Analyze: true}}},
+				Label: filter.Label,
+			})
+		}
+
+		converted := *aggregation
+		converted.Params = map[string]interface{}{"filters": filters}
+		result = append(result, &converted)
+	}
+
+	return result
+}
@@ -0,0 +1,121 @@
+package kibana
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var testIndexPatternFields = []*IndexPatternField{
+	{Name: "@timestamp", Type: "date", Aggregatable: true, Searchable: true},
+	{Name: "bytes", Type: "number", Aggregatable: true, Searchable: true},
+	{Name: "geo.src", Type: "string", Aggregatable: true, Searchable: true},
+	{Name: "message", Type: "string", Aggregatable: false, Searchable: true},
+}
+
+func Test_Aggregation_typed_params_round_trip(t *testing.T) {
+	from, to := 0.0, 1000.0
+	allParams := []AggregationParams{
+		&CountParams{},
+		&FieldMetricParams{Type: AggregationTypeAvg, Field: "bytes"},
+		&FieldMetricParams{Type: AggregationTypeCardinality, Field: "geo.src"},
+		&PercentilesParams{Field: "bytes", Percents: []float64{50, 95, 99}},
+		&TermsParams{Field: "geo.src", Size: 5, Order: "desc", OrderBy: "1", OtherBucket: true, OtherBucketLabel: "Other"},
+		&DateHistogramParams{Field: "@timestamp", Interval: "auto", MinDocCount: 1, ExtendedBounds: map[string]interface{}{}},
+		&HistogramParams{Field: "bytes", Interval: 100},
+		&RangeParams{Field: "bytes", Ranges: []*AggregationRange{{From: &from, To: &to}, {From: &to}}},
+		&FiltersParams{Filters: []*AggregationFilter{{Input: &AggregationFilterInput{Query: "response:404", Language: "lucene"}, Label: "missing"}}},
+		&SignificantTermsParams{Field: "geo.src", Size: 10},
+	}
+
+	for _, params := range allParams {
+		aggregation, err := NewAggregation("2", AggregationSchemaSegment, params)
+		require.NoError(t, err)
+		assert.Equal(t, params.AggregationType().String(), aggregation.Type)
+		assert.True(t, aggregation.Enabled)
+
+		typed, err := aggregation.TypedParams()
+		require.NoError(t, err)
+		if params.AggregationType() == AggregationTypeDateHistogram {
+			// an empty extended bounds is omitted
+			params.(*DateHistogramParams).ExtendedBounds = nil
+		}
+
+		assert.Equal(t, params, typed)
+	}
+}
+
+func Test_Aggregation_filters_are_encoded_as_query_string_before_6(t *testing.T) {
+	state, err := NewTableVisualizationBuilder().
+		WithTypedAggregation("1", AggregationSchemaMetric, &CountParams{}).
+		WithTypedAggregation("2", AggregationSchemaSplit, &FiltersParams{Filters: []*AggregationFilter{
+			{Input: &AggregationFilterInput{Query: "geo.src:CN", Language: "lucene"}, Label: "china"},
+		}}).
+		Build()
+	require.NoError(t, err)
+
+	encoded, err := state.Encode("5.5.3")
+	require.NoError(t, err)
+
+	var raw struct {
+		Aggs []struct {
+			Params json.RawMessage `json:"params"`
+		} `json:"aggs"`
+	}
+	require.NoError(t, json.Unmarshal([]byte(encoded), &raw))
+	assert.JSONEq(t, `{"filters":[{"input":{"query":{"query_string":{"query":"geo.src:CN","analyze_wildcard":true}}},"label":"china"}]}`, string(raw.Aggs[1].Params))
+
+	decoded, err := ParseVisualizationState(encoded)
+	require.NoError(t, err)
+	params, err := decoded.Aggs[1].TypedParams()
+	require.NoError(t, err)
+	assert.Equal(t, &AggregationFilterInput{Query: "geo.src:CN", Language: "lucene"}, params.(*FiltersParams).Filters[0].Input)
+
+	encoded, err = state.Encode(DefaultKibanaVersion7)
+	require.NoError(t, err)
+	require.NoError(t, json.Unmarshal([]byte(encoded), &raw))
+	assert.JSONEq(t, `{"filters":[{"input":{"query":"geo.src:CN","language":"lucene"},"label":"china"}]}`, string(raw.Aggs[1].Params))
+}
+
+func Test_Aggregation_validation_against_index_pattern_fields(t *testing.T) {
+	_, err := NewBarVisualizationBuilder().
+		WithTypedAggregation("1", AggregationSchemaMetric, &FieldMetricParams{Type: AggregationTypeSum, Field: "bytes"}).
+		WithTypedAggregation("2", AggregationSchemaSegment, &DateHistogramParams{Field: "@timestamp", Interval: "auto", MinDocCount: 1}).
+		WithTypedAggregation("3", AggregationSchemaGroup, &TermsParams{Field: "geo.src", Size: 5, Order: "desc", OrderBy: "1"}).
+		WithIndexPatternFields(testIndexPatternFields).
+		Build()
+	require.NoError(t, err)
+
+	_, err = NewBarVisualizationBuilder().
+		WithTypedAggregation("1", AggregationSchemaSegment, &CountParams{}).
+		WithTypedAggregation("2", AggregationSchemaSegment, &DateHistogramParams{Field: "geo.src", Interval: "auto"}).
+		WithTypedAggregation("3", AggregationSchemaGroup, &TermsParams{Field: "message", Size: 5}).
+		WithTypedAggregation("4", AggregationSchemaSplit, &RangeParams{Field: "response_time"}).
+		WithTypedAggregation("5", AggregationSchemaMetric, &TermsParams{Field: "geo.src", Size: 5}).
+		WithIndexPatternFields(testIndexPatternFields).
+		Build()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "aggregation 1: metric aggregation count can not be used as a segment")
+	assert.Contains(t, err.Error(), "aggregation 2: date_histogram aggregation requires a field of type date, geo.src is a string field")
+	assert.Contains(t, err.Error(), "aggregation 3: field message is not aggregatable")
+	assert.Contains(t, err.Error(), "aggregation 4: field response_time does not exist in the index pattern")
+	assert.Contains(t, err.Error(), "aggregation 5: bucket aggregation terms can not be used as a metric")
+
+	unknown := &VisualizationAggregation{Id: "6", Type: "date_range", Schema: "segment", Params: map[string]interface{}{"field": "@timestamp"}}
+	assert.NoError(t, ValidateAggregations([]*VisualizationAggregation{unknown}, testIndexPatternFields))
+}
+
+func Test_IndexPatternAttributes_FieldList(t *testing.T) {
+	data, err := json.Marshal(testIndexPatternFields)
+	require.NoError(t, err)
+
+	fields, err := (&IndexPatternAttributes{Fields: string(data)}).FieldList()
+	require.NoError(t, err)
+	assert.Equal(t, testIndexPatternFields, fields)
+
+	fields, err = (&IndexPatternAttributes{}).FieldList()
+	require.NoError(t, err)
+	assert.Empty(t, fields)
+}
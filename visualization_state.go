@@ -0,0 +1,864 @@
+package kibana
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	goversion "github.com/mcuadros/go-version"
+)
+
+const (
+	VisualizationTypeLine     VisualizationType = "line"
+	VisualizationTypeArea     VisualizationType = "area"
+	VisualizationTypeBar      VisualizationType = "histogram"
+	VisualizationTypePie      VisualizationType = "pie"
+	VisualizationTypeMetric   VisualizationType = "metric"
+	VisualizationTypeTable    VisualizationType = "table"
+	VisualizationTypeMarkdown VisualizationType = "markdown"
+	VisualizationTypeTagCloud VisualizationType = "tagcloud"
+	VisualizationTypeGauge    VisualizationType = "gauge"
+	VisualizationTypeTSVB     VisualizationType = "metrics"
+	VisualizationTypeVega     VisualizationType = "vega"
+)
+
+type VisualizationType string
+
+func (t VisualizationType) String() string {
+	return string(t)
+}
+
+// VisualizationState is the typed form of the visState attribute of a visualization. Params holds one of the
+// typed params below depending on the type, or RawVisualizationParams for the other visualization types. The
+// params kibana writes which the typed params do not model, such as the thresholdLine of 7.x charts, are kept
+// as they were read and encoded again by Encode.
+type VisualizationState struct {
+	Title         string                      `json:"title"`
+	Type          VisualizationType           `json:"type"`
+	Params        VisualizationParams         `json:"params"`
+	Aggs          []*VisualizationAggregation `json:"aggs"`
+	unknownParams interface{}
+}
+
+// VisualizationParams are the chart specific settings of a visualization, they are encoded differently
+// depending on the kibana version
+type VisualizationParams interface {
+	paramsForVersion(version string) interface{}
+}
+
+// RawVisualizationParams are the params of visualization types without a typed model
+type RawVisualizationParams map[string]interface{}
+
+type VisualizationAggregation struct {
+	Id      string                 `json:"id"`
+	Enabled bool                   `json:"enabled"`
+	Type    string                 `json:"type"`
+	Schema  string                 `json:"schema"`
+	Params  map[string]interface{} `json:"params"`
+}
+
+// PointSeriesParams are the params of line, area and bar charts
+type PointSeriesParams struct {
+	Type           VisualizationType    `json:"type"`
+	Grid           *VisualizationGrid   `json:"grid"`
+	CategoryAxes   []*VisualizationAxis `json:"categoryAxes"`
+	ValueAxes      []*VisualizationAxis `json:"valueAxes"`
+	SeriesParams   []*SeriesParams      `json:"seriesParams"`
+	AddTooltip     bool                 `json:"addTooltip"`
+	AddLegend      bool                 `json:"addLegend"`
+	LegendPosition string               `json:"legendPosition"`
+	Times          []interface{}        `json:"times"`
+	AddTimeMarker  bool                 `json:"addTimeMarker"`
+}
+
+type VisualizationGrid struct {
+	CategoryLines bool                   `json:"categoryLines"`
+	ValueAxis     string                 `json:"valueAxis,omitempty"`
+	Style         map[string]interface{} `json:"style,omitempty"`
+}
+
+type VisualizationAxis struct {
+	Id       string                 `json:"id"`
+	Name     string                 `json:"name,omitempty"`
+	Type     string                 `json:"type"`
+	Position string                 `json:"position"`
+	Show     bool                   `json:"show"`
+	Style    map[string]interface{} `json:"style"`
+	Scale    *VisualizationScale    `json:"scale"`
+	Labels   *VisualizationLabels   `json:"labels"`
+	Title    *VisualizationTitle    `json:"title"`
+}
+
+type VisualizationScale struct {
+	Type string `json:"type"`
+	Mode string `json:"mode,omitempty"`
+}
+
+type VisualizationLabels struct {
+	Show     bool `json:"show"`
+	Rotate   int  `json:"rotate,omitempty"`
+	Filter   bool `json:"filter"`
+	Truncate int  `json:"truncate"`
+}
+
+type VisualizationTitle struct {
+	Text string `json:"text,omitempty"`
+}
+
+// SeriesParams configures how the metric with the id in Data is drawn, kibana versions before 7.0 store Show as a string
+type SeriesParams struct {
+	Show                   bool              `json:"show"`
+	Type                   VisualizationType `json:"type"`
+	Mode                   string            `json:"mode"`
+	Data                   *SeriesData       `json:"data"`
+	ValueAxis              string            `json:"valueAxis"`
+	DrawLinesBetweenPoints bool              `json:"drawLinesBetweenPoints"`
+	ShowCircles            bool              `json:"showCircles"`
+	Interpolate            string            `json:"interpolate,omitempty"`
+}
+
+type SeriesData struct {
+	Label string `json:"label"`
+	Id    string `json:"id"`
+}
+
+type PieParams struct {
+	Type           VisualizationType `json:"type"`
+	AddTooltip     bool              `json:"addTooltip"`
+	AddLegend      bool              `json:"addLegend"`
+	LegendPosition string            `json:"legendPosition"`
+	IsDonut        bool              `json:"isDonut"`
+	Labels         *PieLabels        `json:"labels,omitempty"`
+}
+
+type PieLabels struct {
+	Show      bool `json:"show"`
+	Values    bool `json:"values"`
+	LastLevel bool `json:"last_level"`
+	Truncate  int  `json:"truncate"`
+}
+
+type MetricParams struct {
+	Type       VisualizationType `json:"type"`
+	AddTooltip bool              `json:"addTooltip"`
+	AddLegend  bool              `json:"addLegend"`
+	Metric     *MetricStyle      `json:"metric"`
+}
+
+type MetricStyle struct {
+	PercentageMode  bool                  `json:"percentageMode"`
+	UseRanges       bool                  `json:"useRanges"`
+	ColorSchema     string                `json:"colorSchema"`
+	MetricColorMode string                `json:"metricColorMode"`
+	ColorsRange     []*VisualizationRange `json:"colorsRange"`
+	Labels          *VisualizationShow    `json:"labels"`
+	InvertColors    bool                  `json:"invertColors"`
+	Style           *MetricTextStyle      `json:"style"`
+}
+
+type MetricTextStyle struct {
+	BgFill     string `json:"bgFill"`
+	BgColor    bool   `json:"bgColor"`
+	LabelColor bool   `json:"labelColor"`
+	SubText    string `json:"subText"`
+	FontSize   int    `json:"fontSize"`
+}
+
+type VisualizationRange struct {
+	From float64 `json:"from"`
+	To   float64 `json:"to"`
+}
+
+type VisualizationShow struct {
+	Show  bool   `json:"show"`
+	Color string `json:"color,omitempty"`
+}
+
+// TableParams are the params of a data table, kibana versions before 7.0 spell ShowMetricsAtAllLevels as showMeticsAtAllLevels
+type TableParams struct {
+	PerPage                int        `json:"perPage"`
+	ShowPartialRows        bool       `json:"showPartialRows"`
+	ShowMetricsAtAllLevels bool       `json:"showMetricsAtAllLevels"`
+	Sort                   *TableSort `json:"sort"`
+	ShowTotal              bool       `json:"showTotal"`
+	TotalFunc              string     `json:"totalFunc"`
+}
+
+type TableSort struct {
+	ColumnIndex *int    `json:"columnIndex"`
+	Direction   *string `json:"direction"`
+}
+
+// MarkdownParams are the params of a markdown widget, FontSize and OpenLinksInNewTab are only sent to kibana 6.0 and later
+type MarkdownParams struct {
+	FontSize          int    `json:"fontSize"`
+	OpenLinksInNewTab bool   `json:"openLinksInNewTab"`
+	Markdown          string `json:"markdown"`
+}
+
+type TagCloudParams struct {
+	Scale       string `json:"scale"`
+	Orientation string `json:"orientation"`
+	MinFontSize int    `json:"minFontSize"`
+	MaxFontSize int    `json:"maxFontSize"`
+	ShowLabel   bool   `json:"showLabel"`
+}
+
+type GaugeParams struct {
+	Type       VisualizationType `json:"type"`
+	AddTooltip bool              `json:"addTooltip"`
+	AddLegend  bool              `json:"addLegend"`
+	Gauge      *GaugeStyle       `json:"gauge"`
+}
+
+type GaugeStyle struct {
+	VerticalSplit  bool                  `json:"verticalSplit"`
+	ExtendRange    bool                  `json:"extendRange"`
+	PercentageMode bool                  `json:"percentageMode"`
+	GaugeType      string                `json:"gaugeType"`
+	GaugeStyle     string                `json:"gaugeStyle"`
+	BackStyle      string                `json:"backStyle"`
+	Orientation    string                `json:"orientation"`
+	ColorSchema    string                `json:"colorSchema"`
+	GaugeColorMode string                `json:"gaugeColorMode"`
+	ColorsRange    []*VisualizationRange `json:"colorsRange"`
+	InvertColors   bool                  `json:"invertColors"`
+	Labels         *VisualizationShow    `json:"labels"`
+	Scale          *GaugeScale           `json:"scale"`
+	Type           string                `json:"type"`
+	Style          *GaugeFillStyle       `json:"style"`
+}
+
+type GaugeScale struct {
+	Show   bool   `json:"show"`
+	Labels bool   `json:"labels"`
+	Color  string `json:"color"`
+}
+
+type GaugeFillStyle struct {
+	BgWidth    float64 `json:"bgWidth"`
+	Width      float64 `json:"width"`
+	Mask       bool    `json:"mask"`
+	BgMask     bool    `json:"bgMask"`
+	MaskBars   int     `json:"maskBars"`
+	BgFill     string  `json:"bgFill"`
+	BgColor    bool    `json:"bgColor"`
+	SubText    string  `json:"subText"`
+	FontSize   int     `json:"fontSize"`
+	LabelColor bool    `json:"labelColor"`
+}
+
+type VisualizationStateBuilder struct {
+	title             string
+	visualizationType VisualizationType
+	params            VisualizationParams
+	aggs              []*VisualizationAggregation
+	fields            []*IndexPatternField
+	err               error
+}
+
+type visualizationStateJson struct {
+	Title     string                      `json:"title"`
+	Type      VisualizationType           `json:"type"`
+	Params    interface{}                 `json:"params"`
+	Aggs      []*VisualizationAggregation `json:"aggs"`
+	Listeners *struct{}                   `json:"listeners,omitempty"`
+}
+
+type seriesParams600 struct {
+	*SeriesParams
+	Show string `json:"show"`
+}
+
+type pointSeriesParams600 struct {
+	*PointSeriesParams
+	SeriesParams []*seriesParams600 `json:"seriesParams"`
+}
+
+type tableParams600 struct {
+	PerPage               int        `json:"perPage"`
+	ShowPartialRows       bool       `json:"showPartialRows"`
+	ShowMeticsAtAllLevels bool       `json:"showMeticsAtAllLevels"`
+	Sort                  *TableSort `json:"sort"`
+	ShowTotal             bool       `json:"showTotal"`
+	TotalFunc             string     `json:"totalFunc"`
+}
+
+type markdownParams553 struct {
+	Markdown string `json:"markdown"`
+}
+
+var visualizationParamsFromType = map[VisualizationType]func() VisualizationParams{
+	VisualizationTypeLine:     func() VisualizationParams { return &PointSeriesParams{} },
+	VisualizationTypeArea:     func() VisualizationParams { return &PointSeriesParams{} },
+	VisualizationTypeBar:      func() VisualizationParams { return &PointSeriesParams{} },
+	VisualizationTypePie:      func() VisualizationParams { return &PieParams{} },
+	VisualizationTypeMetric:   func() VisualizationParams { return &MetricParams{} },
+	VisualizationTypeTable:    func() VisualizationParams { return &TableParams{} },
+	VisualizationTypeMarkdown: func() VisualizationParams { return &MarkdownParams{} },
+	VisualizationTypeTagCloud: func() VisualizationParams { return &TagCloudParams{} },
+	VisualizationTypeGauge:    func() VisualizationParams { return &GaugeParams{} },
+	VisualizationTypeTSVB:     func() VisualizationParams { return &TSVBParams{} },
+	VisualizationTypeVega:     func() VisualizationParams { return &VegaParams{} },
+}
+
+// visualizationTypesWithoutAggregations compute their data from their params
+var visualizationTypesWithoutAggregations = map[VisualizationType]bool{
+	VisualizationTypeMarkdown: true,
+	VisualizationTypeTSVB:     true,
+	VisualizationTypeVega:     true,
+}
+
+// ParseVisualizationState decodes the visState attribute of a visualization
+func ParseVisualizationState(visualizationState string) (*VisualizationState, error) {
+	state := &VisualizationState{}
+	if err := json.Unmarshal([]byte(visualizationState), state); err != nil {
+		return nil, fmt.Errorf("could not parse visualization state, error: %v", err)
+	}
+
+	return state, nil
+}
+
+// State decodes the visState attribute
+func (attributes *VisualizationAttributes) State() (*VisualizationState, error) {
+	return ParseVisualizationState(attributes.VisualizationState)
+}
+
+// Encode serializes the state to the visState attribute format of the kibana version
+func (state *VisualizationState) Encode(version string) (string, error) {
+	if state.Type == VisualizationTypeVega && goversion.Compare(version, "6.2.0", "<") {
+		return "", fmt.Errorf("vega visualizations are not supported by kibana %s, they require kibana 6.2 or later", version)
+	}
+
+	encoded := &visualizationStateJson{
+		Title: state.Title,
+		Type:  state.Type,
+		Aggs:  aggregationsForVersion(state.Aggs, version),
+	}
+
+	if state.Params != nil {
+		encoded.Params = state.Params.paramsForVersion(version)
+	} else {
+		encoded.Params = map[string]interface{}{}
+	}
+
+	if state.unknownParams != nil {
+		var params interface{}
+		if err := encodeGenericJson(encoded.Params, &params); err != nil {
+			return "", err
+		}
+
+		encoded.Params = mergeUnknownJson(params, state.unknownParams)
+	}
+
+	if encoded.Aggs == nil {
+		encoded.Aggs = []*VisualizationAggregation{}
+	}
+
+	if goversion.Compare(version, "7.0.0", "<") {
+		encoded.Listeners = &struct{}{}
+	}
+
+	data, err := json.Marshal(encoded)
+	if err != nil {
+		return "", fmt.Errorf("could not encode visualization state, error: %v", err)
+	}
+
+	return string(data), nil
+}
+
+func (state *VisualizationState) UnmarshalJSON(data []byte) error {
+	var raw struct {
+		Title  string                      `json:"title"`
+		Type   VisualizationType           `json:"type"`
+		Params json.RawMessage             `json:"params"`
+		Aggs   []*VisualizationAggregation `json:"aggs"`
+	}
+
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+
+	newParams, ok := visualizationParamsFromType[raw.Type]
+	if !ok {
+		newParams = func() VisualizationParams { return &RawVisualizationParams{} }
+	}
+
+	params := newParams()
+	if len(raw.Params) > 0 {
+		if err := json.Unmarshal(raw.Params, params); err != nil {
+			return fmt.Errorf("could not parse params of %s visualization, error: %v", raw.Type, err)
+		}
+	}
+
+	if rawParams, ok := params.(*RawVisualizationParams); ok {
+		params = *rawParams
+	}
+
+	state.Title = raw.Title
+	state.Type = raw.Type
+	state.Params = params
+	state.Aggs = raw.Aggs
+	state.unknownParams = nil
+	if _, generic := params.(RawVisualizationParams); !generic && len(raw.Params) > 0 {
+		var original, decoded interface{}
+		if err := decodeGenericJson(raw.Params, &original); err != nil {
+			return err
+		}
+
+		if err := encodeGenericJson(params, &decoded); err != nil {
+			return err
+		}
+
+		state.unknownParams = unknownJson(original, decoded)
+	}
+
+	return nil
+}
+
+// unknownJson returns the parts of the original json which are missing from the decoded json, nil when there
+// are none. Objects are compared key by key and lists of the same length item by item.
+func unknownJson(original interface{}, decoded interface{}) interface{} {
+	switch original := original.(type) {
+	case map[string]interface{}:
+		decoded, ok := decoded.(map[string]interface{})
+		if !ok {
+			return nil
+		}
+
+		unknown := map[string]interface{}{}
+		for key, value := range original {
+			if decodedValue, ok := decoded[key]; !ok {
+				unknown[key] = value
+			} else if value := unknownJson(value, decodedValue); value != nil {
+				unknown[key] = value
+			}
+		}
+
+		if len(unknown) == 0 {
+			return nil
+		}
+
+		return unknown
+	case []interface{}:
+		decoded, ok := decoded.([]interface{})
+		if !ok || len(decoded) != len(original) {
+			return nil
+		}
+
+		unknown := make([]interface{}, len(original))
+		found := false
+		for index := range original {
+			unknown[index] = unknownJson(original[index], decoded[index])
+			found = found || unknown[index] != nil
+		}
+
+		if !found {
+			return nil
+		}
+
+		return unknown
+	}
+
+	return nil
+}
+
+// mergeUnknownJson adds the unknown json returned by unknownJson to the encoded json
+func mergeUnknownJson(encoded interface{}, unknown interface{}) interface{} {
+	switch unknown := unknown.(type) {
+	case map[string]interface{}:
+		encoded, ok := encoded.(map[string]interface{})
+		if !ok {
+			return encoded
+		}
+
+		for key, value := range unknown {
+			if encodedValue, ok := encoded[key]; ok {
+				encoded[key] = mergeUnknownJson(encodedValue, value)
+			} else {
+				encoded[key] = value
+			}
+		}
+
+		return encoded
+	case []interface{}:
+		encoded, ok := encoded.([]interface{})
+		if !ok {
+			return encoded
+		}
+
+		for index := 0; index < len(encoded) && index < len(unknown); index++ {
+			if unknown[index] != nil {
+				encoded[index] = mergeUnknownJson(encoded[index], unknown[index])
+			}
+		}
+
+		return encoded
+	}
+
+	return encoded
+}
+
+func encodeGenericJson(value interface{}, generic *interface{}) error {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Errorf("could not encode visualization params, error: %v", err)
+	}
+
+	return decodeGenericJson(data, generic)
+}
+
+func decodeGenericJson(data []byte, generic *interface{}) error {
+	if err := json.Unmarshal(data, generic); err != nil {
+		return fmt.Errorf("could not parse visualization params, error: %v", err)
+	}
+
+	return nil
+}
+
+func (params RawVisualizationParams) paramsForVersion(version string) interface{} {
+	return params
+}
+
+func (params *PointSeriesParams) paramsForVersion(version string) interface{} {
+	if goversion.Compare(version, "7.0.0", ">=") {
+		return params
+	}
+
+	encoded := &pointSeriesParams600{PointSeriesParams: params}
+	for _, series := range params.SeriesParams {
+		encoded.SeriesParams = append(encoded.SeriesParams, &seriesParams600{SeriesParams: series, Show: fmt.Sprint(series.Show)})
+	}
+
+	return encoded
+}
+
+func (params *SeriesParams) UnmarshalJSON(data []byte) error {
+	type plainSeriesParams SeriesParams
+	var raw struct {
+		*plainSeriesParams
+		Show interface{} `json:"show"`
+	}
+
+	raw.plainSeriesParams = (*plainSeriesParams)(params)
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+
+	params.Show = raw.Show == true || raw.Show == "true"
+	return nil
+}
+
+func (params *PieParams) paramsForVersion(version string) interface{} {
+	if goversion.Compare(version, "6.0.0", ">=") {
+		return params
+	}
+
+	encoded := *params
+	encoded.Labels = nil
+	return &encoded
+}
+
+func (params *MetricParams) paramsForVersion(version string) interface{} {
+	return params
+}
+
+func (params *TableParams) paramsForVersion(version string) interface{} {
+	if goversion.Compare(version, "7.0.0", ">=") {
+		return params
+	}
+
+	return &tableParams600{
+		PerPage:               params.PerPage,
+		ShowPartialRows:       params.ShowPartialRows,
+		ShowMeticsAtAllLevels: params.ShowMetricsAtAllLevels,
+		Sort:                  params.Sort,
+		ShowTotal:             params.ShowTotal,
+		TotalFunc:             params.TotalFunc,
+	}
+}
+
+func (params *TableParams) UnmarshalJSON(data []byte) error {
+	type plainTableParams TableParams
+	var raw struct {
+		*plainTableParams
+		ShowMeticsAtAllLevels *bool `json:"showMeticsAtAllLevels"`
+	}
+
+	raw.plainTableParams = (*plainTableParams)(params)
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+
+	if raw.ShowMeticsAtAllLevels != nil {
+		params.ShowMetricsAtAllLevels = *raw.ShowMeticsAtAllLevels
+	}
+
+	return nil
+}
+
+func (params *MarkdownParams) paramsForVersion(version string) interface{} {
+	if goversion.Compare(version, "6.0.0", ">=") {
+		return params
+	}
+
+	return &markdownParams553{Markdown: params.Markdown}
+}
+
+func (params *TagCloudParams) paramsForVersion(version string) interface{} {
+	return params
+}
+
+func (params *GaugeParams) paramsForVersion(version string) interface{} {
+	return params
+}
+
+// NewPointSeriesParams returns the default params kibana uses for a new line, area or bar chart
+func NewPointSeriesParams(visualizationType VisualizationType) *PointSeriesParams {
+	series := &SeriesParams{
+		Show:                   true,
+		Type:                   visualizationType,
+		Mode:                   "normal",
+		Data:                   &SeriesData{Label: "Count", Id: "1"},
+		ValueAxis:              "ValueAxis-1",
+		DrawLinesBetweenPoints: true,
+		ShowCircles:            true,
+	}
+
+	categoryLabels := &VisualizationLabels{Show: true, Truncate: 100}
+	switch visualizationType {
+	case VisualizationTypeArea:
+		series.Mode = "stacked"
+		series.Interpolate = "linear"
+	case VisualizationTypeBar:
+		series.Mode = "stacked"
+		categoryLabels.Filter = true
+	}
+
+	return &PointSeriesParams{
+		Type: visualizationType,
+		Grid: &VisualizationGrid{Style: map[string]interface{}{"color": "#eee"}},
+		CategoryAxes: []*VisualizationAxis{{
+			Id:       "CategoryAxis-1",
+			Type:     "category",
+			Position: "bottom",
+			Show:     true,
+			Style:    map[string]interface{}{},
+			Scale:    &VisualizationScale{Type: "linear"},
+			Labels:   categoryLabels,
+			Title:    &VisualizationTitle{},
+		}},
+		ValueAxes: []*VisualizationAxis{{
+			Id:       "ValueAxis-1",
+			Name:     "LeftAxis-1",
+			Type:     "value",
+			Position: "left",
+			Show:     true,
+			Style:    map[string]interface{}{},
+			Scale:    &VisualizationScale{Type: "linear", Mode: "normal"},
+			Labels:   &VisualizationLabels{Show: true, Truncate: 100},
+			Title:    &VisualizationTitle{Text: "Count"},
+		}},
+		SeriesParams:   []*SeriesParams{series},
+		AddTooltip:     true,
+		AddLegend:      true,
+		LegendPosition: "right",
+		Times:          []interface{}{},
+	}
+}
+
+func NewPieParams() *PieParams {
+	return &PieParams{
+		Type:           VisualizationTypePie,
+		AddTooltip:     true,
+		AddLegend:      true,
+		LegendPosition: "right",
+		IsDonut:        true,
+		Labels:         &PieLabels{Show: false, Values: true, LastLevel: true, Truncate: 100},
+	}
+}
+
+func NewMetricParams() *MetricParams {
+	return &MetricParams{
+		Type:       VisualizationTypeMetric,
+		AddTooltip: true,
+		Metric: &MetricStyle{
+			ColorSchema:     "Green to Red",
+			MetricColorMode: "None",
+			ColorsRange:     []*VisualizationRange{{From: 0, To: 10000}},
+			Labels:          &VisualizationShow{Show: true},
+			Style:           &MetricTextStyle{BgFill: "#000", FontSize: 60},
+		},
+	}
+}
+
+func NewTableParams() *TableParams {
+	return &TableParams{
+		PerPage:   10,
+		Sort:      &TableSort{},
+		TotalFunc: "sum",
+	}
+}
+
+func NewMarkdownParams(markdown string) *MarkdownParams {
+	return &MarkdownParams{FontSize: 12, Markdown: markdown}
+}
+
+func NewTagCloudParams() *TagCloudParams {
+	return &TagCloudParams{
+		Scale:       "linear",
+		Orientation: "single",
+		MinFontSize: 18,
+		MaxFontSize: 72,
+		ShowLabel:   true,
+	}
+}
+
+func NewGaugeParams() *GaugeParams {
+	return &GaugeParams{
+		Type:       VisualizationTypeGauge,
+		AddTooltip: true,
+		AddLegend:  true,
+		Gauge: &GaugeStyle{
+			ExtendRange:    true,
+			GaugeType:      "Arc",
+			GaugeStyle:     "Full",
+			BackStyle:      "Full",
+			Orientation:    "vertical",
+			ColorSchema:    "Green to Red",
+			GaugeColorMode: "Labels",
+			ColorsRange:    []*VisualizationRange{{From: 0, To: 50}, {From: 50, To: 75}, {From: 75, To: 100}},
+			Labels:         &VisualizationShow{Show: true, Color: "black"},
+			Scale:          &GaugeScale{Show: true, Color: "#333"},
+			Type:           "meter",
+			Style: &GaugeFillStyle{
+				BgWidth:    0.9,
+				Width:      0.9,
+				MaskBars:   50,
+				BgFill:     "#eee",
+				FontSize:   60,
+				LabelColor: true,
+			},
+		},
+	}
+}
+
+// NewCountAggregation returns the count metric kibana adds to a new visualization
+func NewCountAggregation() *VisualizationAggregation {
+	return &VisualizationAggregation{Id: "1", Enabled: true, Type: "count", Schema: "metric", Params: map[string]interface{}{}}
+}
+
+func NewVisualizationStateBuilder(visualizationType VisualizationType) *VisualizationStateBuilder {
+	return &VisualizationStateBuilder{visualizationType: visualizationType}
+}
+
+func NewLineVisualizationBuilder() *VisualizationStateBuilder {
+	return NewVisualizationStateBuilder(VisualizationTypeLine).WithParams(NewPointSeriesParams(VisualizationTypeLine))
+}
+
+func NewAreaVisualizationBuilder() *VisualizationStateBuilder {
+	return NewVisualizationStateBuilder(VisualizationTypeArea).WithParams(NewPointSeriesParams(VisualizationTypeArea))
+}
+
+func NewBarVisualizationBuilder() *VisualizationStateBuilder {
+	return NewVisualizationStateBuilder(VisualizationTypeBar).WithParams(NewPointSeriesParams(VisualizationTypeBar))
+}
+
+func NewPieVisualizationBuilder() *VisualizationStateBuilder {
+	return NewVisualizationStateBuilder(VisualizationTypePie).WithParams(NewPieParams())
+}
+
+func NewMetricVisualizationBuilder() *VisualizationStateBuilder {
+	return NewVisualizationStateBuilder(VisualizationTypeMetric).WithParams(NewMetricParams())
+}
+
+func NewTableVisualizationBuilder() *VisualizationStateBuilder {
+	return NewVisualizationStateBuilder(VisualizationTypeTable).WithParams(NewTableParams())
+}
+
+func NewMarkdownVisualizationBuilder(markdown string) *VisualizationStateBuilder {
+	return NewVisualizationStateBuilder(VisualizationTypeMarkdown).WithParams(NewMarkdownParams(markdown))
+}
+
+func NewTagCloudVisualizationBuilder() *VisualizationStateBuilder {
+	return NewVisualizationStateBuilder(VisualizationTypeTagCloud).WithParams(NewTagCloudParams())
+}
+
+func NewGaugeVisualizationBuilder() *VisualizationStateBuilder {
+	return NewVisualizationStateBuilder(VisualizationTypeGauge).WithParams(NewGaugeParams())
+}
+
+func (builder *VisualizationStateBuilder) WithTitle(title string) *VisualizationStateBuilder {
+	builder.title = title
+	return builder
+}
+
+func (builder *VisualizationStateBuilder) WithParams(params VisualizationParams) *VisualizationStateBuilder {
+	builder.params = params
+	return builder
+}
+
+func (builder *VisualizationStateBuilder) WithAggregation(aggregation *VisualizationAggregation) *VisualizationStateBuilder {
+	builder.aggs = append(builder.aggs, aggregation)
+	return builder
+}
+
+// WithTypedAggregation adds an aggregation created from typed params, see NewAggregation
+func (builder *VisualizationStateBuilder) WithTypedAggregation(id string, schema AggregationSchema, params AggregationParams) *VisualizationStateBuilder {
+	aggregation, err := NewAggregation(id, schema, params)
+	if err != nil {
+		builder.err = err
+		return builder
+	}
+
+	return builder.WithAggregation(aggregation)
+}
+
+// WithIndexPatternFields validates the aggregations against the fields of the index pattern the visualization uses
+func (builder *VisualizationStateBuilder) WithIndexPatternFields(fields []*IndexPatternField) *VisualizationStateBuilder {
+	builder.fields = fields
+	return builder
+}
+
+// Build creates the state, visualizations other than markdown, time series visual builder and vega without an
+// aggregation get a count metric
+func (builder *VisualizationStateBuilder) Build() (*VisualizationState, error) {
+	if builder.visualizationType == "" {
+		return nil, errors.New("visualization type is required")
+	}
+
+	if builder.err != nil {
+		return nil, builder.err
+	}
+
+	aggs := append([]*VisualizationAggregation{}, builder.aggs...)
+	if len(aggs) == 0 && !visualizationTypesWithoutAggregations[builder.visualizationType] {
+		aggs = append(aggs, NewCountAggregation())
+	}
+
+	params := builder.params
+	if params == nil {
+		params = RawVisualizationParams{}
+	}
+
+	if validator, ok := params.(interface{ validate() error }); ok {
+		if err := validator.validate(); err != nil {
+			return nil, err
+		}
+	}
+
+	state := &VisualizationState{
+		Title:  builder.title,
+		Type:   builder.visualizationType,
+		Params: params,
+		Aggs:   aggs,
+	}
+
+	if builder.fields != nil {
+		if err := state.Validate(builder.fields); err != nil {
+			return nil, err
+		}
+	}
+
+	return state, nil
+}
@@ -0,0 +1,177 @@
+package kibana
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func encodeVisualizationStateToMap(t *testing.T, state *VisualizationState, version string) map[string]interface{} {
+	encoded, err := state.Encode(version)
+	require.NoError(t, err)
+
+	result := map[string]interface{}{}
+	require.NoError(t, json.Unmarshal([]byte(encoded), &result))
+	return result
+}
+
+func Test_VisualizationState_builders_create_each_chart_type(t *testing.T) {
+	builders := map[VisualizationType]*VisualizationStateBuilder{
+		VisualizationTypeLine:     NewLineVisualizationBuilder(),
+		VisualizationTypeArea:     NewAreaVisualizationBuilder(),
+		VisualizationTypeBar:      NewBarVisualizationBuilder(),
+		VisualizationTypePie:      NewPieVisualizationBuilder(),
+		VisualizationTypeMetric:   NewMetricVisualizationBuilder(),
+		VisualizationTypeTable:    NewTableVisualizationBuilder(),
+		VisualizationTypeMarkdown: NewMarkdownVisualizationBuilder("# Errors"),
+		VisualizationTypeTagCloud: NewTagCloudVisualizationBuilder(),
+		VisualizationTypeGauge:    NewGaugeVisualizationBuilder(),
+	}
+
+	for visualizationType, builder := range builders {
+		state, err := builder.WithTitle("test").Build()
+		require.NoError(t, err)
+
+		for _, version := range []string{"5.5.3", DefaultKibanaVersion6, DefaultKibanaVersion7} {
+			encoded, err := state.Encode(version)
+			require.NoError(t, err)
+
+			decoded, err := ParseVisualizationState(encoded)
+			require.NoError(t, err, "%s %s", visualizationType, version)
+			assert.Equal(t, visualizationType, decoded.Type)
+			assert.IsType(t, state.Params, decoded.Params, "%s %s", visualizationType, version)
+			if version == DefaultKibanaVersion7 {
+				assert.Equal(t, state, decoded, "%s should decode to the state it was encoded from", visualizationType)
+			}
+		}
+
+		if visualizationType == VisualizationTypeMarkdown {
+			assert.Empty(t, state.Aggs)
+		} else {
+			assert.Equal(t, []*VisualizationAggregation{NewCountAggregation()}, state.Aggs)
+		}
+	}
+}
+
+func Test_VisualizationState_encodes_series_show_per_version(t *testing.T) {
+	state, err := NewAreaVisualizationBuilder().Build()
+	require.NoError(t, err)
+
+	series := encodeVisualizationStateToMap(t, state, DefaultKibanaVersion6)["params"].(map[string]interface{})["seriesParams"].([]interface{})[0].(map[string]interface{})
+	assert.Equal(t, "true", series["show"])
+	assert.Equal(t, "stacked", series["mode"])
+
+	series = encodeVisualizationStateToMap(t, state, DefaultKibanaVersion7)["params"].(map[string]interface{})["seriesParams"].([]interface{})[0].(map[string]interface{})
+	assert.Equal(t, true, series["show"])
+}
+
+func Test_VisualizationState_encodes_table_and_markdown_per_version(t *testing.T) {
+	table, err := NewTableVisualizationBuilder().Build()
+	require.NoError(t, err)
+	table.Params.(*TableParams).ShowMetricsAtAllLevels = true
+
+	params := encodeVisualizationStateToMap(t, table, "5.5.3")["params"].(map[string]interface{})
+	assert.Equal(t, true, params["showMeticsAtAllLevels"])
+	assert.NotContains(t, params, "showMetricsAtAllLevels")
+
+	params = encodeVisualizationStateToMap(t, table, DefaultKibanaVersion7)["params"].(map[string]interface{})
+	assert.Equal(t, true, params["showMetricsAtAllLevels"])
+
+	markdown, err := NewMarkdownVisualizationBuilder("# Errors").Build()
+	require.NoError(t, err)
+
+	encoded := encodeVisualizationStateToMap(t, markdown, "5.5.3")
+	assert.Equal(t, map[string]interface{}{"markdown": "# Errors"}, encoded["params"])
+	assert.Contains(t, encoded, "listeners")
+	assert.NotContains(t, encodeVisualizationStateToMap(t, markdown, DefaultKibanaVersion7), "listeners")
+}
+
+func Test_VisualizationState_parses_hand_written_state(t *testing.T) {
+	request, err := newTestVisualizationRequestBuilder().Build(DefaultKibanaVersion6)
+	require.NoError(t, err)
+
+	state, err := request.Attributes.State()
+	require.NoError(t, err)
+
+	assert.Equal(t, VisualizationTypeArea, state.Type)
+	params := state.Params.(*PointSeriesParams)
+	assert.True(t, params.SeriesParams[0].Show)
+	assert.Equal(t, "@timestamp date ranges", params.CategoryAxes[0].Title.Text)
+	require.Len(t, state.Aggs, 2)
+	assert.Equal(t, "date_range", state.Aggs[1].Type)
+	assert.Equal(t, "@timestamp", state.Aggs[1].Params["field"])
+
+	unknown, err := ParseVisualizationState(`{"title":"timelion","type":"timelion","params":{"expression":".es(*)"},"aggs":[]}`)
+	require.NoError(t, err)
+	assert.Equal(t, RawVisualizationParams{"expression": ".es(*)"}, unknown.Params)
+}
+
+func Test_VisualizationState_keeps_params_it_does_not_model(t *testing.T) {
+	state, err := ParseVisualizationState(`{"title":"errors","type":"line","params":{
+		"type":"line","addTooltip":true,"addLegend":true,"legendPosition":"right","times":[],"addTimeMarker":false,
+		"grid":{"categoryLines":false},
+		"categoryAxes":[{"id":"CategoryAxis-1","type":"category","position":"bottom","show":true,"style":{},"scale":{"type":"linear"},"labels":{"show":true,"filter":true,"truncate":100,"color":"black"},"title":{}}],
+		"valueAxes":[],"seriesParams":[],
+		"labels":{},
+		"thresholdLine":{"show":false,"value":10,"width":1,"style":"full","color":"#34130C"},
+		"dimensions":{"x":null,"y":[{"accessor":0,"format":{"id":"number"}}]}
+	},"aggs":[]}`)
+	require.NoError(t, err)
+
+	params := state.Params.(*PointSeriesParams)
+	params.LegendPosition = "bottom"
+	params.CategoryAxes[0].Labels.Truncate = 50
+
+	encoded := encodeVisualizationStateToMap(t, state, DefaultKibanaVersion7)["params"].(map[string]interface{})
+	assert.Equal(t, "bottom", encoded["legendPosition"])
+	assert.Equal(t, map[string]interface{}{}, encoded["labels"])
+	assert.Equal(t, map[string]interface{}{"show": false, "value": float64(10), "width": float64(1), "style": "full", "color": "#34130C"}, encoded["thresholdLine"])
+	assert.Equal(t, map[string]interface{}{"x": nil, "y": []interface{}{map[string]interface{}{"accessor": float64(0), "format": map[string]interface{}{"id": "number"}}}}, encoded["dimensions"])
+
+	labels := encoded["categoryAxes"].([]interface{})[0].(map[string]interface{})["labels"]
+	assert.Equal(t, map[string]interface{}{"show": true, "filter": true, "truncate": float64(50), "color": "black"}, labels, "the keys of nested params which are not modelled are kept too")
+
+	built, err := NewLineVisualizationBuilder().WithTitle("errors").WithAggregation(NewCountAggregation()).Build()
+	require.NoError(t, err)
+	assert.NotContains(t, encodeVisualizationStateToMap(t, built, DefaultKibanaVersion7)["params"], "thresholdLine")
+}
+
+func Test_VisualizationCreateWithState(t *testing.T) {
+	client := DefaultTestKibanaClient()
+
+	state, err := NewPieVisualizationBuilder().
+		WithAggregation(NewCountAggregation()).
+		WithAggregation(&VisualizationAggregation{
+			Id:      "2",
+			Enabled: true,
+			Type:    "terms",
+			Schema:  "segment",
+			Params:  map[string]interface{}{"field": "geo.src", "size": 5, "order": "desc", "orderBy": "1"},
+		}).
+		Build()
+	require.NoError(t, err)
+
+	request, err := NewVisualizationRequestBuilder().
+		WithTitle("Top sources").
+		WithState(state).
+		WithSavedSearchId("123").
+		Build(client.Config.KibanaVersion)
+	require.NoError(t, err)
+
+	visualization, err := client.Visualization().Create(request)
+	require.NoError(t, err)
+	defer client.Visualization().Delete(visualization.Id)
+
+	read, err := client.Visualization().GetById(visualization.Id)
+	require.NoError(t, err)
+
+	readState, err := read.Attributes.State()
+	require.NoError(t, err)
+	assert.Equal(t, "Top sources", readState.Title)
+	assert.Equal(t, VisualizationTypePie, readState.Type)
+	assert.True(t, readState.Params.(*PieParams).IsDonut)
+	require.Len(t, readState.Aggs, 2)
+	assert.Equal(t, "geo.src", readState.Aggs[1].Params["field"])
+}
@@ -0,0 +1,396 @@
+package kibana
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	goversion "github.com/mcuadros/go-version"
+	uuid "github.com/satori/go.uuid"
+)
+
+const (
+	TSVBPanelTypeTimeSeries TSVBPanelType = "timeseries"
+	TSVBPanelTypeMetric     TSVBPanelType = "metric"
+	TSVBPanelTypeTopN       TSVBPanelType = "top_n"
+	TSVBPanelTypeGauge      TSVBPanelType = "gauge"
+	TSVBPanelTypeMarkdown   TSVBPanelType = "markdown"
+	TSVBPanelTypeTable      TSVBPanelType = "table"
+)
+
+const (
+	TSVBSplitModeEverything TSVBSplitMode = "everything"
+	TSVBSplitModeTerms      TSVBSplitMode = "terms"
+	TSVBSplitModeFilter     TSVBSplitMode = "filter"
+	TSVBSplitModeFilters    TSVBSplitMode = "filters"
+)
+
+const (
+	TSVBChartTypeLine TSVBChartType = "line"
+	TSVBChartTypeBar  TSVBChartType = "bar"
+)
+
+type TSVBPanelType string
+
+type TSVBSplitMode string
+
+type TSVBChartType string
+
+// TSVBFlag is a boolean setting of a time series visual builder panel, kibana stores them as 0 or 1
+type TSVBFlag bool
+
+// TSVBQuery is a filter of a time series visual builder panel, kibana versions before 7.3 store only the query
+// text and only support lucene
+type TSVBQuery struct {
+	Query    string `json:"query"`
+	Language string `json:"language"`
+}
+
+// TSVBParams are the params of a time series visual builder visualization, Type picks the panel drawn from
+// the series
+type TSVBParams struct {
+	Id                 string            `json:"id"`
+	Type               TSVBPanelType     `json:"type"`
+	Series             []*TSVBSeries     `json:"series"`
+	TimeField          string            `json:"time_field"`
+	IndexPattern       string            `json:"index_pattern"`
+	Interval           string            `json:"interval"`
+	AxisPosition       string            `json:"axis_position"`
+	AxisFormatter      string            `json:"axis_formatter"`
+	AxisMin            string            `json:"axis_min,omitempty"`
+	AxisMax            string            `json:"axis_max,omitempty"`
+	ShowLegend         TSVBFlag          `json:"show_legend"`
+	ShowGrid           TSVBFlag          `json:"show_grid"`
+	DropLastBucket     TSVBFlag          `json:"drop_last_bucket"`
+	TooltipMode        string            `json:"tooltip_mode,omitempty"`
+	Filter             *TSVBQuery        `json:"filter,omitempty"`
+	Annotations        []*TSVBAnnotation `json:"annotations,omitempty"`
+	Markdown           string            `json:"markdown,omitempty"`
+	BackgroundColor    string            `json:"background_color,omitempty"`
+	PivotId            string            `json:"pivot_id,omitempty"`
+	PivotLabel         string            `json:"pivot_label,omitempty"`
+	GaugeMax           string            `json:"gauge_max,omitempty"`
+	IgnoreGlobalFilter TSVBFlag          `json:"ignore_global_filter"`
+}
+
+// TSVBSeries is a group of metrics drawn together, the last metric is the value drawn and the metrics before it
+// are inputs of pipeline metrics such as derivative. SplitMode splits the series by the terms of a field or by
+// filters.
+type TSVBSeries struct {
+	Id                   string             `json:"id"`
+	Label                string             `json:"label,omitempty"`
+	Color                string             `json:"color"`
+	Metrics              []*TSVBMetric      `json:"metrics"`
+	SplitMode            TSVBSplitMode      `json:"split_mode"`
+	SplitColorMode       string             `json:"split_color_mode,omitempty"`
+	TermsField           string             `json:"terms_field,omitempty"`
+	TermsSize            string             `json:"terms_size,omitempty"`
+	TermsOrderBy         string             `json:"terms_order_by,omitempty"`
+	Filter               *TSVBQuery         `json:"filter,omitempty"`
+	SplitFilters         []*TSVBSplitFilter `json:"split_filters,omitempty"`
+	ChartType            TSVBChartType      `json:"chart_type"`
+	Stacked              string             `json:"stacked"`
+	LineWidth            int                `json:"line_width"`
+	PointSize            int                `json:"point_size"`
+	Fill                 float64            `json:"fill"`
+	Formatter            string             `json:"formatter"`
+	AxisPosition         string             `json:"axis_position"`
+	SeparateAxis         TSVBFlag           `json:"separate_axis"`
+	OverrideIndexPattern TSVBFlag           `json:"override_index_pattern"`
+	SeriesIndexPattern   string             `json:"series_index_pattern,omitempty"`
+	SeriesTimeField      string             `json:"series_time_field,omitempty"`
+	SeriesInterval       string             `json:"series_interval,omitempty"`
+}
+
+// TSVBMetric is an aggregation of a series, Field is the field aggregated or for pipeline metrics the id of the
+// metric they read
+type TSVBMetric struct {
+	Id         string  `json:"id"`
+	Type       string  `json:"type"`
+	Field      string  `json:"field,omitempty"`
+	Percentile string  `json:"percentile,omitempty"`
+	Unit       string  `json:"unit,omitempty"`
+	Script     string  `json:"script,omitempty"`
+	Sigma      string  `json:"sigma,omitempty"`
+	Value      float64 `json:"value,omitempty"`
+}
+
+type TSVBSplitFilter struct {
+	Id     string     `json:"id"`
+	Label  string     `json:"label,omitempty"`
+	Color  string     `json:"color"`
+	Filter *TSVBQuery `json:"filter"`
+}
+
+// TSVBAnnotation marks the documents matching the query on a time series panel, Template is the tooltip with
+// the Fields of the document referred to as {{field}}
+type TSVBAnnotation struct {
+	Id                  string     `json:"id"`
+	Color               string     `json:"color"`
+	IndexPattern        string     `json:"index_pattern"`
+	TimeField           string     `json:"time_field"`
+	Icon                string     `json:"icon"`
+	Fields              string     `json:"fields,omitempty"`
+	Template            string     `json:"template,omitempty"`
+	QueryString         *TSVBQuery `json:"query_string,omitempty"`
+	IgnoreGlobalFilters TSVBFlag   `json:"ignore_global_filters"`
+	IgnorePanelFilters  TSVBFlag   `json:"ignore_panel_filters"`
+}
+
+func (flag TSVBFlag) MarshalJSON() ([]byte, error) {
+	if flag {
+		return []byte("1"), nil
+	}
+
+	return []byte("0"), nil
+}
+
+// UnmarshalJSON reads the 0 or 1 kibana stores as well as json booleans
+func (flag *TSVBFlag) UnmarshalJSON(data []byte) error {
+	var value interface{}
+	if err := json.Unmarshal(data, &value); err != nil {
+		return err
+	}
+
+	*flag = value == true || value == float64(1)
+	return nil
+}
+
+// UnmarshalJSON reads the query text kibana versions before 7.3 store as a lucene query
+func (query *TSVBQuery) UnmarshalJSON(data []byte) error {
+	var text string
+	if err := json.Unmarshal(data, &text); err == nil {
+		*query = TSVBQuery{Query: text, Language: QueryLanguageLucene}
+		return nil
+	}
+
+	type plainTSVBQuery TSVBQuery
+	return json.Unmarshal(data, (*plainTSVBQuery)(query))
+}
+
+// NewTSVBParams returns the defaults kibana uses for a new panel of the type reading from the index pattern
+func NewTSVBParams(panelType TSVBPanelType, indexPattern string, timeField string) *TSVBParams {
+	return &TSVBParams{
+		Id:             uuid.NewV4().String(),
+		Type:           panelType,
+		Series:         []*TSVBSeries{},
+		TimeField:      timeField,
+		IndexPattern:   indexPattern,
+		Interval:       "auto",
+		AxisPosition:   "left",
+		AxisFormatter:  "number",
+		ShowLegend:     true,
+		ShowGrid:       true,
+		DropLastBucket: true,
+		TooltipMode:    "show_all",
+	}
+}
+
+func (params *TSVBParams) WithSeries(series ...*TSVBSeries) *TSVBParams {
+	params.Series = append(params.Series, series...)
+	return params
+}
+
+// WithFilter restricts the panel to the documents matching the query
+func (params *TSVBParams) WithFilter(query string, language string) *TSVBParams {
+	params.Filter = &TSVBQuery{Query: query, Language: language}
+	return params
+}
+
+func (params *TSVBParams) WithAnnotation(annotation *TSVBAnnotation) *TSVBParams {
+	params.Annotations = append(params.Annotations, annotation)
+	return params
+}
+
+// WithMarkdown sets the template of a markdown panel, series values are referred to by their variable names
+func (params *TSVBParams) WithMarkdown(markdown string) *TSVBParams {
+	params.Markdown = markdown
+	return params
+}
+
+// NewTSVBSeries returns the defaults kibana uses for a new line series of the metrics, without metrics the
+// series counts the documents
+func NewTSVBSeries(metrics ...*TSVBMetric) *TSVBSeries {
+	if len(metrics) == 0 {
+		metrics = []*TSVBMetric{NewTSVBCountMetric()}
+	}
+
+	return &TSVBSeries{
+		Id:           uuid.NewV4().String(),
+		Color:        "#68BC00",
+		Metrics:      metrics,
+		SplitMode:    TSVBSplitModeEverything,
+		ChartType:    TSVBChartTypeLine,
+		Stacked:      "none",
+		LineWidth:    1,
+		PointSize:    1,
+		Fill:         0.5,
+		Formatter:    "number",
+		AxisPosition: "right",
+	}
+}
+
+func (series *TSVBSeries) WithLabel(label string) *TSVBSeries {
+	series.Label = label
+	return series
+}
+
+func (series *TSVBSeries) WithColor(color string) *TSVBSeries {
+	series.Color = color
+	return series
+}
+
+// WithChartType draws the series as bars or lines, stacked is none, stacked or percent
+func (series *TSVBSeries) WithChartType(chartType TSVBChartType, stacked string) *TSVBSeries {
+	series.ChartType = chartType
+	series.Stacked = stacked
+	return series
+}
+
+// SplitByTerms splits the series by the most frequent terms of the field, ordered by the metric with the id
+// orderBy or by count when it is empty
+func (series *TSVBSeries) SplitByTerms(field string, size int, orderBy string) *TSVBSeries {
+	if orderBy == "" {
+		orderBy = "_count"
+	}
+
+	series.SplitMode = TSVBSplitModeTerms
+	series.TermsField = field
+	series.TermsSize = fmt.Sprint(size)
+	series.TermsOrderBy = orderBy
+	return series
+}
+
+// SplitByFilter restricts the series to the documents matching the query
+func (series *TSVBSeries) SplitByFilter(query string, language string) *TSVBSeries {
+	series.SplitMode = TSVBSplitModeFilter
+	series.Filter = &TSVBQuery{Query: query, Language: language}
+	return series
+}
+
+// SplitByFilters splits the series into a series for each filter
+func (series *TSVBSeries) SplitByFilters(filters ...*TSVBSplitFilter) *TSVBSeries {
+	series.SplitMode = TSVBSplitModeFilters
+	series.SplitFilters = append(series.SplitFilters, filters...)
+	return series
+}
+
+// WithIndexPattern reads the series from another index pattern than the panel
+func (series *TSVBSeries) WithIndexPattern(indexPattern string, timeField string) *TSVBSeries {
+	series.OverrideIndexPattern = true
+	series.SeriesIndexPattern = indexPattern
+	series.SeriesTimeField = timeField
+	return series
+}
+
+// NewTSVBMetric creates a metric of the aggregation type, such as avg, max or cardinality, over the field
+func NewTSVBMetric(metricType string, field string) *TSVBMetric {
+	return &TSVBMetric{Id: uuid.NewV4().String(), Type: metricType, Field: field}
+}
+
+func NewTSVBCountMetric() *TSVBMetric {
+	return NewTSVBMetric("count", "")
+}
+
+func NewTSVBSplitFilter(label string, color string, query string, language string) *TSVBSplitFilter {
+	return &TSVBSplitFilter{
+		Id:     uuid.NewV4().String(),
+		Label:  label,
+		Color:  color,
+		Filter: &TSVBQuery{Query: query, Language: language},
+	}
+}
+
+// NewTSVBAnnotation marks the documents of the index pattern matching the query, the annotations ignore the
+// global and panel filters
+func NewTSVBAnnotation(indexPattern string, timeField string, query string, language string, template string, fields string) *TSVBAnnotation {
+	return &TSVBAnnotation{
+		Id:                  uuid.NewV4().String(),
+		Color:               "#F00",
+		IndexPattern:        indexPattern,
+		TimeField:           timeField,
+		Icon:                "fa-tag",
+		Fields:              fields,
+		Template:            template,
+		QueryString:         &TSVBQuery{Query: query, Language: language},
+		IgnoreGlobalFilters: true,
+		IgnorePanelFilters:  true,
+	}
+}
+
+// validate checks the panel has series and the series have the settings of their split mode
+func (params *TSVBParams) validate() error {
+	if len(params.Series) == 0 {
+		return errors.New("invalid time series visual builder params, the panel has no series")
+	}
+
+	for _, series := range params.Series {
+		switch {
+		case len(series.Metrics) == 0:
+			return fmt.Errorf("invalid time series visual builder series %s, the series has no metrics", series.Id)
+		case series.SplitMode == TSVBSplitModeTerms && series.TermsField == "":
+			return fmt.Errorf("invalid time series visual builder series %s, splitting by terms requires a field", series.Id)
+		case series.SplitMode == TSVBSplitModeFilter && series.Filter == nil:
+			return fmt.Errorf("invalid time series visual builder series %s, splitting by a filter requires a query", series.Id)
+		case series.SplitMode == TSVBSplitModeFilters && len(series.SplitFilters) == 0:
+			return fmt.Errorf("invalid time series visual builder series %s, splitting by filters requires filters", series.Id)
+		}
+	}
+
+	return nil
+}
+
+// paramsForVersion replaces the queries of the filters by their text before kibana 7.3
+func (params *TSVBParams) paramsForVersion(version string) interface{} {
+	encoded := *params
+	if encoded.Series == nil {
+		encoded.Series = []*TSVBSeries{}
+	}
+
+	if goversion.Compare(version, "7.3.0", ">=") {
+		return &encoded
+	}
+
+	data, err := json.Marshal(&encoded)
+	if err != nil {
+		return &encoded
+	}
+
+	legacy := map[string]interface{}{}
+	json.Unmarshal(data, &legacy)
+	tsvbQueryText(legacy, "filter")
+	for _, series := range tsvbObjects(legacy["series"]) {
+		tsvbQueryText(series, "filter")
+		for _, splitFilter := range tsvbObjects(series["split_filters"]) {
+			tsvbQueryText(splitFilter, "filter")
+		}
+	}
+	for _, annotation := range tsvbObjects(legacy["annotations"]) {
+		tsvbQueryText(annotation, "query_string")
+	}
+
+	return legacy
+}
+
+func tsvbObjects(value interface{}) []map[string]interface{} {
+	var objects []map[string]interface{}
+	values, _ := value.([]interface{})
+	for _, value := range values {
+		if object, ok := value.(map[string]interface{}); ok {
+			objects = append(objects, object)
+		}
+	}
+
+	return objects
+}
+
+func tsvbQueryText(object map[string]interface{}, key string) {
+	if query, ok := object[key].(map[string]interface{}); ok {
+		object[key] = query["query"]
+	}
+}
+
+// NewTSVBVisualizationBuilder creates a time series visual builder visualization, which has no aggregations
+func NewTSVBVisualizationBuilder(params *TSVBParams) *VisualizationStateBuilder {
+	return NewVisualizationStateBuilder(VisualizationTypeTSVB).WithParams(params)
+}
@@ -0,0 +1,103 @@
+package kibana
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestTSVBParams() *TSVBParams {
+	bytes := NewTSVBMetric("avg", "bytes")
+	return NewTSVBParams(TSVBPanelTypeTimeSeries, "logstash-*", "@timestamp").
+		WithFilter("response:500", QueryLanguageLucene).
+		WithSeries(
+			NewTSVBSeries(bytes, NewTSVBMetric("derivative", bytes.Id)).WithLabel("Bytes by country").SplitByTerms("geo.src", 5, bytes.Id),
+			NewTSVBSeries().WithChartType(TSVBChartTypeBar, "stacked").SplitByFilters(
+				NewTSVBSplitFilter("Errors", "#F00", "response >= 500", QueryLanguageKuery),
+				NewTSVBSplitFilter("Success", "#0F0", "response < 400", QueryLanguageKuery),
+			),
+		).
+		WithAnnotation(NewTSVBAnnotation("deployments-*", "@timestamp", "service:web", QueryLanguageLucene, "Deployed {{version}}", "version"))
+}
+
+func Test_TSVBVisualization_encodes_filters_per_version(t *testing.T) {
+	state, err := NewTSVBVisualizationBuilder(newTestTSVBParams()).WithTitle("Traffic").Build()
+	require.NoError(t, err)
+	assert.Empty(t, state.Aggs, "time series visual builder visualizations have no aggregations")
+
+	encoded := encodeVisualizationStateToMap(t, state, DefaultKibanaVersion7)
+	params := encoded["params"].(map[string]interface{})
+	assert.Equal(t, "metrics", encoded["type"])
+	assert.Equal(t, map[string]interface{}{"query": "response:500", "language": "lucene"}, params["filter"])
+	assert.Equal(t, float64(1), params["show_legend"])
+	assert.Equal(t, float64(0), params["ignore_global_filter"])
+
+	series := params["series"].([]interface{})
+	require.Len(t, series, 2)
+	assert.Equal(t, "terms", series[0].(map[string]interface{})["split_mode"])
+	assert.Equal(t, "5", series[0].(map[string]interface{})["terms_size"])
+	splitFilters := series[1].(map[string]interface{})["split_filters"].([]interface{})
+	assert.Equal(t, map[string]interface{}{"query": "response >= 500", "language": "kuery"}, splitFilters[0].(map[string]interface{})["filter"])
+
+	encoded = encodeVisualizationStateToMap(t, state, DefaultKibanaVersion6)
+	params = encoded["params"].(map[string]interface{})
+	assert.Equal(t, "response:500", params["filter"], "kibana versions before 7.3 store the query text")
+	splitFilters = params["series"].([]interface{})[1].(map[string]interface{})["split_filters"].([]interface{})
+	assert.Equal(t, "response >= 500", splitFilters[0].(map[string]interface{})["filter"])
+	annotation := params["annotations"].([]interface{})[0].(map[string]interface{})
+	assert.Equal(t, "service:web", annotation["query_string"])
+
+	visualizationState, err := state.Encode(DefaultKibanaVersion7)
+	require.NoError(t, err)
+
+	decoded, err := ParseVisualizationState(visualizationState)
+	require.NoError(t, err)
+	assert.Equal(t, state.Params, decoded.Params)
+
+	visualizationState, err = state.Encode(DefaultKibanaVersion6)
+	require.NoError(t, err)
+
+	decoded, err = ParseVisualizationState(visualizationState)
+	require.NoError(t, err)
+	decodedFilter := decoded.Params.(*TSVBParams).Series[1].SplitFilters[0].Filter
+	assert.Equal(t, &TSVBQuery{Query: "response >= 500", Language: QueryLanguageLucene}, decodedFilter, "query text is decoded as lucene")
+}
+
+func Test_TSVBVisualization_create(t *testing.T) {
+	fake := NewFakeKibana(DefaultKibanaVersion7)
+	defer fake.Close()
+
+	client := NewClient(fake.Config())
+	state, err := NewTSVBVisualizationBuilder(newTestTSVBParams()).Build()
+	require.NoError(t, err)
+
+	request, err := NewVisualizationRequestBuilder().WithTitle("Traffic").WithState(state).Build(client.Config.KibanaVersion)
+	require.NoError(t, err)
+
+	created, err := client.Visualization().Create(request)
+	require.NoError(t, err)
+
+	visualization, err := client.Visualization().GetById(created.Id)
+	require.NoError(t, err)
+
+	decoded, err := visualization.Attributes.State()
+	require.NoError(t, err)
+	assert.Equal(t, "Traffic", decoded.Title)
+	assert.Equal(t, state.Params, decoded.Params)
+}
+
+func Test_TSVBParams_validation(t *testing.T) {
+	_, err := NewTSVBVisualizationBuilder(NewTSVBParams(TSVBPanelTypeMetric, "logstash-*", "@timestamp")).Build()
+	assert.EqualError(t, err, "invalid time series visual builder params, the panel has no series")
+
+	series := NewTSVBSeries()
+	series.SplitMode = TSVBSplitModeTerms
+	_, err = NewTSVBVisualizationBuilder(NewTSVBParams(TSVBPanelTypeTopN, "logstash-*", "@timestamp").WithSeries(series)).Build()
+	assert.EqualError(t, err, "invalid time series visual builder series "+series.Id+", splitting by terms requires a field")
+
+	series = NewTSVBSeries()
+	series.SplitMode = TSVBSplitModeFilters
+	_, err = NewTSVBVisualizationBuilder(NewTSVBParams(TSVBPanelTypeTopN, "logstash-*", "@timestamp").WithSeries(series)).Build()
+	assert.EqualError(t, err, "invalid time series visual builder series "+series.Id+", splitting by filters requires filters")
+}
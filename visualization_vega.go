@@ -0,0 +1,142 @@
+package kibana
+
+import (
+	"encoding/json"
+
+	goversion "github.com/mcuadros/go-version"
+)
+
+// VegaParams are the params of a vega visualization, Spec is the vega or vega-lite specification as json or
+// hjson. Specs created with a VegaSpec get the schema of the vega version of the kibana they are saved to.
+type VegaParams struct {
+	Spec string `json:"spec"`
+
+	spec *VegaSpec
+}
+
+// VegaSpec is a vega or vega-lite specification, kibana replaces the elasticsearch data urls of the
+// specification with the results of the queries
+type VegaSpec struct {
+	lite       bool
+	properties map[string]interface{}
+}
+
+// VegaElasticsearchData is the url of a data source of a vega specification which queries elasticsearch, Body
+// is the search request body. TimeField restricts the query to the time range of the dashboard and Context adds
+// the dashboard query and filters to the query.
+type VegaElasticsearchData struct {
+	Index     string
+	TimeField string
+	Context   bool
+	Body      map[string]interface{}
+}
+
+// NewVegaParams uses the specification as it is written, which sets the vega version with its $schema
+func NewVegaParams(spec string) *VegaParams {
+	return &VegaParams{Spec: spec}
+}
+
+// NewVegaSpec creates an empty vega specification
+func NewVegaSpec() *VegaSpec {
+	return &VegaSpec{properties: map[string]interface{}{}}
+}
+
+// NewVegaLiteSpec creates an empty vega-lite specification
+func NewVegaLiteSpec() *VegaSpec {
+	return &VegaSpec{lite: true, properties: map[string]interface{}{}}
+}
+
+// With sets a top level property of the specification, such as mark, encoding, marks or scales
+func (spec *VegaSpec) With(name string, value interface{}) *VegaSpec {
+	spec.properties[name] = value
+	return spec
+}
+
+// WithTitle sets the title shown above the chart
+func (spec *VegaSpec) WithTitle(title string) *VegaSpec {
+	return spec.With("title", title)
+}
+
+// WithElasticsearchData queries elasticsearch for the data of the chart, property is the path of the values in
+// the search response such as aggregations.time_buckets.buckets. Vega-lite specifications have a single data
+// source, vega specifications add a data source named name.
+func (spec *VegaSpec) WithElasticsearchData(name string, data *VegaElasticsearchData, property string) *VegaSpec {
+	url := map[string]interface{}{"index": data.Index, "body": data.Body}
+	if data.Context {
+		url["%context%"] = true
+	}
+
+	if data.TimeField != "" {
+		url["%timefield%"] = data.TimeField
+	}
+
+	source := map[string]interface{}{"url": url}
+	if property != "" {
+		source["format"] = map[string]interface{}{"property": property}
+	}
+
+	if spec.lite {
+		return spec.With("data", source)
+	}
+
+	source["name"] = name
+	sources, _ := spec.properties["data"].([]interface{})
+	return spec.With("data", append(sources, source))
+}
+
+// WithVegaLiteEncoding sets the encoding of a channel of a vega-lite specification, such as x or color, to the
+// field of the values of the given type, such as temporal or quantitative
+func (spec *VegaSpec) WithVegaLiteEncoding(channel string, field string, fieldType string) *VegaSpec {
+	encoding, _ := spec.properties["encoding"].(map[string]interface{})
+	if encoding == nil {
+		encoding = map[string]interface{}{}
+	}
+
+	encoding[channel] = map[string]interface{}{"field": field, "type": fieldType}
+	return spec.With("encoding", encoding)
+}
+
+// Params creates the params of a visualization drawing the specification
+func (spec *VegaSpec) Params() *VegaParams {
+	return &VegaParams{spec: spec}
+}
+
+// specForVersion encodes the specification with the schema of the vega version kibana bundles, kibana 6.x
+// bundles vega 3 and vega-lite 2, kibana 7.0 vega 4 and kibana 7.9 vega 5 and vega-lite 4
+func (spec *VegaSpec) specForVersion(version string) string {
+	properties := map[string]interface{}{}
+	for name, value := range spec.properties {
+		properties[name] = value
+	}
+
+	schema := "https://vega.github.io/schema/vega/v5.json"
+	switch {
+	case spec.lite && goversion.Compare(version, "7.9.0", "<"):
+		schema = "https://vega.github.io/schema/vega-lite/v2.json"
+	case spec.lite && goversion.Compare(version, "8.0.0", "<"):
+		schema = "https://vega.github.io/schema/vega-lite/v4.json"
+	case spec.lite:
+		schema = "https://vega.github.io/schema/vega-lite/v5.json"
+	case goversion.Compare(version, "7.0.0", "<"):
+		schema = "https://vega.github.io/schema/vega/v3.json"
+	case goversion.Compare(version, "7.9.0", "<"):
+		schema = "https://vega.github.io/schema/vega/v4.json"
+	}
+
+	properties["$schema"] = schema
+	data, _ := json.MarshalIndent(properties, "", "  ")
+	return string(data)
+}
+
+func (params *VegaParams) paramsForVersion(version string) interface{} {
+	if params.spec == nil {
+		return params
+	}
+
+	return &VegaParams{Spec: params.spec.specForVersion(version)}
+}
+
+// NewVegaVisualizationBuilder creates a vega visualization, which has no aggregations
+func NewVegaVisualizationBuilder(params *VegaParams) *VisualizationStateBuilder {
+	return NewVisualizationStateBuilder(VisualizationTypeVega).WithParams(params)
+}
@@ -0,0 +1,90 @@
+package kibana
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestVegaLiteSpec() *VegaSpec {
+	return NewVegaLiteSpec().
+		WithTitle("Requests").
+		WithElasticsearchData("requests", &VegaElasticsearchData{
+			Index:     "logstash-*",
+			TimeField: "@timestamp",
+			Context:   true,
+			Body: map[string]interface{}{
+				"aggs": map[string]interface{}{
+					"time_buckets": map[string]interface{}{
+						"date_histogram": map[string]interface{}{"field": "@timestamp", "interval": map[string]interface{}{"%autointerval%": true}},
+					},
+				},
+				"size": 0,
+			},
+		}, "aggregations.time_buckets.buckets").
+		With("mark", "line").
+		WithVegaLiteEncoding("x", "key", "temporal").
+		WithVegaLiteEncoding("y", "doc_count", "quantitative")
+}
+
+func Test_VegaVisualization_encodes_the_schema_per_version(t *testing.T) {
+	state, err := NewVegaVisualizationBuilder(newTestVegaLiteSpec().Params()).WithTitle("Requests").Build()
+	require.NoError(t, err)
+	assert.Empty(t, state.Aggs, "vega visualizations have no aggregations")
+
+	schemas := map[string]string{
+		"6.2.0":               "https://vega.github.io/schema/vega-lite/v2.json",
+		DefaultKibanaVersion7: "https://vega.github.io/schema/vega-lite/v2.json",
+		"7.10.2":              "https://vega.github.io/schema/vega-lite/v4.json",
+		DefaultKibanaVersion8: "https://vega.github.io/schema/vega-lite/v5.json",
+	}
+
+	for version, schema := range schemas {
+		encoded := encodeVisualizationStateToMap(t, state, version)
+		assert.Equal(t, "vega", encoded["type"])
+
+		spec := map[string]interface{}{}
+		require.NoError(t, json.Unmarshal([]byte(encoded["params"].(map[string]interface{})["spec"].(string)), &spec))
+		assert.Equal(t, schema, spec["$schema"], version)
+		assert.Equal(t, "line", spec["mark"])
+
+		data := spec["data"].(map[string]interface{})
+		assert.Equal(t, map[string]interface{}{"property": "aggregations.time_buckets.buckets"}, data["format"])
+		assert.Equal(t, "@timestamp", data["url"].(map[string]interface{})["%timefield%"])
+		assert.Equal(t, true, data["url"].(map[string]interface{})["%context%"])
+	}
+}
+
+func Test_VegaSpec_data_sources(t *testing.T) {
+	spec := NewVegaSpec().
+		WithElasticsearchData("errors", &VegaElasticsearchData{Index: "logstash-*", Body: map[string]interface{}{"size": 10}}, "hits.hits").
+		WithElasticsearchData("deployments", &VegaElasticsearchData{Index: "deployments-*", Body: map[string]interface{}{"size": 10}}, "")
+
+	encoded := map[string]interface{}{}
+	require.NoError(t, json.Unmarshal([]byte(spec.specForVersion("7.10.2")), &encoded))
+	assert.Equal(t, "https://vega.github.io/schema/vega/v5.json", encoded["$schema"])
+
+	data := encoded["data"].([]interface{})
+	require.Len(t, data, 2, "vega specifications have named data sources")
+	assert.Equal(t, "errors", data[0].(map[string]interface{})["name"])
+	assert.Equal(t, "deployments", data[1].(map[string]interface{})["name"])
+	assert.NotContains(t, data[1], "format")
+}
+
+func Test_VegaVisualization_raw_spec(t *testing.T) {
+	spec := `{$schema: "https://vega.github.io/schema/vega-lite/v2.json", mark: "point"}`
+	state, err := NewVegaVisualizationBuilder(NewVegaParams(spec)).Build()
+	require.NoError(t, err)
+
+	encoded := encodeVisualizationStateToMap(t, state, DefaultKibanaVersion7)
+	assert.Equal(t, map[string]interface{}{"spec": spec}, encoded["params"], "a written specification is saved unchanged")
+
+	decoded, err := ParseVisualizationState(`{"title":"Points","type":"vega","params":{"spec":"{mark: \"point\"}"},"aggs":[]}`)
+	require.NoError(t, err)
+	assert.Equal(t, &VegaParams{Spec: `{mark: "point"}`}, decoded.Params)
+
+	_, err = state.Encode("5.5.3")
+	assert.EqualError(t, err, "vega visualizations are not supported by kibana 5.5.3, they require kibana 6.2 or later")
+}